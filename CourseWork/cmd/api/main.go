@@ -2,17 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/controller"
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/handlers"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository/audit"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository/candles"
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository/orders"
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository/users"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/secret"
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/service"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -29,6 +36,12 @@ const (
 	dbPSWD                = "DB_PSWD"
 	dbName                = "db_Name"
 	dbPort                = "DB_Port"
+	wsURL                 = "WS_URL"
+	orderURL              = "ORDER_URL"
+	chartsURL             = "CHARTS_URL"
+	masterKeyName         = "MASTER_KEY"
+	reconcileMinutes      = "RECONCILE_MINUTES"
+	metricsAddr           = "METRICS_ADDR"
 	logFile               = "logs.txt"
 )
 
@@ -42,24 +55,41 @@ func main() {
 	mw := io.MultiWriter(os.Stdout, file)
 	log.SetOutput(mw)
 
-	port, key, tg, reconnections, hours, orderConfig, err := loadConfig()
+	port, key, tg, reconnections, hours, dbConfig, err := loadConfig()
 	if err != nil {
 		return
 	}
-	orderStorage := orders.OrderStorage{Config: *orderConfig}
+	orderStorage := orders.OrderStorage{Config: *dbConfig}
+	candleStorage := candles.CandleStorage{Config: *dbConfig}
+	auditStorage := audit.AuditStorage{Config: *dbConfig}
 
-	userStorage, err := users.NewUserStorage(key, hours)
+	masterKey := loadOptionalString(masterKeyName, os.Getenv(masterKeyName))
+	if len(masterKey) == 0 {
+		log.Fatalf("%s is empty, generate it by go run cmd/masterkey/main.go", masterKeyName)
+	}
+	keeper, err := secret.NewKeeper(masterKey)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	userStorage, err := users.NewUserDBStorage(*dbConfig, key, hours)
 	if err != nil {
 		log.Fatalf(err.Error())
 	}
+	defer userStorage.Shutdown()
 
 	tgBot, err := service.NewTelegramBot(tg)
 	if err != nil {
 		log.Fatalf(err.Error())
 	}
 
-	trader := service.NewAlgoTrader(userStorage, &orderStorage, log, reconnections)
+	trader := service.NewAlgoTrader(userStorage, &orderStorage, &candleStorage,
+		&auditStorage, loadEndpoints(), keeper, log, reconnections)
 	trader.AddMessageWriter(tgBot)
+	if trader.Reconciler.Interval, err = loadInterval(reconcileMinutes,
+		service.DefaultReconcileInterval); err != nil {
+		log.Fatalf(err.Error())
+	}
 	if err = trader.Run(); err != nil {
 		log.Fatalf(err.Error())
 	}
@@ -73,6 +103,14 @@ func main() {
 		}
 	}()
 
+	if addr := loadOptionalString(metricsAddr, ""); len(addr) != 0 {
+		go func() {
+			if err := srv.RunMetrics(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("ERROR: running metrics server is failed <%s>", err)
+			}
+		}()
+	}
+
 	log.Print("Server is running")
 
 	quit := make(chan os.Signal, 1)
@@ -106,7 +144,29 @@ func loadString(name string) (string, error) {
 	return value, nil
 }
 
-func loadConfig() (string, string, string, int64, int64, *orders.ConnectionConfig, error) {
+// loadOptionalString returns default value if variable isn't set.
+func loadOptionalString(name, defaultValue string) string {
+	value, err := loadString(name)
+	if err != nil || len(value) == 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// loadInterval returns default interval if variable of minutes isn't set.
+func loadInterval(name string, defaultInterval time.Duration) (time.Duration, error) {
+	value := loadOptionalString(name, "")
+	if len(value) == 0 {
+		return defaultInterval, nil
+	}
+	minutes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || minutes <= 0 {
+		return 0, fmt.Errorf("invalid %s value", name)
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+func loadConfig() (string, string, string, int64, int64, *repository.ConnectionConfig, error) {
 	viper.SetConfigFile(configPath)
 	if err := viper.ReadInConfig(); err != nil {
 		return "", "", "", 0, 0, nil, fmt.Errorf("can't load config: %w", err)
@@ -138,7 +198,7 @@ func loadConfig() (string, string, string, int64, int64, *orders.ConnectionConfi
 	return port, key, tg, reconnections, hours, connectionConfig, nil
 }
 
-func loadDBVars() (*orders.ConnectionConfig, error) {
+func loadDBVars() (*repository.ConnectionConfig, error) {
 	user, err := loadString(dbUser)
 	if err != nil {
 		return nil, err
@@ -155,7 +215,7 @@ func loadDBVars() (*orders.ConnectionConfig, error) {
 	if err != nil {
 		return nil, err
 	}
-	orderConfig := &orders.ConnectionConfig{
+	orderConfig := &repository.ConnectionConfig{
 		Username: user,
 		Password: password,
 		NameDB:   name,
@@ -163,3 +223,11 @@ func loadDBVars() (*orders.ConnectionConfig, error) {
 	}
 	return orderConfig, nil
 }
+
+func loadEndpoints() service.Endpoints {
+	endpoints := service.DefaultEndpoints()
+	endpoints.SocketURL = loadOptionalString(wsURL, endpoints.SocketURL)
+	endpoints.OrderURL = loadOptionalString(orderURL, endpoints.OrderURL)
+	endpoints.ChartsURL = loadOptionalString(chartsURL, endpoints.ChartsURL)
+	return endpoints
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	csvFormat  = "csv"
+	jsonFormat = "json"
+)
+
+func main() {
+	log := logrus.New()
+
+	input := flag.String("input", "", "path to csv or json file with candles")
+	format := flag.String("format", "", "candles format: csv or json, file extension is used by default")
+	pairName := flag.String("pair", "PI_XBTUSD", "pair name")
+	interval := flag.String("interval", string(domain.Candle1m), "candles interval")
+	indicatorName := flag.String("indicator", service.DonchianName,
+		"indicator name: "+strings.Join(service.IndicatorNames(), ", "))
+	params := flag.String("params", "{}", "indicator parameters as json, e.g. {\"period\": 14}")
+	limit := flag.Float64("limit", 0, "limit price fraction")
+	size := flag.Int64("size", 0, "fixed order size, it replaces sizing")
+	sizing := flag.String("sizing", "{}", "order sizing as json, e.g. {\"mode\": \"notional\", \"notional\": 1000}")
+	asJSON := flag.Bool("json", false, "print report as json")
+	flag.Parse()
+
+	candles, err := loadCandles(*input, *format, domain.CandleInterval(*interval))
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+	config := domain.Config{
+		PairName:      *pairName,
+		PairInterval:  domain.CandleInterval(*interval),
+		IndicatorName: *indicatorName,
+		Limit:         *limit,
+	}
+	if err = json.Unmarshal([]byte(*params), &config.Params); err != nil {
+		log.Fatalf("can't parse indicator parameters <%s>", err)
+	}
+	if err = json.Unmarshal([]byte(*sizing), &config.Sizing); err != nil {
+		log.Fatalf("can't parse sizing <%s>", err)
+	}
+	backtester, err := service.NewBacktester(config, *size)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+	report, err := backtester.Run(candles)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf(err.Error())
+		}
+		fmt.Println(string(data))
+		return
+	}
+	for _, trade := range report.Trades {
+		fmt.Println(trade)
+	}
+	fmt.Println(report)
+}
+
+// loadCandles reads candles from file by format.
+func loadCandles(path, format string, interval domain.CandleInterval) ([]domain.Candle, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("input file is empty")
+	}
+	if len(format) == 0 {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't open candles <%w>", err)
+	}
+	defer file.Close()
+
+	switch format {
+	case csvFormat:
+		return domain.ReadCandlesCSV(file, interval)
+	case jsonFormat:
+		return domain.ReadCandlesJSON(file, interval)
+	default:
+		return nil, fmt.Errorf("unsupported candles format <%s>", format)
+	}
+}
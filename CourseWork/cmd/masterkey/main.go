@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/secret"
+)
+
+// main prints new random master key for MASTER_KEY variable.
+func main() {
+	masterKey, err := secret.GenerateMasterKey()
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+	fmt.Println(masterKey)
+}
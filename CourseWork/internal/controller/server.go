@@ -6,33 +6,52 @@ import (
 	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/handlers"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/metrics"
 )
 
 // Server represents http server structure with handler's implementations.
+// Metrics are served by separate http server, so they aren't exposed on public port.
 type Server struct {
-	httpServer *http.Server
-	handler    handlers.Handler
+	httpServer    *http.Server
+	metricsServer *http.Server
+	handler       handlers.Handler
 }
 
 // NewServer creates Server pointer.
 func NewServer(handler handlers.Handler) *Server {
-	return &Server{handler: handler}
+	return &Server{
+		httpServer:    &http.Server{},
+		metricsServer: &http.Server{},
+		handler:       handler,
+	}
 }
 
 // Run runs http server on chosen port with handlers from Server and sets timeouts.
 func (s *Server) Run(port string) error {
-	s.httpServer = &http.Server{
-		Addr:         ":" + port,
-		Handler:      s.handler.InitRoutes(),
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-	}
+	s.httpServer.Addr = ":" + port
+	s.httpServer.Handler = s.handler.InitRoutes()
+	s.httpServer.ReadTimeout = 10 * time.Second
+	s.httpServer.WriteTimeout = 10 * time.Second
 
 	return s.httpServer.ListenAndServe()
 }
 
-// Shutdown gracefully stops all handler's goroutines and http server.
+// RunMetrics runs http server of Prometheus metrics on chosen address,
+// e.g. localhost:9100 keeps metrics private to the host.
+func (s *Server) RunMetrics(addr string) error {
+	s.metricsServer.Addr = addr
+	s.metricsServer.Handler = metrics.Handler()
+	s.metricsServer.ReadTimeout = 10 * time.Second
+	s.metricsServer.WriteTimeout = 10 * time.Second
+
+	return s.metricsServer.ListenAndServe()
+}
+
+// Shutdown gracefully stops all handler's goroutines, http server and metrics server.
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.handler.Trader.ShutDown()
+	if err := s.metricsServer.Shutdown(ctx); err != nil {
+		return err
+	}
 	return s.httpServer.Shutdown(ctx)
 }
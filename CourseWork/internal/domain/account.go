@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	LongSide             = "long"
+	ShortSide            = "short"
+	UnknownFill          = "unknown_fill"
+	FillAmountMismatch   = "fill_amount_mismatch"
+	PositionSizeMismatch = "position_size_mismatch"
+)
+
+// Fill describes order's execution on stock market.
+type Fill struct {
+	FillID   string    `json:"fill_id"`
+	Symbol   string    `json:"symbol"`
+	Side     string    `json:"side"`
+	OrderID  string    `json:"order_id"`
+	Size     float64   `json:"size"`
+	Price    float64   `json:"price"`
+	FillTime time.Time `json:"fillTime"`
+	FillType string    `json:"fillType"`
+}
+
+// FillsResponse describes incomplete JSON response of fills.
+type FillsResponse struct {
+	Result string `json:"result"`
+	Fills  []Fill `json:"fills"`
+}
+
+// OpenPosition describes position on stock market, Size is always positive.
+type OpenPosition struct {
+	Side     string    `json:"side"`
+	Symbol   string    `json:"symbol"`
+	Price    float64   `json:"price"`
+	FillTime time.Time `json:"fillTime"`
+	Size     float64   `json:"size"`
+}
+
+// SignedSize returns size, which is negative for short position like Position's size.
+func (position OpenPosition) SignedSize() int64 {
+	size := int64(math.Round(position.Size))
+	if position.Side == ShortSide {
+		return -size
+	}
+	return size
+}
+
+// OpenPositionsResponse describes incomplete JSON response of open positions.
+type OpenPositionsResponse struct {
+	Result        string         `json:"result"`
+	OpenPositions []OpenPosition `json:"openPositions"`
+}
+
+// Account describes stock market's cash or margin account.
+type Account struct {
+	Type      string             `json:"type"`
+	Currency  string             `json:"currency,omitempty"`
+	Balances  map[string]float64 `json:"balances"`
+	Auxiliary *Auxiliary         `json:"auxiliary,omitempty"`
+}
+
+// Auxiliary is a part of Account with available funds, PnL and portfolio value.
+type Auxiliary struct {
+	AF  float64 `json:"af"`
+	PnL float64 `json:"pnl"`
+	PV  float64 `json:"pv"`
+}
+
+// AccountsResponse describes incomplete JSON response of accounts.
+type AccountsResponse struct {
+	Result   string             `json:"result"`
+	Accounts map[string]Account `json:"accounts"`
+}
+
+// Mismatch describes difference between stored and stock market's state.
+// Expected is stored value and Actual is stock market's one.
+type Mismatch struct {
+	Kind     string  `json:"kind"`
+	PairName string  `json:"pair_name"`
+	OrderID  string  `json:"order_id,omitempty"`
+	Expected float64 `json:"expected"`
+	Actual   float64 `json:"actual"`
+}
+
+func (mismatch Mismatch) String() string {
+	message := fmt.Sprintf("%s of <%s>", strings.ReplaceAll(mismatch.Kind, "_", " "),
+		mismatch.PairName)
+	if len(mismatch.OrderID) != 0 {
+		message += fmt.Sprintf(" order <%s>", mismatch.OrderID)
+	}
+	return message + fmt.Sprintf(": stored <%g>, exchange <%g>", mismatch.Expected, mismatch.Actual)
+}
+
+// Reconciliation describes the last comparison of user's stored orders and
+// positions with stock market's fills, positions and accounts.
+type Reconciliation struct {
+	Username   string             `json:"username"`
+	CheckedAt  time.Time          `json:"checked_at"`
+	Fills      int                `json:"fills"`
+	Mismatches []Mismatch         `json:"mismatches"`
+	Accounts   map[string]Account `json:"accounts"`
+}
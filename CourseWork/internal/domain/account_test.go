@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenPosition_SignedSize(t *testing.T) {
+	assert.Equal(t, int64(2), OpenPosition{Side: LongSide, Size: 2}.SignedSize())
+	assert.Equal(t, int64(-2), OpenPosition{Side: ShortSide, Size: 2}.SignedSize())
+}
+
+func TestMismatch_String(t *testing.T) {
+	mismatch := Mismatch{Kind: FillAmountMismatch, PairName: "PI_XBTUSD", OrderID: "id",
+		Expected: 2, Actual: 3}
+	assert.Equal(t, "fill amount mismatch of <PI_XBTUSD> order <id>: stored <2>, exchange <3>",
+		mismatch.String())
+	mismatch = Mismatch{Kind: PositionSizeMismatch, PairName: "PI_XBTUSD", Actual: -1}
+	assert.Equal(t, "position size mismatch of <PI_XBTUSD>: stored <0>, exchange <-1>",
+		mismatch.String())
+}
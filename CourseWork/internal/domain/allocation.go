@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+)
+
+const (
+	EqualAllocation      AllocationMode = "equal"
+	FixedAllocation      AllocationMode = "fixed"
+	RiskParityAllocation AllocationMode = "risk_parity"
+)
+
+// AllocationMode describes how user's budget is split across pairs.
+type AllocationMode string
+
+// Allocation describes user's budget, which is split across user's pairs by pair names.
+// Zero budget disables allocation, equal weights are default.
+// Fixed mode uses Weights of pairs and risk parity mode weights pairs by inverse volatility.
+type Allocation struct {
+	Budget  float64            `json:"budget,omitempty"`
+	Mode    AllocationMode     `json:"mode,omitempty"`
+	Weights map[string]float64 `json:"weights,omitempty"`
+}
+
+// Validate checks Allocation budget, mode and weights.
+func (allocation Allocation) Validate() error {
+	if allocation.Budget < 0 {
+		return fmt.Errorf("allocation budget is negative")
+	}
+	for _, weight := range allocation.Weights {
+		if weight < 0 {
+			return fmt.Errorf("allocation weight is negative")
+		}
+	}
+	switch allocation.Mode {
+	case "", EqualAllocation, RiskParityAllocation:
+	case FixedAllocation:
+		if len(allocation.Weights) == 0 {
+			return fmt.Errorf("allocation weights are empty")
+		}
+	default:
+		return fmt.Errorf("unsupported allocation mode")
+	}
+	return nil
+}
+
+// IsEnabled checks if Allocation limits orders.
+func (allocation Allocation) IsEnabled() bool {
+	return allocation.Budget > 0
+}
+
+// Split returns budget of every pair by pairs' volatilities, zero volatility is unknown.
+// Weights of fixed mode are normalized by weights of the given pairs, pair without weight
+// gets nothing. Risk parity mode uses equal weights until volatility of every pair is known.
+func (allocation Allocation) Split(volatilities map[string]float64) map[string]float64 {
+	weights := make(map[string]float64, len(volatilities))
+	for pairName, volatility := range volatilities {
+		switch allocation.Mode {
+		case FixedAllocation:
+			weights[pairName] = allocation.Weights[pairName]
+		case RiskParityAllocation:
+			if volatility <= 0 {
+				return allocation.equal(volatilities)
+			}
+			weights[pairName] = 1 / volatility
+		default:
+			weights[pairName] = 1
+		}
+	}
+	var sum float64
+	for _, weight := range weights {
+		sum += weight
+	}
+	budgets := make(map[string]float64, len(weights))
+	for pairName, weight := range weights {
+		if sum > 0 {
+			budgets[pairName] = allocation.Budget * weight / sum
+		} else {
+			budgets[pairName] = 0
+		}
+	}
+	return budgets
+}
+
+// equal splits budget equally across pairs.
+func (allocation Allocation) equal(volatilities map[string]float64) map[string]float64 {
+	budgets := make(map[string]float64, len(volatilities))
+	for pairName := range volatilities {
+		budgets[pairName] = allocation.Budget / float64(len(volatilities))
+	}
+	return budgets
+}
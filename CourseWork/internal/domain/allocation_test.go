@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocation_Validate(t *testing.T) {
+	assert.NoError(t, Allocation{}.Validate())
+	assert.NoError(t, Allocation{Budget: 100, Mode: RiskParityAllocation}.Validate())
+	assert.NoError(t, Allocation{Budget: 100, Mode: FixedAllocation,
+		Weights: map[string]float64{"PI_XBTUSD": 1}}.Validate())
+	assert.Error(t, Allocation{Budget: -1}.Validate())
+	assert.Error(t, Allocation{Mode: "unknown"}.Validate())
+	assert.Error(t, Allocation{Mode: FixedAllocation}.Validate())
+	assert.Error(t, Allocation{Weights: map[string]float64{"PI_XBTUSD": -1}}.Validate())
+	assert.False(t, Allocation{}.IsEnabled())
+}
+
+func TestAllocation_Split(t *testing.T) {
+	tests := []struct {
+		name         string
+		allocation   Allocation
+		volatilities map[string]float64
+		budgets      map[string]float64
+	}{
+		{name: "equal", allocation: Allocation{Budget: 90},
+			volatilities: map[string]float64{"a": 0, "b": 0.1, "c": 0.2},
+			budgets:      map[string]float64{"a": 30, "b": 30, "c": 30}},
+		{name: "fixed is normalized by subscribed pairs",
+			allocation: Allocation{Budget: 100, Mode: FixedAllocation,
+				Weights: map[string]float64{"a": 3, "b": 1, "c": 4}},
+			volatilities: map[string]float64{"a": 0, "b": 0, "d": 0},
+			budgets:      map[string]float64{"a": 75, "b": 25, "d": 0}},
+		{name: "fixed without weights",
+			allocation: Allocation{Budget: 100, Mode: FixedAllocation,
+				Weights: map[string]float64{"c": 1}},
+			volatilities: map[string]float64{"a": 0},
+			budgets:      map[string]float64{"a": 0}},
+		{name: "risk parity", allocation: Allocation{Budget: 90, Mode: RiskParityAllocation},
+			volatilities: map[string]float64{"a": 0.1, "b": 0.2},
+			budgets:      map[string]float64{"a": 60, "b": 30}},
+		{name: "risk parity with unknown volatility",
+			allocation:   Allocation{Budget: 90, Mode: RiskParityAllocation},
+			volatilities: map[string]float64{"a": 0.1, "b": 0},
+			budgets:      map[string]float64{"a": 45, "b": 45}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budgets := tt.allocation.Split(tt.volatilities)
+			assert.Equal(t, len(budgets), len(tt.budgets))
+			for pairName, budget := range tt.budgets {
+				assert.InDelta(t, budgets[pairName], budget, 1e-9)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultAuditLimit = 500
+	MaxAuditLimit     = 5000
+)
+
+const (
+	SignalStage   AuditStage = "signal"
+	DecisionStage AuditStage = "decision"
+	OrderStage    AuditStage = "order"
+	ResponseStage AuditStage = "response"
+	StorageStage  AuditStage = "storage"
+)
+
+const (
+	SizeCheck       = "size"
+	AllocationCheck = "allocation"
+	RiskCheck       = "risk"
+	ExitCheck       = "exit"
+)
+
+// AuditStage describes step of trading decision.
+type AuditStage string
+
+// AuditEvent describes one stage of user's trading decision, stages of one decision share TraceID.
+// Data keeps stage's details: candle with indicator state and signal, sizing and risk decision,
+// order request or exchange response.
+type AuditEvent struct {
+	ID       int64           `json:"id"`
+	TraceID  string          `json:"trace_id"`
+	Stage    AuditStage      `json:"stage"`
+	Time     time.Time       `json:"time"`
+	Username string          `json:"username"`
+	PairName string          `json:"pair_name"`
+	Interval CandleInterval  `json:"interval"`
+	OrderID  string          `json:"order_id,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// SignalAudit is data of SignalStage.
+type SignalAudit struct {
+	Candle    Candle          `json:"candle"`
+	Indicator json.RawMessage `json:"indicator,omitempty"`
+	Signal    Signal          `json:"signal"`
+}
+
+// DecisionAudit is data of DecisionStage, Check is the failed check or the last one.
+// Reason is set for ExitCheck only, it's the risk limit, which closes position.
+type DecisionAudit struct {
+	Check    string `json:"check"`
+	Reason   string `json:"reason,omitempty"`
+	Volume   int64  `json:"volume"`
+	Position int64  `json:"position"`
+}
+
+// NewTraceID returns id of user's decision by candle of pair's interval.
+func NewTraceID(pairName string, interval CandleInterval, candleTime int64, username string) string {
+	return fmt.Sprintf("%s-%s-%d-%s", pairName, interval.Resolution(), candleTime, username)
+}
+
+// NewExitTraceID returns id of user's risk exit by candle of pair's interval,
+// so it differs from decision by indicator's signal of the same candle.
+func NewExitTraceID(pairName string, interval CandleInterval, candleTime int64,
+	username, reason string) string {
+	return fmt.Sprintf("%s-%s", NewTraceID(pairName, interval, candleTime, username), reason)
+}
+
+// NewAuditEvent returns AuditEvent of order event's stage with marshalled data.
+func NewAuditEvent(stage AuditStage, event StockMarketEvent, data interface{}) AuditEvent {
+	auditEvent := AuditEvent{
+		TraceID:  event.TraceID,
+		Stage:    stage,
+		Time:     time.Now(),
+		Username: event.Username,
+		PairName: event.Name,
+		Interval: event.Interval,
+	}
+	if data == nil {
+		return auditEvent
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		auditEvent.Error = fmt.Sprintf("can't marshal audit data <%s>", err)
+		return auditEvent
+	}
+	auditEvent.Data = raw
+	return auditEvent
+}
+
+// WithError returns AuditEvent with err's message, nil err doesn't change it.
+func (event AuditEvent) WithError(err error) AuditEvent {
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// AuditFilter describes user's audit events of decision with OrderID and events in [From, To].
+// Events are sorted from the oldest.
+type AuditFilter struct {
+	Username string
+	OrderID  string
+	From     time.Time
+	To       time.Time
+	Limit    int64
+}
+
+// Validate checks AuditFilter bounds and sets default limit.
+func (filter *AuditFilter) Validate() error {
+	if len(filter.Username) == 0 {
+		return fmt.Errorf("username is empty")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return fmt.Errorf("from is after to")
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+	if filter.Limit < 0 || filter.Limit > MaxAuditLimit {
+		return fmt.Errorf("limit is out of bounds")
+	}
+	return nil
+}
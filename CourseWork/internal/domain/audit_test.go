@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAuditEvent(t *testing.T) {
+	traceID := NewTraceID("PI_XBTUSD", Candle1m, 10, "username")
+	assert.Equal(t, traceID, "PI_XBTUSD-1m-10-username")
+	assert.Equal(t, NewExitTraceID("PI_XBTUSD", Candle1m, 10, "username", "stop-loss"),
+		"PI_XBTUSD-1m-10-username-stop-loss")
+	event := StockMarketEvent{Signal: OpenLong, Name: "PI_XBTUSD", Interval: Candle1m,
+		Username: "username", TraceID: traceID}
+
+	auditEvent := NewAuditEvent(DecisionStage, event, DecisionAudit{Check: RiskCheck, Volume: 2})
+	assert.Equal(t, auditEvent.TraceID, traceID)
+	assert.Equal(t, auditEvent.Stage, DecisionStage)
+	assert.Equal(t, auditEvent.Username, "username")
+	assert.Equal(t, auditEvent.PairName, "PI_XBTUSD")
+	assert.False(t, auditEvent.Time.IsZero())
+	assert.Empty(t, auditEvent.Error)
+	var decision DecisionAudit
+	assert.NoError(t, json.Unmarshal(auditEvent.Data, &decision))
+	assert.Equal(t, decision, DecisionAudit{Check: RiskCheck, Volume: 2})
+
+	auditEvent = NewAuditEvent(StorageStage, event, nil).WithError(errors.New("db error"))
+	assert.Empty(t, auditEvent.Data)
+	assert.Equal(t, auditEvent.Error, "db error")
+	assert.Empty(t, NewAuditEvent(StorageStage, event, nil).WithError(nil).Error)
+	assert.NotEmpty(t, NewAuditEvent(SignalStage, event, math.NaN()).Error)
+}
+
+func TestAuditFilter_Validate(t *testing.T) {
+	filter := AuditFilter{Username: "username"}
+	assert.NoError(t, filter.Validate())
+	assert.Equal(t, filter.Limit, int64(DefaultAuditLimit))
+
+	filter = AuditFilter{}
+	assert.Error(t, filter.Validate())
+	filter = AuditFilter{Username: "username", Limit: MaxAuditLimit + 1}
+	assert.Error(t, filter.Validate())
+	filter = AuditFilter{Username: "username", From: time.Unix(2, 0), To: time.Unix(1, 0)}
+	assert.Error(t, filter.Validate())
+}
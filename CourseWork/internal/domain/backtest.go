@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// Trade describes closed position from backtesting.
+type Trade struct {
+	Side       Signal  `json:"side"`
+	EntryTime  int64   `json:"entry_time"`
+	ExitTime   int64   `json:"exit_time"`
+	EntryPrice float64 `json:"entry_price"`
+	ExitPrice  float64 `json:"exit_price"`
+	Size       int64   `json:"size"`
+	PnL        float64 `json:"pnl"`
+	Return     float64 `json:"return"`
+}
+
+// NewTrade returns Trade with counted PnL and return.
+func NewTrade(side Signal, entry, exit Candle, entryPrice, exitPrice float64, size int64) Trade {
+	pnl := (exitPrice - entryPrice) * float64(size)
+	if side == Sell {
+		pnl = -pnl
+	}
+	var ret float64
+	if entryPrice != 0 && size != 0 {
+		ret = pnl / (entryPrice * float64(size))
+	}
+	return Trade{
+		Side:       side,
+		EntryTime:  entry.Time,
+		ExitTime:   exit.Time,
+		EntryPrice: entryPrice,
+		ExitPrice:  exitPrice,
+		Size:       size,
+		PnL:        pnl,
+		Return:     ret,
+	}
+}
+
+func (trade Trade) String() string {
+	return fmt.Sprintf("%s: %d -> %d, %.2f -> %.2f, size: %d, pnl: %.2f",
+		trade.Side, trade.EntryTime, trade.ExitTime, trade.EntryPrice,
+		trade.ExitPrice, trade.Size, trade.PnL)
+}
+
+// BacktestReport consists of backtest trades and their statistics.
+type BacktestReport struct {
+	Trades      []Trade `json:"trades"`
+	Candles     int     `json:"candles"`
+	TotalPnL    float64 `json:"total_pnl"`
+	WinRate     float64 `json:"win_rate"`
+	MaxDrawdown float64 `json:"max_drawdown"`
+	SharpeRatio float64 `json:"sharpe_ratio"`
+}
+
+// NewBacktestReport counts statistics by closed trades.
+// Sharpe ratio is counted by trade returns without annualization.
+func NewBacktestReport(trades []Trade, candles int) BacktestReport {
+	report := BacktestReport{
+		Trades:  trades,
+		Candles: candles,
+	}
+	if len(trades) == 0 {
+		return report
+	}
+	var wins int
+	var equity, peak, returns float64
+	for _, trade := range trades {
+		if trade.PnL > 0 {
+			wins++
+		}
+		returns += trade.Return
+		equity += trade.PnL
+		if equity > peak {
+			peak = equity
+		}
+		if peak-equity > report.MaxDrawdown {
+			report.MaxDrawdown = peak - equity
+		}
+	}
+	report.TotalPnL = equity
+	report.WinRate = float64(wins) / float64(len(trades))
+	report.SharpeRatio = sharpeRatio(trades, returns/float64(len(trades)))
+	return report
+}
+
+// sharpeRatio counts mean of returns divided by their sample deviation.
+func sharpeRatio(trades []Trade, mean float64) float64 {
+	if len(trades) < 2 {
+		return 0
+	}
+	var variance float64
+	for _, trade := range trades {
+		variance += (trade.Return - mean) * (trade.Return - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(trades)-1))
+	if deviation == 0 {
+		return 0
+	}
+	return mean / deviation
+}
+
+func (report BacktestReport) String() string {
+	return fmt.Sprintf("Candles: %d,\n"+
+		"Trades: %d,\n"+
+		"PnL: %.2f,\n"+
+		"Win rate: %.2f%%,\n"+
+		"Max drawdown: %.2f,\n"+
+		"Sharpe ratio: %.4f", report.Candles, len(report.Trades), report.TotalPnL,
+		report.WinRate*100, report.MaxDrawdown, report.SharpeRatio)
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTrade(t *testing.T) {
+	trade := NewTrade(Buy, Candle{Time: 1}, Candle{Time: 2}, 10, 12, 2)
+	assert.Equal(t, trade.PnL, 4.)
+	assert.Equal(t, trade.Return, 0.2)
+	assert.Equal(t, trade.EntryTime, int64(1))
+	assert.Equal(t, trade.ExitTime, int64(2))
+
+	trade = NewTrade(Sell, Candle{Time: 1}, Candle{Time: 2}, 10, 12, 2)
+	assert.Equal(t, trade.PnL, -4.)
+	assert.Equal(t, trade.Return, -0.2)
+}
+
+func TestNewBacktestReport(t *testing.T) {
+	report := NewBacktestReport(nil, 10)
+	assert.Equal(t, report.Candles, 10)
+	assert.Equal(t, report.TotalPnL, 0.)
+	assert.Equal(t, report.SharpeRatio, 0.)
+
+	trades := []Trade{
+		{PnL: 10, Return: 0.1},
+		{PnL: -15, Return: -0.1},
+		{PnL: 5, Return: 0.3},
+	}
+	report = NewBacktestReport(trades, 10)
+	assert.Equal(t, report.TotalPnL, 0.)
+	assert.InDelta(t, report.WinRate, 2./3, 1e-9)
+	assert.Equal(t, report.MaxDrawdown, 15.)
+	assert.InDelta(t, report.SharpeRatio, 0.5, 1e-9)
+}
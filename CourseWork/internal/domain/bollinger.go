@@ -0,0 +1,59 @@
+package domain
+
+// Bollinger is Bollinger Bands implementation of Indicator.
+// Long position is opened when close is below lower band and short position
+// when close is above upper band.
+type Bollinger struct {
+	Period    int32
+	Deviation float64
+	Upper     float64
+	Middle    float64
+	Lower     float64
+	Machine   PositionMachine
+	closes    *window
+	guard     candleGuard
+}
+
+// NewBollinger returns pointer to Bollinger with params or default values.
+func NewBollinger(params IndicatorParams) (*Bollinger, error) {
+	period, err := periodOrDefault(params.Period, DefaultBollingerPeriod)
+	if err != nil {
+		return nil, err
+	}
+	deviation, err := valueOrDefault(params.Deviation, DefaultDeviation)
+	if err != nil {
+		return nil, err
+	}
+	machine, err := NewPositionMachine(params.Direction)
+	if err != nil {
+		return nil, err
+	}
+	return &Bollinger{
+		Period:    period,
+		Deviation: deviation,
+		closes:    newWindow(period),
+		Machine:   machine,
+	}, nil
+}
+
+// Add is adding implementation of Indicator.
+func (indicator *Bollinger) Add(candle Candle) (Signal, error) {
+	if err := indicator.guard.check(candle, "bollinger"); err != nil {
+		return "", err
+	}
+	indicator.closes.push(candle.Close)
+	if !indicator.closes.isFull() {
+		return WaitToSet, nil
+	}
+	indicator.Middle = indicator.closes.mean()
+	width := indicator.Deviation * indicator.closes.deviation()
+	indicator.Upper = indicator.Middle + width
+	indicator.Lower = indicator.Middle - width
+	return indicator.Machine.Signal(candle.Close < indicator.Lower,
+		candle.Close > indicator.Upper), nil
+}
+
+// Reset forgets entered position, so the next entering signal opens it.
+func (indicator *Bollinger) Reset() {
+	indicator.Machine.Reset()
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBollinger(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		isError bool
+	}{
+		{name: "default", params: IndicatorParams{}},
+		{name: "custom", params: IndicatorParams{Period: 3, Deviation: 1}},
+		{name: "negative deviation", params: IndicatorParams{Deviation: -1}, isError: true},
+		{name: "huge period", params: IndicatorParams{Period: maxIndicatorPeriod + 1}, isError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bollinger, err := NewBollinger(test.params)
+			if test.isError {
+				assert.Error(t, err)
+				assert.Nil(t, bollinger)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, bollinger)
+		})
+	}
+}
+
+func TestBollinger_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		closes  []float64
+		signals []Signal
+	}{
+		{
+			name:    "lower and upper bands",
+			params:  IndicatorParams{Period: 3, Deviation: 1},
+			closes:  []float64{10, 10, 10, 7, 8, 12},
+			signals: []Signal{WaitToSet, WaitToSet, WaitToBuy, OpenLong, WaitToSell, CloseLong},
+		},
+		{
+			name:    "inside bands",
+			params:  IndicatorParams{Period: 2, Deviation: 2},
+			closes:  []float64{10, 11, 10, 11},
+			signals: []Signal{WaitToSet, WaitToBuy, WaitToBuy, WaitToBuy},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bollinger, err := NewBollinger(test.params)
+			assert.NoError(t, err)
+			assertSignals(t, bollinger, test.closes, test.signals)
+		})
+	}
+
+	bollinger, err := NewBollinger(IndicatorParams{})
+	assert.NoError(t, err)
+	_, err = bollinger.Add(Candle{Close: -1, Time: 1})
+	assert.Error(t, err)
+}
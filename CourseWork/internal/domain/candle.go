@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -11,20 +13,82 @@ const (
 	Candle2m  CandleInterval = "candles_trade_2m"
 	Candle5m  CandleInterval = "candles_trade_5m"
 	Candle10m CandleInterval = "candles_trade_10m"
+	// derived intervals are built from Candle1m feed
+	Candle15m CandleInterval = "candles_trade_15m"
+	Candle1h  CandleInterval = "candles_trade_1h"
+	Candle4h  CandleInterval = "candles_trade_4h"
+	Candle1d  CandleInterval = "candles_trade_1d"
+)
+
+const candlePrefix = "candles_trade_"
+
+const (
+	DefaultCandlesLimit = 500
+	MaxCandlesLimit     = 5000
 )
 
 // CandleInterval represents string description of candle time interval.
 type CandleInterval string
 
+// candleDurations consists of supported intervals.
+var candleDurations = map[CandleInterval]time.Duration{
+	Candle1m:  time.Minute,
+	Candle2m:  2 * time.Minute,
+	Candle5m:  5 * time.Minute,
+	Candle10m: 10 * time.Minute,
+	Candle15m: 15 * time.Minute,
+	Candle1h:  time.Hour,
+	Candle4h:  4 * time.Hour,
+	Candle1d:  24 * time.Hour,
+}
+
+// Validate checks if interval is supported.
+func (interval CandleInterval) Validate() error {
+	if _, ok := candleDurations[interval]; !ok {
+		return fmt.Errorf("unsupported candle type")
+	}
+	return nil
+}
+
+// IsDerived checks if interval isn't stock market's feed and is built locally.
+func (interval CandleInterval) IsDerived() bool {
+	return interval == Candle15m || interval == Candle1h ||
+		interval == Candle4h || interval == Candle1d
+}
+
+// Feed returns stock market's feed of interval's candles.
+func (interval CandleInterval) Feed() CandleInterval {
+	if interval.IsDerived() {
+		return Candle1m
+	}
+	return interval
+}
+
+// Resolution returns short description of interval, e.g. 1m.
+func (interval CandleInterval) Resolution() string {
+	return strings.TrimPrefix(string(interval), candlePrefix)
+}
+
+// Duration returns time interval of candles.
+func (interval CandleInterval) Duration() (time.Duration, error) {
+	duration, ok := candleDurations[interval]
+	if !ok {
+		return 0, fmt.Errorf("unsupported candle interval <%s>", interval)
+	}
+	return duration, nil
+}
+
 // Candle consists of all possible properties from kraken stock market.
+// Backfilled candle was missed by socket and is received later from charts.
 type Candle struct {
-	Interval CandleInterval
-	Open     float64 `json:"open"`
-	Close    float64 `json:"close"`
-	High     float64 `json:"high"`
-	Low      float64 `json:"low"`
-	Time     int64   `json:"time"`
-	Volume   int64   `json:"volume"`
+	Interval   CandleInterval `json:"interval,omitempty"`
+	Open       float64        `json:"open"`
+	Close      float64        `json:"close"`
+	High       float64        `json:"high"`
+	Low        float64        `json:"low"`
+	Time       int64          `json:"time"`
+	Volume     int64          `json:"volume"`
+	Backfilled bool           `json:"-"`
 }
 
 // CandleJSON describes json from stock market with wrong field types.
@@ -97,3 +161,33 @@ func (candle Candle) String() string {
 	return fmt.Sprintf("Open: %.2f; High: %.2f; Low: %.2f; Close: %.2f; Volume: %d;",
 		candle.Open, candle.High, candle.Low, candle.Close, candle.Volume)
 }
+
+// CandleFilter describes stored candles of pair's interval, which started in [From, To].
+// Candles are sorted from the oldest one.
+type CandleFilter struct {
+	PairName string
+	Interval CandleInterval
+	From     time.Time
+	To       time.Time
+	Limit    int64
+}
+
+// Validate checks CandleFilter bounds and sets default limit.
+func (filter *CandleFilter) Validate() error {
+	if len(filter.PairName) == 0 {
+		return fmt.Errorf("pair name is empty")
+	}
+	if err := filter.Interval.Validate(); err != nil {
+		return err
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return fmt.Errorf("from is after to")
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultCandlesLimit
+	}
+	if filter.Limit < 0 || filter.Limit > MaxCandlesLimit {
+		return fmt.Errorf("limit is out of bounds")
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrOutdatedCandle = errors.New("candle is older than the current one")
+)
+
+// CandleGenerator builds candles of derived Interval from candles of its feed.
+// Candle's time is truncated to Interval and candles of the same period are merged.
+// Feed sends updates of the current candle, so the latest update of feed candle
+// replaces the previous one and only closed feed candles are merged.
+// Candle of Interval is returned only when its period is closed by feed candle
+// of the next period, so indicators get every derived candle once and complete.
+type CandleGenerator struct {
+	Interval CandleInterval
+	duration int64
+	start    int64
+	merged   *Candle
+	last     *Candle
+}
+
+// NewCandleGenerator returns pointer to CandleGenerator of derived interval.
+func NewCandleGenerator(interval CandleInterval) (*CandleGenerator, error) {
+	if !interval.IsDerived() {
+		return nil, fmt.Errorf("interval <%s> isn't derived", interval)
+	}
+	duration, err := interval.Duration()
+	if err != nil {
+		return nil, err
+	}
+	return &CandleGenerator{
+		Interval: interval,
+		duration: duration.Milliseconds(),
+	}, nil
+}
+
+// Start returns start of Interval's period, which includes time.
+func (generator *CandleGenerator) Start(time int64) int64 {
+	return time - time%generator.duration
+}
+
+// Add merges feed candle. It returns closed candle of Interval and true,
+// if feed candle starts the next period.
+func (generator *CandleGenerator) Add(candle Candle) (Candle, bool, error) {
+	if err := candle.Validate(); err != nil {
+		return Candle{}, false, err
+	}
+	start := generator.Start(candle.Time)
+	var closed Candle
+	var isClosed bool
+	if generator.last != nil {
+		switch {
+		case candle.Time < generator.last.Time:
+			return Candle{}, false, ErrOutdatedCandle
+		case start != generator.start:
+			// the current period is closed
+			closed, isClosed = generator.current(), true
+			generator.merged = nil
+		case candle.Time > generator.last.Time:
+			// the last feed candle is closed
+			merged := *generator.last
+			if generator.merged != nil {
+				merged = generator.merged.merge(merged)
+			}
+			generator.merged = &merged
+		}
+	}
+	generator.start = start
+	generator.last = &candle
+	return closed, isClosed, nil
+}
+
+// current returns candle of Interval merged from feed candles of the current period.
+func (generator *CandleGenerator) current() Candle {
+	current := *generator.last
+	if generator.merged != nil {
+		current = generator.merged.merge(current)
+	}
+	current.Interval = generator.Interval
+	current.Time = generator.start
+	return current
+}
+
+// AggregateCandles merges sorted closed candles into candles of interval,
+// which start at truncated time of merged candles.
+func AggregateCandles(candles []Candle, interval CandleInterval) ([]Candle, error) {
+	duration, err := interval.Duration()
+	if err != nil {
+		return nil, err
+	}
+	step := duration.Milliseconds()
+	aggregated := make([]Candle, 0, len(candles))
+	for _, candle := range candles {
+		start := candle.Time - candle.Time%step
+		if last := len(aggregated) - 1; last >= 0 && aggregated[last].Time == start {
+			aggregated[last] = aggregated[last].merge(candle)
+			continue
+		}
+		candle.Interval = interval
+		candle.Time = start
+		aggregated = append(aggregated, candle)
+	}
+	return aggregated, nil
+}
+
+// merge returns candle updated by the next one of the same period.
+func (candle Candle) merge(next Candle) Candle {
+	if candle.Low > next.Low {
+		candle.Low = next.Low
+	}
+	if candle.High < next.High {
+		candle.High = next.High
+	}
+	candle.Close = next.Close
+	candle.Volume += next.Volume
+	candle.Backfilled = next.Backfilled
+	return candle
+}
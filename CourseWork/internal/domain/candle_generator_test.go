@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCandleGenerator(t *testing.T) {
+	_, err := NewCandleGenerator(Candle1m)
+	assert.Error(t, err)
+	generator, err := NewCandleGenerator(Candle15m)
+	assert.NoError(t, err)
+	assert.Equal(t, Candle15m, generator.Interval)
+}
+
+func TestCandleGenerator_Add(t *testing.T) {
+	generator, err := NewCandleGenerator(Candle15m)
+	assert.NoError(t, err)
+	minute := int64(60000)
+	start := 15 * minute
+	assert.Equal(t, start, generator.Start(start+2*minute))
+
+	_, isClosed, err := generator.Add(Candle{Open: 10, High: 11, Low: 9, Close: 10,
+		Volume: 1, Time: start + minute})
+	assert.NoError(t, err)
+	assert.False(t, isClosed)
+
+	// update of the same feed candle replaces it
+	_, isClosed, err = generator.Add(Candle{Open: 10, High: 12, Low: 9, Close: 12,
+		Volume: 2, Time: start + minute})
+	assert.NoError(t, err)
+	assert.False(t, isClosed)
+
+	_, isClosed, err = generator.Add(Candle{Open: 12, High: 13, Low: 8, Close: 11,
+		Volume: 3, Time: start + 2*minute, Backfilled: true})
+	assert.NoError(t, err)
+	assert.False(t, isClosed)
+
+	_, _, err = generator.Add(Candle{Time: start})
+	assert.ErrorIs(t, err, ErrOutdatedCandle)
+
+	// the next period closes the merged candle and starts from scratch
+	closed, isClosed, err := generator.Add(Candle{Open: 11, High: 11, Low: 11, Close: 11,
+		Volume: 1, Time: 2 * start})
+	assert.NoError(t, err)
+	assert.True(t, isClosed)
+	assert.Equal(t, Candle{Interval: Candle15m, Open: 10, High: 13, Low: 8, Close: 11,
+		Volume: 5, Time: start, Backfilled: true}, closed)
+	closed, isClosed, err = generator.Add(Candle{Open: 11, High: 12, Low: 11, Close: 12,
+		Volume: 1, Time: 3 * start})
+	assert.NoError(t, err)
+	assert.True(t, isClosed)
+	assert.Equal(t, Candle{Interval: Candle15m, Open: 11, High: 11, Low: 11, Close: 11,
+		Volume: 1, Time: 2 * start}, closed)
+
+	_, _, err = generator.Add(Candle{Low: -1, Time: 3 * start})
+	assert.Error(t, err)
+}
+
+func TestAggregateCandles(t *testing.T) {
+	candles, err := AggregateCandles([]Candle{
+		{Open: 1, High: 2, Low: 1, Close: 2, Volume: 1, Time: 60000},
+		{Open: 2, High: 3, Low: 0.5, Close: 1, Volume: 2, Time: 120000},
+		{Open: 1, High: 1, Low: 1, Close: 1, Volume: 1, Time: 600000},
+	}, Candle10m)
+	assert.NoError(t, err)
+	assert.Equal(t, []Candle{
+		{Interval: Candle10m, Open: 1, High: 3, Low: 0.5, Close: 1, Volume: 3, Time: 0},
+		{Interval: Candle10m, Open: 1, High: 1, Low: 1, Close: 1, Volume: 1, Time: 600000},
+	}, candles)
+	_, err = AggregateCandles(nil, "unknown")
+	assert.Error(t, err)
+}
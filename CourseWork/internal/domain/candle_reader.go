@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	csvColumns = 6
+)
+
+// chartsResponse describes kraken charts response with candles.
+type chartsResponse struct {
+	Candles []Candle `json:"candles"`
+}
+
+// ReadCandlesCSV reads candles from csv with time,open,high,low,close,volume columns.
+// Header line is optional.
+func ReadCandlesCSV(r io.Reader, interval CandleInterval) ([]Candle, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = csvColumns
+	reader.TrimLeadingSpace = true
+	candles := make([]Candle, 0)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't read csv <%w>", err)
+		}
+		// header skipping
+		if line == 1 && strings.EqualFold(record[0], "time") {
+			continue
+		}
+		candle, err := parseCandleRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse csv line %d <%w>", line, err)
+		}
+		candle.Interval = interval
+		candles = append(candles, candle)
+	}
+	return candles, nil
+}
+
+// ReadCandlesJSON reads candles from json array or from kraken charts response.
+func ReadCandlesJSON(r io.Reader, interval CandleInterval) ([]Candle, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("can't read json <%w>", err)
+	}
+	var candles []Candle
+	if err = json.Unmarshal(data, &candles); err != nil {
+		var response chartsResponse
+		if err = json.Unmarshal(data, &response); err != nil {
+			return nil, fmt.Errorf("can't unmarshal candles <%w>", err)
+		}
+		candles = response.Candles
+	}
+	for i := range candles {
+		candles[i].Interval = interval
+	}
+	return candles, nil
+}
+
+// parseCandleRecord converts csv record to Candle.
+func parseCandleRecord(record []string) (Candle, error) {
+	ts, err := strconv.ParseInt(record[0], 10, 64)
+	if err != nil {
+		return Candle{}, fmt.Errorf("can't parse time <%w>", err)
+	}
+	prices := make([]float64, 4)
+	for i := range prices {
+		prices[i], err = strconv.ParseFloat(record[i+1], 64)
+		if err != nil {
+			return Candle{}, fmt.Errorf("can't parse price <%w>", err)
+		}
+	}
+	volume, err := strconv.ParseInt(record[5], 10, 64)
+	if err != nil {
+		return Candle{}, fmt.Errorf("can't parse volume <%w>", err)
+	}
+	candle := Candle{
+		Open:   prices[0],
+		High:   prices[1],
+		Low:    prices[2],
+		Close:  prices[3],
+		Time:   ts,
+		Volume: volume,
+	}
+	if err = candle.Validate(); err != nil {
+		return Candle{}, err
+	}
+	return candle, nil
+}
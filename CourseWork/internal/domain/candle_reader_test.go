@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCandlesCSV(t *testing.T) {
+	data := "time,open,high,low,close,volume\n" +
+		"1,1,2,0.5,1.5,10\n" +
+		"2,1.5,3,1,2,20\n"
+	candles, err := ReadCandlesCSV(strings.NewReader(data), Candle1m)
+	assert.NoError(t, err)
+	assert.Equal(t, len(candles), 2)
+	assert.Equal(t, candles[1], Candle{Interval: Candle1m, Open: 1.5, High: 3,
+		Low: 1, Close: 2, Time: 2, Volume: 20})
+
+	candles, err = ReadCandlesCSV(strings.NewReader("1,1,2,0.5,1.5,10\n"), Candle1m)
+	assert.NoError(t, err)
+	assert.Equal(t, len(candles), 1)
+
+	_, err = ReadCandlesCSV(strings.NewReader("1,z,2,0.5,1.5,10\n"), Candle1m)
+	assert.Error(t, err)
+	_, err = ReadCandlesCSV(strings.NewReader("1,1,2,-0.5,1.5,10\n"), Candle1m)
+	assert.Error(t, err)
+	_, err = ReadCandlesCSV(strings.NewReader("1,1,2\n"), Candle1m)
+	assert.Error(t, err)
+}
+
+func TestReadCandlesJSON(t *testing.T) {
+	data := `[{"open":"1","high":"2","low":"0.5","close":"1.5","time":1,"volume":10}]`
+	candles, err := ReadCandlesJSON(strings.NewReader(data), Candle5m)
+	assert.NoError(t, err)
+	assert.Equal(t, len(candles), 1)
+	assert.Equal(t, candles[0].Interval, Candle5m)
+	assert.Equal(t, candles[0].High, 2.)
+
+	data = `{"candles":[{"open":"1","high":"2","low":"0.5","close":"1.5","time":1,"volume":10}]}`
+	candles, err = ReadCandlesJSON(strings.NewReader(data), Candle5m)
+	assert.NoError(t, err)
+	assert.Equal(t, len(candles), 1)
+
+	_, err = ReadCandlesJSON(strings.NewReader(`[{"open":"z"}]`), Candle5m)
+	assert.Error(t, err)
+}
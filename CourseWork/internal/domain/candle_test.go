@@ -3,6 +3,7 @@ package domain
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -111,3 +112,51 @@ func TestCandle_UnmarshalJSON(t *testing.T) {
 	err = json.Unmarshal(data, &unmarshalledCandle)
 	assert.Error(t, err)
 }
+
+func TestCandleInterval_Duration(t *testing.T) {
+	assert.Equal(t, "10m", Candle10m.Resolution())
+	duration, err := Candle2m.Duration()
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, duration)
+	_, err = CandleInterval("candles_trade_x").Duration()
+	assert.Error(t, err)
+}
+
+func TestCandleFilter_Validate(t *testing.T) {
+	filter := CandleFilter{PairName: "pair", Interval: Candle1m}
+	assert.NoError(t, filter.Validate())
+	assert.Equal(t, filter.Limit, int64(DefaultCandlesLimit))
+
+	now := time.Now()
+	tests := []struct {
+		name   string
+		filter CandleFilter
+	}{
+		{name: "empty pair name", filter: CandleFilter{Interval: Candle1m}},
+		{name: "interval", filter: CandleFilter{PairName: "x", Interval: "candles_trade_1w"}},
+		{name: "dates", filter: CandleFilter{PairName: "x", Interval: Candle1m,
+			From: now, To: now.Add(-time.Hour)}},
+		{name: "negative limit", filter: CandleFilter{PairName: "x", Interval: Candle1m, Limit: -1}},
+		{name: "big limit", filter: CandleFilter{PairName: "x", Interval: Candle1m,
+			Limit: MaxCandlesLimit + 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Error(t, test.filter.Validate())
+		})
+	}
+}
+
+func TestCandleInterval_Feed(t *testing.T) {
+	for _, interval := range []CandleInterval{Candle15m, Candle1h, Candle4h, Candle1d} {
+		assert.NoError(t, interval.Validate())
+		assert.True(t, interval.IsDerived())
+		assert.Equal(t, Candle1m, interval.Feed())
+	}
+	assert.False(t, Candle10m.IsDerived())
+	assert.Equal(t, Candle10m, Candle10m.Feed())
+	duration, err := Candle4h.Duration()
+	assert.NoError(t, err)
+	assert.Equal(t, 4*time.Hour, duration)
+	assert.Equal(t, "1d", Candle1d.Resolution())
+}
@@ -6,6 +6,8 @@ import (
 	"sync"
 )
 
+const executionEvent = "EXECUTION"
+
 // ErrorJSON is needed to notify client about error through http.
 type ErrorJSON struct {
 	Message string `json:"error"`
@@ -88,12 +90,19 @@ func (queue CandleQueue) getLow() float64 {
 }
 
 // StockMarketEvent inform service about necessary order's information.
+// Protection describes order, which is placed after the fill of event's order.
+// TraceID links audit events of the same decision.
 type StockMarketEvent struct {
-	Signal   Signal
-	Name     string
-	Interval CandleInterval
-	Volume   int64
-	Close    float64
+	Signal     Signal
+	Name       string
+	Interval   CandleInterval
+	Username   string
+	Indicator  string
+	Volume     int64
+	Close      float64
+	Order      OrderOptions
+	Protection OrderOptions
+	TraceID    string
 }
 
 func (event StockMarketEvent) String() string {
@@ -129,37 +138,99 @@ type SendStatus struct {
 }
 
 // OrderEvent is a part of SendStatus.
+// Execution events describe fills, other events describe placed or cancelled Order.
 type OrderEvent struct {
 	Price               float64              `json:"price"`
 	Amount              float64              `json:"amount"`
 	Reason              string               `json:"reason"`
 	Type                string               `json:"type"`
+	Order               *PlacedOrder         `json:"order"`
 	OrderPriorExecution *OrderPriorExecution `json:"orderPriorExecution"`
 }
 
 // OrderPriorExecution is a part of OrderEvent.
 type OrderPriorExecution struct {
-	Side string `json:"side"`
+	Side string    `json:"side"`
+	Type OrderType `json:"type"`
+}
+
+// PlacedOrder is a part of OrderEvent.
+type PlacedOrder struct {
+	OrderID    string    `json:"orderId"`
+	Type       OrderType `json:"type"`
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"`
+	Quantity   float64   `json:"quantity"`
+	Filled     float64   `json:"filled"`
+	LimitPrice float64   `json:"limitPrice"`
+	StopPrice  float64   `json:"stopPrice"`
+	ReduceOnly bool      `json:"reduceOnly"`
 }
 
 // Message returns OrderInfo structs info about OrderResponse into struct for further processing.
+// Amount is a sum of all executions and Price is their average,
+// so order without executions has zero amount and its limit price.
 func (orderResponse OrderResponse) Message(name string) (OrderInfo, error) {
 	if orderResponse.SendStatus == nil {
 		return OrderInfo{}, fmt.Errorf("incorrect received data: SendStatus is nil")
 	}
-	if orderResponse.SendStatus.OrderEvents == nil ||
-		len(orderResponse.SendStatus.OrderEvents) == 0 {
+	if len(orderResponse.SendStatus.OrderEvents) == 0 {
 		return OrderInfo{}, fmt.Errorf("incorrect received data: OrderEvents is nil")
 	}
-	if orderResponse.SendStatus.OrderEvents[0].OrderPriorExecution == nil {
-		return OrderInfo{}, fmt.Errorf(
-			"incorrect received data: OrderPriorExecution is nil")
-	}
-	return OrderInfo{
+	info := OrderInfo{
 		Name:    name,
 		OrderID: orderResponse.SendStatus.OrderID,
-		Price:   orderResponse.SendStatus.OrderEvents[0].Price,
-		Amount:  int64(orderResponse.SendStatus.OrderEvents[0].Amount),
-		Side:    orderResponse.SendStatus.OrderEvents[0].OrderPriorExecution.Side,
-	}, nil
+		Status:  orderResponse.SendStatus.Status,
+	}
+	var amount, cost float64
+	for _, event := range orderResponse.SendStatus.OrderEvents {
+		if event == nil {
+			continue
+		}
+		if event.Type == executionEvent {
+			if event.OrderPriorExecution == nil {
+				return OrderInfo{}, fmt.Errorf(
+					"incorrect received data: OrderPriorExecution is nil")
+			}
+			info.Side = event.OrderPriorExecution.Side
+			if len(event.OrderPriorExecution.Type) != 0 {
+				info.Type = event.OrderPriorExecution.Type
+			}
+			amount += event.Amount
+			cost += event.Amount * event.Price
+			continue
+		}
+		if event.Order != nil {
+			info.Side = event.Order.Side
+			info.Type = event.Order.Type
+			info.Size = int64(event.Order.Quantity)
+			info.Price = event.Order.LimitPrice
+		}
+	}
+	if len(info.Side) == 0 {
+		return OrderInfo{}, fmt.Errorf("incorrect received data: order side is empty")
+	}
+	if amount > 0 {
+		info.Amount = int64(amount)
+		info.Price = cost / amount
+	}
+	return info, nil
+}
+
+// CancelResponse describes incomplete JSON response of order cancellation.
+type CancelResponse struct {
+	Result       string        `json:"result"`
+	CancelStatus *CancelStatus `json:"cancelStatus"`
+}
+
+// CancelStatus is a part of CancelResponse.
+type CancelStatus struct {
+	OrderID string `json:"order_id"`
+	Status  string `json:"status"`
+}
+
+// OpenOrdersResponse describes incomplete JSON response of open orders.
+type OpenOrdersResponse struct {
+	Result     string      `json:"result"`
+	OpenOrders []OpenOrder `json:"openOrders"`
 }
@@ -12,6 +12,13 @@ const (
 	WaitToSell         Signal = "wait to sell"
 	WaitToBuy          Signal = "wait to buy"
 	WaitToSet          Signal = "wait to set"
+
+	LongDirection  Direction = "long"
+	ShortDirection Direction = "short"
+	BothDirection  Direction = "both"
+
+	BandExit   ExitRule = "band"
+	MediumExit ExitRule = "medium"
 )
 
 var (
@@ -21,30 +28,58 @@ var (
 // Signal is necessary for order creation.
 type Signal string
 
+// Direction describes which positions could be opened, signal of the other
+// direction only closes position.
+type Direction string
+
+// ExitRule describes which channel's line closes positions.
+type ExitRule string
+
 // Donchian is implementation of Indicator.
+// Long position is opened by high breakout and short position by low breakout,
+// breakout of the opposite band reverses position.
+// CandleQueue isn't marshalled, channel's lines and position are its state.
 type Donchian struct {
-	CandleQueue   CandleQueue
+	CandleQueue   CandleQueue `json:"-"`
 	High          float64
 	Low           float64
 	Medium        float64
 	ChannelSize   int32
-	IsEntered     bool
+	Exit          ExitRule
+	Machine       PositionMachine
 	lastTimestamp int64
 }
 
-// NewDonchian return pointer to Donchian structure with channel size.
-// Channel size could be default.
-func NewDonchian() *Donchian {
+// NewDonchian return pointer to Donchian structure with params.
+// Channel size could be default, long direction and band exit are default too.
+func NewDonchian(params IndicatorParams) (*Donchian, error) {
+	channelSize, err := periodOrDefault(params.ChannelSize, DefaultChannelSize)
+	if err != nil {
+		return nil, err
+	}
+	machine, err := NewPositionMachine(params.Direction)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported donchian direction <%s>", params.Direction)
+	}
+	exit := params.Exit
+	switch exit {
+	case "":
+		exit = BandExit
+	case BandExit, MediumExit:
+	default:
+		return nil, fmt.Errorf("unsupported donchian exit <%s>", exit)
+	}
 	donchian := &Donchian{
 		CandleQueue:   *NewCandleQueue(),
 		High:          0,
 		Low:           0,
 		Medium:        0,
-		ChannelSize:   DefaultChannelSize,
-		IsEntered:     false,
+		ChannelSize:   channelSize,
+		Exit:          exit,
+		Machine:       machine,
 		lastTimestamp: 0,
 	}
-	return donchian
+	return donchian, nil
 }
 
 // Add is adding implementation of Indicator.
@@ -69,16 +104,32 @@ func (indicator *Donchian) Add(candle Candle) (Signal, error) {
 	indicator.High = indicator.CandleQueue.High
 	indicator.Medium = (indicator.High + indicator.Low) / 2
 
-	if indicator.IsEntered {
-		if candle.Low < indicator.Low {
-			indicator.IsEntered = false
-			return Sell, nil
+	isHighBreak := candle.High > indicator.High
+	isLowBreak := candle.Low < indicator.Low
+	switch indicator.Machine.State {
+	case LongState:
+		if !isLowBreak && candle.Low < indicator.exitLine(indicator.Low) {
+			return indicator.Machine.Move(FlatState), nil
+		}
+		return indicator.Machine.Signal(false, isLowBreak), nil
+	case ShortState:
+		if !isHighBreak && candle.High > indicator.exitLine(indicator.High) {
+			return indicator.Machine.Move(FlatState), nil
 		}
-		return WaitToSell, nil
+		return indicator.Machine.Signal(isHighBreak, false), nil
 	}
-	if candle.High > indicator.High {
-		indicator.IsEntered = true
-		return Buy, nil
+	return indicator.Machine.Signal(isHighBreak, isLowBreak), nil
+}
+
+// exitLine returns the opposite band or medium line by exit rule.
+func (indicator *Donchian) exitLine(band float64) float64 {
+	if indicator.Exit == MediumExit {
+		return indicator.Medium
 	}
-	return WaitToBuy, nil
+	return band
+}
+
+// Reset forgets entered position, so the next entering signal opens it.
+func (indicator *Donchian) Reset() {
+	indicator.Machine.Reset()
 }
@@ -8,13 +8,97 @@ import (
 )
 
 func TestNewDonchian(t *testing.T) {
-	donchian := NewDonchian()
+	donchian, err := NewDonchian(IndicatorParams{})
+	assert.NoError(t, err)
 	assert.NotNil(t, donchian.CandleQueue)
+	assert.Equal(t, donchian.ChannelSize, int32(DefaultChannelSize))
+	assert.Equal(t, donchian.Machine, PositionMachine{Direction: LongDirection, State: FlatState})
+	assert.Equal(t, donchian.Exit, BandExit)
+
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		isError bool
+	}{
+		{name: "custom", params: IndicatorParams{ChannelSize: 20, Direction: BothDirection, Exit: MediumExit}},
+		{name: "negative channel", params: IndicatorParams{ChannelSize: -1}, isError: true},
+		{name: "unknown direction", params: IndicatorParams{Direction: "up"}, isError: true},
+		{name: "unknown exit", params: IndicatorParams{Exit: "low"}, isError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			donchian, err := NewDonchian(test.params)
+			if test.isError {
+				assert.Error(t, err)
+				assert.Nil(t, donchian)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, donchian.ChannelSize, test.params.ChannelSize)
+		})
+	}
+}
+
+func TestDonchian_AddRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		candles [][2]float64
+		signals []Signal
+	}{
+		{
+			name:    "short only",
+			params:  IndicatorParams{Direction: ShortDirection},
+			candles: [][2]float64{{1, 1}, {1, 1}, {1, 1}, {1, 0.5}, {2, 0.5}},
+			signals: []Signal{WaitToSet, WaitToSet, WaitToSell, OpenShort, CloseShort},
+		},
+		{
+			name:    "both directions",
+			params:  IndicatorParams{Direction: BothDirection},
+			candles: [][2]float64{{1, 1}, {1, 1}, {2, 1}, {2, 0.5}, {1, 0.2}, {3, 0.2}},
+			signals: []Signal{WaitToSet, WaitToSet, OpenLong, ReverseToShort, WaitToBuy, ReverseToLong},
+		},
+		{
+			name:    "both directions medium exit",
+			params:  IndicatorParams{Direction: BothDirection, Exit: MediumExit},
+			candles: [][2]float64{{2, 0}, {2, 0}, {3, 1.5}, {2, 1.4}, {2.5, 1.45}, {1, 0.5}, {2, 0.6}},
+			signals: []Signal{WaitToSet, WaitToSet, OpenLong, CloseLong, WaitToBuy, OpenShort, CloseShort},
+		},
+		{
+			name:    "band exit",
+			params:  IndicatorParams{},
+			candles: [][2]float64{{2, 0}, {2, 0}, {3, 1.5}, {2, 1.4}},
+			signals: []Signal{WaitToSet, WaitToSet, OpenLong, WaitToSell},
+		},
+		{
+			name:    "medium exit",
+			params:  IndicatorParams{Exit: MediumExit},
+			candles: [][2]float64{{2, 0}, {2, 0}, {3, 1.5}, {2, 1.4}},
+			signals: []Signal{WaitToSet, WaitToSet, OpenLong, CloseLong},
+		},
+		{
+			name:    "longer channel",
+			params:  IndicatorParams{ChannelSize: 3},
+			candles: [][2]float64{{1, 1}, {1, 1}, {1, 1}, {2, 1}},
+			signals: []Signal{WaitToSet, WaitToSet, WaitToSet, OpenLong},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			donchian, err := NewDonchian(test.params)
+			assert.NoError(t, err)
+			for i, candle := range test.candles {
+				signal, err := donchian.Add(Candle{High: candle[0], Low: candle[1], Time: int64(i + 1)})
+				assert.NoError(t, err)
+				assert.Equal(t, test.signals[i], signal, "candle %d", i)
+			}
+		})
+	}
 }
 
 func TestDonchian_Add(t *testing.T) {
-	donchian := NewDonchian()
-	donchian.ChannelSize = 2
+	donchian, err := NewDonchian(IndicatorParams{})
+	assert.NoError(t, err)
 	var i int32
 	for i = 0; i < donchian.ChannelSize; i++ {
 		signal, err := donchian.Add(Candle{Time: int64(i + 1)})
@@ -24,23 +108,23 @@ func TestDonchian_Add(t *testing.T) {
 	i++
 	signal, err := donchian.Add(Candle{High: 1, Low: 1, Time: int64(i)})
 	assert.NoError(t, err)
-	assert.Equal(t, signal, Buy)
-	assert.Equal(t, donchian.IsEntered, true)
+	assert.Equal(t, signal, OpenLong)
+	assert.Equal(t, donchian.Machine.State, LongState)
 
 	fmt.Println(donchian.Low)
 	i++
 	signal, err = donchian.Add(Candle{High: 1, Low: 1, Time: int64(i)})
 	assert.NoError(t, err)
 	assert.Equal(t, signal, WaitToSell)
-	assert.Equal(t, donchian.IsEntered, true)
+	assert.Equal(t, donchian.Machine.State, LongState)
 
 	i++
 	fmt.Println(donchian.Low)
 	signal, err = donchian.Add(Candle{High: 1, Low: 0, Time: int64(i)})
 	fmt.Println(donchian.Low)
 	assert.NoError(t, err)
-	assert.Equal(t, signal, Sell)
-	assert.Equal(t, donchian.IsEntered, false)
+	assert.Equal(t, signal, CloseLong)
+	assert.Equal(t, donchian.Machine.State, FlatState)
 
 	i++
 	_, err = donchian.Add(Candle{High: 2, Low: 0, Time: int64(i)})
@@ -54,3 +138,11 @@ func TestDonchian_Add(t *testing.T) {
 	_, err = donchian.Add(Candle{High: 3})
 	assert.Error(t, err)
 }
+
+func TestDonchian_Reset(t *testing.T) {
+	donchian, err := NewDonchian(IndicatorParams{Direction: ShortDirection})
+	assert.NoError(t, err)
+	donchian.Machine.State = ShortState
+	donchian.Reset()
+	assert.Equal(t, FlatState, donchian.Machine.State)
+}
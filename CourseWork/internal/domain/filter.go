@@ -0,0 +1,60 @@
+package domain
+
+import "fmt"
+
+const (
+	AndRule  FilterRule = "and"
+	OrRule   FilterRule = "or"
+	VetoRule FilterRule = "veto"
+)
+
+const maxFilters = 5
+
+// FilterRule describes how filter's signals are combined with primary signals.
+// And filter allows entries only in direction of its trend, veto filter blocks
+// entries against its trend and or filter could enter and exit by itself.
+type FilterRule string
+
+// FilterConfig describes Indicator of another interval of the same pair.
+// Trend of filter is the order side of its last order signal.
+type FilterConfig struct {
+	Interval      CandleInterval  `json:"interval"`
+	IndicatorName string          `json:"indicator_name"`
+	Params        IndicatorParams `json:"params"`
+	Rule          FilterRule      `json:"rule,omitempty"`
+}
+
+// RuleOrDefault returns filter's rule, AndRule is default.
+func (filter FilterConfig) RuleOrDefault() FilterRule {
+	if len(filter.Rule) == 0 {
+		return AndRule
+	}
+	return filter.Rule
+}
+
+// Validate checks filter's interval and rule. Indicator is checked by its creation.
+func (filter FilterConfig) Validate(primary CandleInterval) error {
+	if err := filter.Interval.Validate(); err != nil {
+		return err
+	}
+	if filter.Interval == primary {
+		return fmt.Errorf("filter interval is the same as pair interval")
+	}
+	switch filter.RuleOrDefault() {
+	case AndRule, OrRule, VetoRule:
+	default:
+		return fmt.Errorf("unsupported filter rule")
+	}
+	return nil
+}
+
+// Opposite returns signal of opposite direction, wait signals haven't direction.
+func (signal Signal) Opposite() Signal {
+	switch signal {
+	case Buy:
+		return Sell
+	case Sell:
+		return Buy
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterConfig_Validate(t *testing.T) {
+	filter := FilterConfig{Interval: Candle10m}
+	assert.NoError(t, filter.Validate(Candle1m))
+	assert.Equal(t, AndRule, filter.RuleOrDefault())
+	assert.Error(t, filter.Validate(Candle10m))
+	filter.Rule = VetoRule
+	assert.NoError(t, filter.Validate(Candle1m))
+	filter.Rule = "xor"
+	assert.Error(t, filter.Validate(Candle1m))
+	filter = FilterConfig{Interval: "candles_trade_1w"}
+	assert.Error(t, filter.Validate(Candle1m))
+
+	config := Config{PairName: "name", PairInterval: Candle1m,
+		Filters: []FilterConfig{{Interval: Candle1m}}}
+	assert.Error(t, config.Validate())
+	config.Filters = make([]FilterConfig, maxFilters+1)
+	for i := range config.Filters {
+		config.Filters[i].Interval = Candle1h
+	}
+	assert.Error(t, config.Validate())
+	config.Filters = config.Filters[:1]
+	assert.NoError(t, config.Validate())
+}
+
+func TestSignal_Opposite(t *testing.T) {
+	assert.Equal(t, Sell, Buy.Opposite())
+	assert.Equal(t, Buy, Sell.Opposite())
+	assert.Equal(t, Signal(""), WaitToBuy.Opposite())
+}
@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	DefaultRSIPeriod       = 14
+	DefaultOverbought      = 70
+	DefaultOversold        = 30
+	DefaultFastPeriod      = 12
+	DefaultSlowPeriod      = 26
+	DefaultSignalPeriod    = 9
+	DefaultBollingerPeriod = 20
+	DefaultDeviation       = 2
+	DefaultFastSMAPeriod   = 5
+	DefaultSlowSMAPeriod   = 20
+	DefaultWarmUp          = 100
+	maxIndicatorPeriod     = 1000
+	maxIndicatorThreshold  = 100
+)
+
+// IndicatorParams consists of indicators' parameters from Config.
+// Zero values are replaced by indicator's defaults. Direction limits positions of
+// every indicator, long-then-flat is default.
+type IndicatorParams struct {
+	ChannelSize  int32     `json:"channel_size,omitempty"`
+	Direction    Direction `json:"direction,omitempty"`
+	Exit         ExitRule  `json:"exit,omitempty"`
+	Period       int32     `json:"period,omitempty"`
+	FastPeriod   int32     `json:"fast_period,omitempty"`
+	SlowPeriod   int32     `json:"slow_period,omitempty"`
+	SignalPeriod int32     `json:"signal_period,omitempty"`
+	Overbought   float64   `json:"overbought,omitempty"`
+	Oversold     float64   `json:"oversold,omitempty"`
+	Deviation    float64   `json:"deviation,omitempty"`
+}
+
+// candleGuard validates candles and skips candles with stored timestamps.
+type candleGuard struct {
+	lastTimestamp int64
+}
+
+// check returns error if candle can't be added to indicator.
+func (guard *candleGuard) check(candle Candle, indicatorName string) error {
+	if err := candle.Validate(); err != nil {
+		return fmt.Errorf("incorrect candle parameters in %s: %w", indicatorName, err)
+	}
+	if candle.Time <= guard.lastTimestamp {
+		return ErrSameTimestamp
+	}
+	guard.lastTimestamp = candle.Time
+	return nil
+}
+
+// directionOrDefault returns long direction if direction isn't set.
+func directionOrDefault(direction Direction) (Direction, error) {
+	switch direction {
+	case "":
+		return LongDirection, nil
+	case LongDirection, ShortDirection, BothDirection:
+		return direction, nil
+	}
+	return "", fmt.Errorf("unsupported direction <%s>", direction)
+}
+
+// window stores last values with fixed size.
+type window struct {
+	values []float64
+	size   int32
+}
+
+// newWindow returns window with fixed size.
+func newWindow(size int32) *window {
+	return &window{
+		values: make([]float64, 0, size),
+		size:   size,
+	}
+}
+
+// push adds value and removes the oldest one if window is full.
+func (w *window) push(value float64) {
+	if int32(len(w.values)) == w.size {
+		w.values = w.values[1:]
+	}
+	w.values = append(w.values, value)
+}
+
+// isFull checks if window has size values.
+func (w *window) isFull() bool {
+	return int32(len(w.values)) == w.size
+}
+
+// mean returns arithmetic mean of window's values.
+func (w *window) mean() float64 {
+	if len(w.values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, value := range w.values {
+		sum += value
+	}
+	return sum / float64(len(w.values))
+}
+
+// deviation returns population standard deviation of window's values.
+func (w *window) deviation() float64 {
+	if len(w.values) == 0 {
+		return 0
+	}
+	mean := w.mean()
+	var sum float64
+	for _, value := range w.values {
+		sum += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(sum / float64(len(w.values)))
+}
+
+// ema is exponential moving average, which is seeded by simple average.
+type ema struct {
+	seed   *window
+	value  float64
+	alpha  float64
+	isSet  bool
+	period int32
+}
+
+// newEMA returns ema with period.
+func newEMA(period int32) *ema {
+	return &ema{
+		seed:   newWindow(period),
+		alpha:  2 / float64(period+1),
+		period: period,
+	}
+}
+
+// add updates average and returns false until it is seeded.
+func (average *ema) add(value float64) bool {
+	if !average.isSet {
+		average.seed.push(value)
+		if !average.seed.isFull() {
+			return false
+		}
+		average.value = average.seed.mean()
+		average.isSet = true
+		return true
+	}
+	average.value = average.alpha*value + (1-average.alpha)*average.value
+	return true
+}
+
+// periodOrDefault returns default period if period isn't set.
+func periodOrDefault(period, defaultPeriod int32) (int32, error) {
+	if period == 0 {
+		return defaultPeriod, nil
+	}
+	if period < 0 || period > maxIndicatorPeriod {
+		return 0, fmt.Errorf("period is out of bounds")
+	}
+	return period, nil
+}
+
+// valueOrDefault returns default value if value isn't set.
+func valueOrDefault(value, defaultValue float64) (float64, error) {
+	if value == 0 {
+		return defaultValue, nil
+	}
+	if value < 0 || value > maxIndicatorThreshold {
+		return 0, fmt.Errorf("threshold is out of bounds")
+	}
+	return value, nil
+}
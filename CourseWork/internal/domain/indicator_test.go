@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// closeCandles returns candles with increasing timestamps by close prices.
+func closeCandles(closes ...float64) []Candle {
+	candles := make([]Candle, len(closes))
+	for i, cl := range closes {
+		candles[i] = Candle{Open: cl, High: cl, Low: cl, Close: cl, Time: int64(i + 1)}
+	}
+	return candles
+}
+
+// indicator is the same interface as service's Indicator.
+type indicator interface {
+	Add(candle Candle) (Signal, error)
+}
+
+// assertSignals checks indicator's signals sequence.
+func assertSignals(t *testing.T, indicator indicator, closes []float64, signals []Signal) {
+	for i, candle := range closeCandles(closes...) {
+		signal, err := indicator.Add(candle)
+		assert.NoError(t, err)
+		assert.Equal(t, signals[i], signal, "candle %d", i)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	w := newWindow(2)
+	assert.False(t, w.isFull())
+	assert.Equal(t, w.mean(), 0.)
+	w.push(1)
+	w.push(3)
+	assert.True(t, w.isFull())
+	assert.Equal(t, w.mean(), 2.)
+	assert.Equal(t, w.deviation(), 1.)
+	w.push(5)
+	assert.Equal(t, w.mean(), 4.)
+}
+
+func TestEMA(t *testing.T) {
+	average := newEMA(2)
+	assert.False(t, average.add(1))
+	assert.True(t, average.add(3))
+	assert.Equal(t, average.value, 2.)
+	assert.True(t, average.add(5))
+	assert.InDelta(t, average.value, 4, 1e-9)
+}
+
+func TestCandleGuard(t *testing.T) {
+	guard := candleGuard{}
+	assert.NoError(t, guard.check(Candle{Time: 1}, "test"))
+	assert.ErrorIs(t, guard.check(Candle{Time: 1}, "test"), ErrSameTimestamp)
+	assert.Error(t, guard.check(Candle{Time: 2, Close: -1}, "test"))
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"fmt"
+)
+
+// MACD is moving average convergence divergence implementation of Indicator.
+// Long position is opened when MACD line crosses signal line upwards
+// and short position when downwards.
+type MACD struct {
+	FastPeriod   int32
+	SlowPeriod   int32
+	SignalPeriod int32
+	Line         float64
+	SignalLine   float64
+	Machine      PositionMachine
+	fast         *ema
+	slow         *ema
+	signal       *ema
+	isAbove      bool
+	isCrossReady bool
+	guard        candleGuard
+}
+
+// NewMACD returns pointer to MACD with params or default values.
+func NewMACD(params IndicatorParams) (*MACD, error) {
+	fast, err := periodOrDefault(params.FastPeriod, DefaultFastPeriod)
+	if err != nil {
+		return nil, err
+	}
+	slow, err := periodOrDefault(params.SlowPeriod, DefaultSlowPeriod)
+	if err != nil {
+		return nil, err
+	}
+	signal, err := periodOrDefault(params.SignalPeriod, DefaultSignalPeriod)
+	if err != nil {
+		return nil, err
+	}
+	machine, err := NewPositionMachine(params.Direction)
+	if err != nil {
+		return nil, err
+	}
+	if fast >= slow {
+		return nil, fmt.Errorf("fast period should be less than slow period")
+	}
+	return &MACD{
+		FastPeriod:   fast,
+		SlowPeriod:   slow,
+		SignalPeriod: signal,
+		fast:         newEMA(fast),
+		slow:         newEMA(slow),
+		signal:       newEMA(signal),
+		Machine:      machine,
+	}, nil
+}
+
+// Add is adding implementation of Indicator.
+func (indicator *MACD) Add(candle Candle) (Signal, error) {
+	if err := indicator.guard.check(candle, "macd"); err != nil {
+		return "", err
+	}
+	isFastSet := indicator.fast.add(candle.Close)
+	if !indicator.slow.add(candle.Close) || !isFastSet {
+		return WaitToSet, nil
+	}
+	indicator.Line = indicator.fast.value - indicator.slow.value
+	if !indicator.signal.add(indicator.Line) {
+		return WaitToSet, nil
+	}
+	indicator.SignalLine = indicator.signal.value
+	isAbove := indicator.Line > indicator.SignalLine
+	isCrossedUp := indicator.isCrossReady && isAbove && !indicator.isAbove
+	isCrossedDown := indicator.isCrossReady && !isAbove && indicator.isAbove
+	indicator.isAbove = isAbove
+	indicator.isCrossReady = true
+	return indicator.Machine.Signal(isCrossedUp, isCrossedDown), nil
+}
+
+// Reset forgets entered position, so the next entering signal opens it.
+func (indicator *MACD) Reset() {
+	indicator.Machine.Reset()
+}
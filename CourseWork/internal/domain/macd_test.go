@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMACD(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		isError bool
+	}{
+		{name: "default", params: IndicatorParams{}},
+		{name: "custom", params: IndicatorParams{FastPeriod: 2, SlowPeriod: 3, SignalPeriod: 2}},
+		{name: "crossed periods", params: IndicatorParams{FastPeriod: 3, SlowPeriod: 2}, isError: true},
+		{name: "negative signal", params: IndicatorParams{SignalPeriod: -1}, isError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			macd, err := NewMACD(test.params)
+			if test.isError {
+				assert.Error(t, err)
+				assert.Nil(t, macd)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, macd)
+		})
+	}
+}
+
+func TestMACD_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		closes  []float64
+		signals []Signal
+	}{
+		{
+			name:   "fall, rise and dip",
+			params: IndicatorParams{FastPeriod: 2, SlowPeriod: 3, SignalPeriod: 2},
+			closes: []float64{10, 9, 8, 7, 8, 10, 12, 11, 9, 7},
+			signals: []Signal{WaitToSet, WaitToSet, WaitToSet, WaitToBuy, OpenLong,
+				WaitToSell, WaitToSell, CloseLong, WaitToBuy, WaitToBuy},
+		},
+		{
+			name:    "growth without cross",
+			params:  IndicatorParams{FastPeriod: 2, SlowPeriod: 3, SignalPeriod: 2},
+			closes:  []float64{1, 2, 3, 4, 5, 6},
+			signals: []Signal{WaitToSet, WaitToSet, WaitToSet, WaitToBuy, WaitToBuy, WaitToBuy},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			macd, err := NewMACD(test.params)
+			assert.NoError(t, err)
+			assertSignals(t, macd, test.closes, test.signals)
+		})
+	}
+
+	macd, err := NewMACD(IndicatorParams{})
+	assert.NoError(t, err)
+	_, err = macd.Add(Candle{Close: -1, Time: 1})
+	assert.Error(t, err)
+}
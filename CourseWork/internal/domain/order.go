@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultOrdersLimit = 50
+	MaxOrdersLimit     = 500
+)
+
+// OrderFilter describes user's orders page request.
+// Orders are sorted from the newest, Cursor is ID of the last order of previous page.
+type OrderFilter struct {
+	Username string
+	OrderID  string
+	PairName string
+	Side     string
+	From     time.Time
+	To       time.Time
+	Cursor   int64
+	Limit    int64
+}
+
+// Validate checks OrderFilter bounds and sets default limit.
+func (filter *OrderFilter) Validate() error {
+	if len(filter.Username) == 0 {
+		return fmt.Errorf("username is empty")
+	}
+	if len(filter.Side) != 0 && filter.Side != string(Buy) && filter.Side != string(Sell) {
+		return fmt.Errorf("unsupported order side")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return fmt.Errorf("from is after to")
+	}
+	if filter.Cursor < 0 {
+		return fmt.Errorf("cursor is negative")
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultOrdersLimit
+	}
+	if filter.Limit < 0 || filter.Limit > MaxOrdersLimit {
+		return fmt.Errorf("limit is out of bounds")
+	}
+	return nil
+}
+
+// OrderPage consists of orders and cursor of the next page, which is zero on the last page.
+type OrderPage struct {
+	Orders     []OrderInfo `json:"orders"`
+	NextCursor int64       `json:"next_cursor"`
+}
+
+const (
+	IOCOrder        OrderType = "ioc"
+	LimitOrder      OrderType = "lmt"
+	PostOnlyOrder   OrderType = "post"
+	StopOrder       OrderType = "stp"
+	TakeProfitOrder OrderType = "take_profit"
+)
+
+// OrderType describes Kraken order type.
+type OrderType string
+
+// IsResting checks if unfilled part of order rests on stock market.
+func (orderType OrderType) IsResting() bool {
+	return len(orderType) != 0 && orderType != IOCOrder
+}
+
+// OrderOptions describes order placement, immediate-or-cancel order is default.
+// Trigger is a fraction of close, which moves trigger price of stop and take-profit orders.
+type OrderOptions struct {
+	Type       OrderType `json:"type,omitempty"`
+	Trigger    float64   `json:"trigger,omitempty"`
+	ReduceOnly bool      `json:"reduce_only,omitempty"`
+}
+
+// Validate checks OrderOptions type and trigger.
+func (options OrderOptions) Validate() error {
+	switch options.Type {
+	case "", IOCOrder, LimitOrder, PostOnlyOrder:
+	case StopOrder, TakeProfitOrder:
+		if options.Trigger == 0 {
+			return fmt.Errorf("trigger is empty")
+		}
+	default:
+		return fmt.Errorf("unsupported order type")
+	}
+	if options.Trigger < 0 || options.Trigger >= 1 {
+		return fmt.Errorf("trigger is out of bounds")
+	}
+	return nil
+}
+
+// OrderType returns order type or immediate-or-cancel if it isn't set.
+func (options OrderOptions) OrderType() OrderType {
+	if len(options.Type) == 0 {
+		return IOCOrder
+	}
+	return options.Type
+}
+
+// Entry returns options of signal's order. Stop and take-profit orders protect
+// filled signal's order, so signal's order is immediate-or-cancel then.
+func (options OrderOptions) Entry() OrderOptions {
+	if options.Type == StopOrder || options.Type == TakeProfitOrder {
+		return OrderOptions{Type: IOCOrder, ReduceOnly: options.ReduceOnly}
+	}
+	return options
+}
+
+// Protection returns options of reduce-only stop or take-profit order, which protects
+// filled signal's order. Other order types aren't protected.
+func (options OrderOptions) Protection() (OrderOptions, bool) {
+	if options.Type != StopOrder && options.Type != TakeProfitOrder {
+		return OrderOptions{}, false
+	}
+	return OrderOptions{Type: options.Type, Trigger: options.Trigger, ReduceOnly: true}, true
+}
+
+// TriggerPrice counts trigger price by side, stop order is triggered by breaking price
+// in the order side and take-profit order in the opposite side.
+func (options OrderOptions) TriggerPrice(side Signal, price float64) float64 {
+	offset := options.Trigger
+	if side == Sell {
+		offset = -offset
+	}
+	if options.Type == TakeProfitOrder {
+		offset = -offset
+	}
+	return price * (1 + offset)
+}
+
+// OpenOrder describes resting order on stock market.
+type OpenOrder struct {
+	OrderID      string    `json:"order_id"`
+	Symbol       string    `json:"symbol"`
+	Side         string    `json:"side"`
+	Type         OrderType `json:"orderType"`
+	LimitPrice   float64   `json:"limitPrice"`
+	StopPrice    float64   `json:"stopPrice,omitempty"`
+	UnfilledSize float64   `json:"unfilledSize"`
+	FilledSize   float64   `json:"filledSize"`
+	ReduceOnly   bool      `json:"reduceOnly"`
+	Status       string    `json:"status"`
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderFilter_Validate(t *testing.T) {
+	filter := OrderFilter{Username: "username"}
+	assert.NoError(t, filter.Validate())
+	assert.Equal(t, filter.Limit, int64(DefaultOrdersLimit))
+
+	now := time.Now()
+	tests := []struct {
+		name   string
+		filter OrderFilter
+	}{
+		{name: "empty username", filter: OrderFilter{}},
+		{name: "side", filter: OrderFilter{Username: "x", Side: "wait to buy"}},
+		{name: "dates", filter: OrderFilter{Username: "x", From: now, To: now.Add(-time.Hour)}},
+		{name: "cursor", filter: OrderFilter{Username: "x", Cursor: -1}},
+		{name: "negative limit", filter: OrderFilter{Username: "x", Limit: -1}},
+		{name: "big limit", filter: OrderFilter{Username: "x", Limit: MaxOrdersLimit + 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Error(t, test.filter.Validate())
+		})
+	}
+
+	filter = OrderFilter{Username: "x", Side: string(Sell), From: now, To: now, Limit: 1}
+	assert.NoError(t, filter.Validate())
+	assert.Equal(t, filter.Limit, int64(1))
+}
+
+func TestOrderOptions_Validate(t *testing.T) {
+	assert.NoError(t, OrderOptions{}.Validate())
+	assert.NoError(t, OrderOptions{Type: PostOnlyOrder, ReduceOnly: true}.Validate())
+	assert.NoError(t, OrderOptions{Type: StopOrder, Trigger: 0.1}.Validate())
+	assert.Error(t, OrderOptions{Type: "mkt"}.Validate())
+	assert.Error(t, OrderOptions{Type: TakeProfitOrder}.Validate())
+	assert.Error(t, OrderOptions{Type: StopOrder, Trigger: 1}.Validate())
+	assert.Error(t, OrderOptions{Trigger: -0.1}.Validate())
+	assert.Equal(t, OrderOptions{}.OrderType(), IOCOrder)
+}
+
+func TestOrderType_IsResting(t *testing.T) {
+	assert.False(t, OrderType("").IsResting())
+	assert.False(t, IOCOrder.IsResting())
+	assert.True(t, LimitOrder.IsResting())
+	assert.True(t, StopOrder.IsResting())
+}
+
+func TestOrderOptions_Protection(t *testing.T) {
+	limit := OrderOptions{Type: LimitOrder, ReduceOnly: true}
+	assert.Equal(t, limit.Entry(), limit)
+	_, ok := limit.Protection()
+	assert.False(t, ok)
+	_, ok = OrderOptions{}.Protection()
+	assert.False(t, ok)
+
+	stop := OrderOptions{Type: StopOrder, Trigger: 0.1}
+	assert.Equal(t, stop.Entry(), OrderOptions{Type: IOCOrder})
+	protection, ok := stop.Protection()
+	assert.True(t, ok)
+	assert.Equal(t, protection, OrderOptions{Type: StopOrder, Trigger: 0.1, ReduceOnly: true})
+	takeProfit := OrderOptions{Type: TakeProfitOrder, Trigger: 0.2, ReduceOnly: true}
+	assert.Equal(t, takeProfit.Entry(), OrderOptions{Type: IOCOrder, ReduceOnly: true})
+	protection, ok = takeProfit.Protection()
+	assert.True(t, ok)
+	assert.Equal(t, protection, takeProfit)
+}
+
+func TestOrderOptions_TriggerPrice(t *testing.T) {
+	stop := OrderOptions{Type: StopOrder, Trigger: 0.1}
+	assert.InDelta(t, stop.TriggerPrice(Buy, 10), 11, 1e-9)
+	assert.InDelta(t, stop.TriggerPrice(Sell, 10), 9, 1e-9)
+	takeProfit := OrderOptions{Type: TakeProfitOrder, Trigger: 0.1}
+	assert.InDelta(t, takeProfit.TriggerPrice(Buy, 10), 9, 1e-9)
+	assert.InDelta(t, takeProfit.TriggerPrice(Sell, 10), 11, 1e-9)
+}
+
+func TestOrderResponse_Message(t *testing.T) {
+	_, err := OrderResponse{}.Message("name")
+	assert.Error(t, err)
+	_, err = OrderResponse{SendStatus: &SendStatus{}}.Message("name")
+	assert.Error(t, err)
+	_, err = OrderResponse{SendStatus: &SendStatus{OrderEvents: []*OrderEvent{
+		{Type: executionEvent, Price: 1, Amount: 1}}}}.Message("name")
+	assert.Error(t, err)
+
+	prior := &OrderPriorExecution{Side: string(Buy), Type: LimitOrder}
+	info, err := OrderResponse{SendStatus: &SendStatus{
+		OrderID: "id",
+		Status:  "placed",
+		OrderEvents: []*OrderEvent{
+			{Type: executionEvent, Price: 10, Amount: 1, OrderPriorExecution: prior},
+			{Type: executionEvent, Price: 13, Amount: 2, OrderPriorExecution: prior},
+			{Type: "PLACE", Order: &PlacedOrder{Type: LimitOrder, Side: string(Buy),
+				Quantity: 5, Filled: 3, LimitPrice: 13}},
+		},
+	}}.Message("name")
+	assert.NoError(t, err)
+	assert.Equal(t, info.Amount, int64(3))
+	assert.Equal(t, info.Size, int64(5))
+	assert.InDelta(t, info.Price, 12, 1e-9)
+	assert.Equal(t, info.Type, LimitOrder)
+	assert.Equal(t, info.Status, "placed")
+
+	// resting order without executions
+	info, err = OrderResponse{SendStatus: &SendStatus{OrderEvents: []*OrderEvent{
+		{Type: "PLACE", Order: &PlacedOrder{Type: StopOrder, Side: string(Sell),
+			Quantity: 2, LimitPrice: 9}},
+	}}}.Message("name")
+	assert.NoError(t, err)
+	assert.Zero(t, info.Amount)
+	assert.Equal(t, info.Price, 9.)
+	assert.Equal(t, info.Side, string(Sell))
+}
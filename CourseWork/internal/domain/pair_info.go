@@ -0,0 +1,32 @@
+package domain
+
+import "encoding/json"
+
+const (
+	ConnectedSocket    SocketStatus = "connected"
+	ReconnectingSocket SocketStatus = "reconnecting"
+	DisconnectedSocket SocketStatus = "disconnected"
+	UnknownSocket      SocketStatus = "unknown"
+)
+
+// SocketStatus describes state of stock market's socket connection.
+type SocketStatus string
+
+// PairInfo describes running pair for admins. Paused pair reads candles,
+// but its signals aren't sent as orders. LastCandle is time of the last received candle.
+type PairInfo struct {
+	Name         string           `json:"name"`
+	Interval     CandleInterval   `json:"interval"`
+	Paused       bool             `json:"paused"`
+	LastCandle   int64            `json:"last_candle_time"`
+	SocketStatus SocketStatus     `json:"socket_status"`
+	Subscribers  []SubscriberInfo `json:"subscribers"`
+}
+
+// SubscriberInfo describes user's subscription on running pair with its Indicator state.
+type SubscriberInfo struct {
+	Username  string          `json:"username"`
+	Indicator string          `json:"indicator_name"`
+	Mode      TradingMode     `json:"mode"`
+	State     json.RawMessage `json:"state,omitempty"`
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// Position describes user's pair position built from order fills.
+// Size is positive for long position and negative for short one.
+type Position struct {
+	PairName      string  `json:"pair_name"`
+	Size          int64   `json:"size"`
+	AvgPrice      float64 `json:"avg_price"`
+	LastPrice     float64 `json:"last_price"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// Fill applies order fill to Position, opposite fill realizes PnL of closed part.
+func (position *Position) Fill(side string, price float64, amount int64) error {
+	if amount <= 0 || price <= 0 {
+		return fmt.Errorf("fill price and amount should be positive")
+	}
+	delta := amount
+	switch side {
+	case string(Buy):
+	case string(Sell):
+		delta = -amount
+	default:
+		return fmt.Errorf("unsupported fill side <%s>", side)
+	}
+	if position.Size == 0 || (position.Size > 0) == (delta > 0) {
+		size := math.Abs(float64(position.Size))
+		position.AvgPrice = (position.AvgPrice*size + price*float64(amount)) /
+			(size + float64(amount))
+		position.Size += delta
+	} else {
+		closed := amount
+		if absSize := int64(math.Abs(float64(position.Size))); absSize < closed {
+			closed = absSize
+		}
+		position.RealizedPnL += float64(closed) * (price - position.AvgPrice) * position.direction()
+		position.Size += delta
+		switch {
+		case position.Size == 0:
+			position.AvgPrice = 0
+		case (position.Size > 0) == (delta > 0):
+			// position is reversed, the rest is opened by fill price
+			position.AvgPrice = price
+		}
+	}
+	position.Mark(price)
+	return nil
+}
+
+// Mark recounts unrealized PnL by the latest price.
+func (position *Position) Mark(price float64) {
+	position.LastPrice = price
+	position.UnrealizedPnL = float64(position.Size) * (price - position.AvgPrice)
+	if position.Size == 0 {
+		position.UnrealizedPnL = 0
+	}
+}
+
+// TotalPnL returns sum of realized and unrealized PnL.
+func (position Position) TotalPnL() float64 {
+	return position.RealizedPnL + position.UnrealizedPnL
+}
+
+// direction returns 1 for long Position and -1 for short one.
+func (position Position) direction() float64 {
+	if position.Size < 0 {
+		return -1
+	}
+	return 1
+}
+
+func (position Position) String() string {
+	return fmt.Sprintf("Position: <%d>,\n"+
+		"Average price: <%.2f>,\n"+
+		"Realized PnL: <%.2f>,\n"+
+		"Unrealized PnL: <%.2f>", position.Size, position.AvgPrice,
+		position.RealizedPnL, position.UnrealizedPnL)
+}
+
+// Portfolio consists of user's positions with total PnL and allocations of running pairs.
+type Portfolio struct {
+	Username      string             `json:"username"`
+	Positions     []Position         `json:"positions"`
+	RealizedPnL   float64            `json:"realized_pnl"`
+	UnrealizedPnL float64            `json:"unrealized_pnl"`
+	TotalPnL      float64            `json:"total_pnl"`
+	Allocations   map[string]float64 `json:"allocations,omitempty"`
+}
+
+// NewPortfolio returns Portfolio and sums positions' PnL.
+func NewPortfolio(username string, positions []Position) Portfolio {
+	portfolio := Portfolio{Username: username, Positions: positions}
+	for _, position := range positions {
+		portfolio.RealizedPnL += position.RealizedPnL
+		portfolio.UnrealizedPnL += position.UnrealizedPnL
+	}
+	portfolio.TotalPnL = portfolio.RealizedPnL + portfolio.UnrealizedPnL
+	return portfolio
+}
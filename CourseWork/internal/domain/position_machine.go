@@ -0,0 +1,180 @@
+package domain
+
+const (
+	FlatState  PositionState = "flat"
+	LongState  PositionState = "long"
+	ShortState PositionState = "short"
+
+	OpenLong       Signal = "open long"
+	CloseLong      Signal = "close long"
+	OpenShort      Signal = "open short"
+	CloseShort     Signal = "close short"
+	ReverseToLong  Signal = "reverse to long"
+	ReverseToShort Signal = "reverse to short"
+)
+
+// PositionState describes indicator's position.
+type PositionState string
+
+// transitions consist of signals, which move position from one state to another.
+var transitions = map[PositionState]map[PositionState]Signal{
+	FlatState:  {LongState: OpenLong, ShortState: OpenShort},
+	LongState:  {FlatState: CloseLong, ShortState: ReverseToShort},
+	ShortState: {FlatState: CloseShort, LongState: ReverseToLong},
+}
+
+// PositionMachine switches flat, long and short states of indicator's position.
+// Direction limits which positions could be opened, long direction is default.
+type PositionMachine struct {
+	Direction Direction
+	State     PositionState
+}
+
+// NewPositionMachine returns flat PositionMachine with direction or default one.
+func NewPositionMachine(direction Direction) (PositionMachine, error) {
+	direction, err := directionOrDefault(direction)
+	if err != nil {
+		return PositionMachine{}, err
+	}
+	return PositionMachine{Direction: direction, State: FlatState}, nil
+}
+
+// Signal moves position by long and short conditions, long condition is checked first.
+// Condition of not allowed direction only closes the opposite position.
+func (machine *PositionMachine) Signal(long, short bool) Signal {
+	switch {
+	case long:
+		if machine.Direction == ShortDirection {
+			return machine.Move(FlatState)
+		}
+		return machine.Move(LongState)
+	case short:
+		if machine.Direction == LongDirection {
+			return machine.Move(FlatState)
+		}
+		return machine.Move(ShortState)
+	}
+	return machine.Wait()
+}
+
+// Allows checks if target position is allowed by Direction, flat position is always allowed.
+func (machine PositionMachine) Allows(target PositionState) bool {
+	switch target {
+	case LongState:
+		return machine.Direction != ShortDirection
+	case ShortState:
+		return machine.Direction != LongDirection
+	}
+	return true
+}
+
+// Move switches position to target state and returns signal of the transition.
+// The same state returns wait signal.
+func (machine *PositionMachine) Move(target PositionState) Signal {
+	signal, ok := transitions[machine.state()][target]
+	if !ok {
+		return machine.Wait()
+	}
+	machine.State = target
+	return signal
+}
+
+// Wait returns wait signal by position state.
+func (machine PositionMachine) Wait() Signal {
+	switch machine.state() {
+	case LongState:
+		return WaitToSell
+	case ShortState:
+		return WaitToBuy
+	}
+	if machine.Direction == ShortDirection {
+		return WaitToSell
+	}
+	return WaitToBuy
+}
+
+// Reset forgets entered position.
+func (machine *PositionMachine) Reset() {
+	machine.State = FlatState
+}
+
+// state returns position state, zero state is flat.
+func (machine PositionMachine) state() PositionState {
+	if len(machine.State) == 0 {
+		return FlatState
+	}
+	return machine.State
+}
+
+// Side returns order side of signal, wait signals haven't side.
+func (signal Signal) Side() Signal {
+	switch signal {
+	case Buy, OpenLong, CloseShort, ReverseToLong:
+		return Buy
+	case Sell, OpenShort, CloseLong, ReverseToShort:
+		return Sell
+	}
+	return ""
+}
+
+// IsOrder checks if signal creates order.
+func (signal Signal) IsOrder() bool {
+	return len(signal.Side()) != 0
+}
+
+// IsReduceOnly checks if signal only closes position.
+func (signal Signal) IsReduceOnly() bool {
+	return signal == CloseLong || signal == CloseShort
+}
+
+// Exit returns reduce-only part of signal, which closes position.
+// Reverse signal closes position, entering signals haven't reduce-only part.
+func (signal Signal) Exit() (Signal, bool) {
+	switch signal {
+	case CloseLong, ReverseToShort:
+		return CloseLong, true
+	case CloseShort, ReverseToLong:
+		return CloseShort, true
+	}
+	return "", false
+}
+
+// Target returns position state after signal. Buy and Sell signals of long-then-flat
+// indicators close the opposite position and open position otherwise.
+func (signal Signal) Target(state PositionState) (PositionState, bool) {
+	switch signal {
+	case OpenLong, ReverseToLong:
+		return LongState, true
+	case OpenShort, ReverseToShort:
+		return ShortState, true
+	case CloseLong, CloseShort:
+		return FlatState, true
+	case Buy:
+		if state == ShortState {
+			return FlatState, true
+		}
+		return LongState, true
+	case Sell:
+		if state == LongState {
+			return FlatState, true
+		}
+		return ShortState, true
+	}
+	return "", false
+}
+
+// OrderVolume returns order volume of signal by position size. Close signal closes
+// the whole position and reverse signal closes it and opens volume in the opposite side.
+func (signal Signal) OrderVolume(volume, size int64) int64 {
+	switch {
+	case signal == CloseLong && size > 0:
+		return size
+	case signal == CloseShort && size < 0:
+		return -size
+	case signal == ReverseToShort && size > 0:
+		return volume + size
+	case signal == ReverseToLong && size < 0:
+		return volume - size
+	}
+	return volume
+}
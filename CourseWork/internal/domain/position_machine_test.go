@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPositionMachine(t *testing.T) {
+	machine, err := NewPositionMachine("")
+	assert.NoError(t, err)
+	assert.Equal(t, PositionMachine{Direction: LongDirection, State: FlatState}, machine)
+	_, err = NewPositionMachine("up")
+	assert.Error(t, err)
+}
+
+func TestPositionMachine_Signal(t *testing.T) {
+	long := PositionMachine{Direction: LongDirection}
+	assert.Equal(t, WaitToBuy, long.Signal(false, true))
+	assert.Equal(t, OpenLong, long.Signal(true, false))
+	assert.Equal(t, WaitToSell, long.Signal(true, false))
+	assert.Equal(t, CloseLong, long.Signal(false, true))
+	assert.Equal(t, FlatState, long.State)
+
+	short := PositionMachine{Direction: ShortDirection}
+	assert.Equal(t, WaitToSell, short.Signal(true, false))
+	assert.Equal(t, OpenShort, short.Signal(false, true))
+	assert.Equal(t, WaitToBuy, short.Signal(false, false))
+	assert.Equal(t, CloseShort, short.Signal(true, false))
+
+	both := PositionMachine{Direction: BothDirection}
+	assert.Equal(t, OpenShort, both.Signal(false, true))
+	assert.Equal(t, ReverseToLong, both.Signal(true, true))
+	assert.Equal(t, ReverseToShort, both.Signal(false, true))
+	assert.Equal(t, CloseShort, both.Move(FlatState))
+	both.State = LongState
+	both.Reset()
+	assert.Equal(t, FlatState, both.State)
+}
+
+func TestPositionMachine_Allows(t *testing.T) {
+	long := PositionMachine{Direction: LongDirection}
+	assert.True(t, long.Allows(LongState))
+	assert.False(t, long.Allows(ShortState))
+	assert.True(t, long.Allows(FlatState))
+	short := PositionMachine{Direction: ShortDirection}
+	assert.False(t, short.Allows(LongState))
+	assert.True(t, short.Allows(ShortState))
+	both := PositionMachine{Direction: BothDirection}
+	assert.True(t, both.Allows(LongState))
+	assert.True(t, both.Allows(ShortState))
+}
+
+func TestSignal_Side(t *testing.T) {
+	tests := []struct {
+		signal     Signal
+		side       Signal
+		reduceOnly bool
+	}{
+		{signal: Buy, side: Buy},
+		{signal: Sell, side: Sell},
+		{signal: OpenLong, side: Buy},
+		{signal: CloseLong, side: Sell, reduceOnly: true},
+		{signal: OpenShort, side: Sell},
+		{signal: CloseShort, side: Buy, reduceOnly: true},
+		{signal: ReverseToLong, side: Buy},
+		{signal: ReverseToShort, side: Sell},
+		{signal: WaitToBuy},
+	}
+	for _, test := range tests {
+		t.Run(string(test.signal), func(t *testing.T) {
+			assert.Equal(t, test.side, test.signal.Side())
+			assert.Equal(t, len(test.side) != 0, test.signal.IsOrder())
+			assert.Equal(t, test.reduceOnly, test.signal.IsReduceOnly())
+		})
+	}
+}
+
+func TestSignal_Exit(t *testing.T) {
+	exit, ok := ReverseToShort.Exit()
+	assert.True(t, ok)
+	assert.Equal(t, CloseLong, exit)
+	exit, _ = ReverseToLong.Exit()
+	assert.Equal(t, CloseShort, exit)
+	exit, _ = CloseLong.Exit()
+	assert.Equal(t, CloseLong, exit)
+	_, ok = OpenLong.Exit()
+	assert.False(t, ok)
+	_, ok = Buy.Exit()
+	assert.False(t, ok)
+}
+
+func TestSignal_Target(t *testing.T) {
+	target, ok := ReverseToShort.Target(LongState)
+	assert.True(t, ok)
+	assert.Equal(t, ShortState, target)
+	target, _ = Buy.Target(ShortState)
+	assert.Equal(t, FlatState, target)
+	target, _ = Buy.Target(FlatState)
+	assert.Equal(t, LongState, target)
+	target, _ = Sell.Target(LongState)
+	assert.Equal(t, FlatState, target)
+	_, ok = WaitToSell.Target(LongState)
+	assert.False(t, ok)
+}
+
+func TestSignal_OrderVolume(t *testing.T) {
+	assert.Equal(t, int64(3), CloseLong.OrderVolume(1, 3))
+	assert.Equal(t, int64(1), CloseLong.OrderVolume(1, -3))
+	assert.Equal(t, int64(3), CloseShort.OrderVolume(1, -3))
+	assert.Equal(t, int64(4), ReverseToShort.OrderVolume(1, 3))
+	assert.Equal(t, int64(4), ReverseToLong.OrderVolume(1, -3))
+	assert.Equal(t, int64(1), OpenLong.OrderVolume(1, -3))
+}
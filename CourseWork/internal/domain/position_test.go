@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPosition_Fill(t *testing.T) {
+	position := Position{PairName: "pair"}
+	assert.Error(t, position.Fill(string(Buy), 10, 0))
+	assert.Error(t, position.Fill(string(Buy), 0, 1))
+	assert.Error(t, position.Fill(string(WaitToBuy), 10, 1))
+
+	assert.NoError(t, position.Fill(string(Buy), 10, 1))
+	assert.NoError(t, position.Fill(string(Buy), 20, 1))
+	assert.Equal(t, position.Size, int64(2))
+	assert.InDelta(t, position.AvgPrice, 15, 1e-9)
+	assert.InDelta(t, position.UnrealizedPnL, 10, 1e-9)
+
+	position.Mark(25)
+	assert.InDelta(t, position.UnrealizedPnL, 20, 1e-9)
+	assert.InDelta(t, position.TotalPnL(), 20, 1e-9)
+
+	// partial close
+	assert.NoError(t, position.Fill(string(Sell), 25, 1))
+	assert.Equal(t, position.Size, int64(1))
+	assert.InDelta(t, position.RealizedPnL, 10, 1e-9)
+	assert.InDelta(t, position.AvgPrice, 15, 1e-9)
+
+	// reverse into short
+	assert.NoError(t, position.Fill(string(Sell), 5, 3))
+	assert.Equal(t, position.Size, int64(-2))
+	assert.InDelta(t, position.RealizedPnL, 0, 1e-9)
+	assert.InDelta(t, position.AvgPrice, 5, 1e-9)
+	position.Mark(4)
+	assert.InDelta(t, position.UnrealizedPnL, 2, 1e-9)
+
+	// close short
+	assert.NoError(t, position.Fill(string(Buy), 3, 2))
+	assert.Equal(t, position.Size, int64(0))
+	assert.InDelta(t, position.RealizedPnL, 4, 1e-9)
+	assert.Zero(t, position.AvgPrice)
+	assert.Zero(t, position.UnrealizedPnL)
+}
+
+func TestNewPortfolio(t *testing.T) {
+	portfolio := NewPortfolio("username", []Position{
+		{RealizedPnL: 1, UnrealizedPnL: 2},
+		{RealizedPnL: -3, UnrealizedPnL: 0.5},
+	})
+	assert.InDelta(t, portfolio.RealizedPnL, -2, 1e-9)
+	assert.InDelta(t, portfolio.UnrealizedPnL, 2.5, 1e-9)
+	assert.InDelta(t, portfolio.TotalPnL, 0.5, 1e-9)
+}
+
+func TestOrderInfo_String(t *testing.T) {
+	info := OrderInfo{Name: "pair", Side: string(Buy), Price: 10, Amount: 1}
+	assert.NotContains(t, info.String(), "PnL")
+	info.Position = &Position{Size: 1, AvgPrice: 10, RealizedPnL: 1.5}
+	assert.Contains(t, info.String(), "Realized PnL: <1.50>")
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"fmt"
+)
+
+const (
+	StopLossReason   = "stop-loss"
+	TakeProfitReason = "take-profit"
+)
+
+// RiskLimits describes user's risk settings, zero value disables the rule.
+// StopLoss and TakeProfit are fractions of position's average price,
+// MaxDailyLoss is an absolute loss since the start of the day.
+// Allocation limits notional of every pair's position by its part of user's budget.
+type RiskLimits struct {
+	StopLoss       float64    `json:"stop_loss,omitempty"`
+	TakeProfit     float64    `json:"take_profit,omitempty"`
+	MaxPosition    int64      `json:"max_position,omitempty"`
+	MaxDailyOrders int64      `json:"max_daily_orders,omitempty"`
+	MaxDailyLoss   float64    `json:"max_daily_loss,omitempty"`
+	Allocation     Allocation `json:"allocation,omitempty"`
+}
+
+// Validate checks RiskLimits bounds.
+func (limits RiskLimits) Validate() error {
+	if limits.StopLoss < 0 || limits.StopLoss >= 1 {
+		return fmt.Errorf("stop loss is out of bounds")
+	}
+	if limits.TakeProfit < 0 {
+		return fmt.Errorf("take profit is negative")
+	}
+	if limits.MaxPosition < 0 || limits.MaxDailyOrders < 0 || limits.MaxDailyLoss < 0 {
+		return fmt.Errorf("risk limits should be non-negative")
+	}
+	return limits.Allocation.Validate()
+}
+
+// Exit returns closing signal and reason if position reached stop-loss or take-profit.
+func (limits RiskLimits) Exit(position Position) (Signal, string, bool) {
+	if position.Size == 0 || position.AvgPrice <= 0 || position.LastPrice <= 0 {
+		return "", "", false
+	}
+	change := (position.LastPrice - position.AvgPrice) / position.AvgPrice * position.direction()
+	signal := CloseLong
+	if position.Size < 0 {
+		signal = CloseShort
+	}
+	if limits.StopLoss > 0 && change <= -limits.StopLoss {
+		return signal, StopLossReason, true
+	}
+	if limits.TakeProfit > 0 && change >= limits.TakeProfit {
+		return signal, TakeProfitReason, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskLimits_Validate(t *testing.T) {
+	assert.NoError(t, RiskLimits{}.Validate())
+	assert.NoError(t, RiskLimits{StopLoss: 0.1, TakeProfit: 2, MaxPosition: 1,
+		MaxDailyOrders: 1, MaxDailyLoss: 1}.Validate())
+	assert.Error(t, RiskLimits{StopLoss: 1}.Validate())
+	assert.Error(t, RiskLimits{StopLoss: -0.1}.Validate())
+	assert.Error(t, RiskLimits{TakeProfit: -0.1}.Validate())
+	assert.Error(t, RiskLimits{MaxPosition: -1}.Validate())
+	assert.Error(t, RiskLimits{MaxDailyOrders: -1}.Validate())
+	assert.Error(t, RiskLimits{MaxDailyLoss: -1}.Validate())
+	assert.Error(t, RiskLimits{Allocation: Allocation{Budget: -1}}.Validate())
+}
+
+func TestRiskLimits_Exit(t *testing.T) {
+	limits := RiskLimits{StopLoss: 0.1, TakeProfit: 0.2}
+	tests := []struct {
+		name     string
+		position Position
+		signal   Signal
+		reason   string
+		ok       bool
+	}{
+		{name: "flat", position: Position{AvgPrice: 10, LastPrice: 1}},
+		{name: "long hold", position: Position{Size: 1, AvgPrice: 10, LastPrice: 9.5}},
+		{name: "long stop", position: Position{Size: 1, AvgPrice: 10, LastPrice: 9},
+			signal: CloseLong, reason: StopLossReason, ok: true},
+		{name: "long take", position: Position{Size: 1, AvgPrice: 10, LastPrice: 12},
+			signal: CloseLong, reason: TakeProfitReason, ok: true},
+		{name: "short stop", position: Position{Size: -1, AvgPrice: 10, LastPrice: 11},
+			signal: CloseShort, reason: StopLossReason, ok: true},
+		{name: "short take", position: Position{Size: -1, AvgPrice: 10, LastPrice: 8},
+			signal: CloseShort, reason: TakeProfitReason, ok: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signal, reason, ok := limits.Exit(test.position)
+			assert.Equal(t, signal, test.signal)
+			assert.Equal(t, reason, test.reason)
+			assert.Equal(t, ok, test.ok)
+		})
+	}
+	_, _, ok := RiskLimits{}.Exit(Position{Size: 1, AvgPrice: 10, LastPrice: 1})
+	assert.False(t, ok)
+}
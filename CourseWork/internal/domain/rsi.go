@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"fmt"
+)
+
+// RSI is relative strength index implementation of Indicator.
+// Long position is opened when index is oversold and short position when index is overbought.
+type RSI struct {
+	Period     int32
+	Overbought float64
+	Oversold   float64
+	Value      float64
+	Machine    PositionMachine
+	averageUp  float64
+	averageDn  float64
+	prevClose  float64
+	counter    int32
+	guard      candleGuard
+}
+
+// NewRSI returns pointer to RSI with params or default values.
+func NewRSI(params IndicatorParams) (*RSI, error) {
+	period, err := periodOrDefault(params.Period, DefaultRSIPeriod)
+	if err != nil {
+		return nil, err
+	}
+	overbought, err := valueOrDefault(params.Overbought, DefaultOverbought)
+	if err != nil {
+		return nil, err
+	}
+	oversold, err := valueOrDefault(params.Oversold, DefaultOversold)
+	if err != nil {
+		return nil, err
+	}
+	machine, err := NewPositionMachine(params.Direction)
+	if err != nil {
+		return nil, err
+	}
+	if oversold >= overbought {
+		return nil, fmt.Errorf("oversold should be less than overbought")
+	}
+	return &RSI{
+		Period:     period,
+		Overbought: overbought,
+		Oversold:   oversold,
+		Machine:    machine,
+	}, nil
+}
+
+// Add is adding implementation of Indicator.
+// It counts index by Wilder's smoothing.
+func (indicator *RSI) Add(candle Candle) (Signal, error) {
+	if err := indicator.guard.check(candle, "rsi"); err != nil {
+		return "", err
+	}
+	defer func() {
+		indicator.prevClose = candle.Close
+	}()
+	if indicator.counter == 0 {
+		indicator.counter++
+		return WaitToSet, nil
+	}
+	var up, down float64
+	if delta := candle.Close - indicator.prevClose; delta > 0 {
+		up = delta
+	} else {
+		down = -delta
+	}
+	period := float64(indicator.Period)
+	if indicator.counter <= indicator.Period {
+		indicator.averageUp += up / period
+		indicator.averageDn += down / period
+		indicator.counter++
+		if indicator.counter <= indicator.Period {
+			return WaitToSet, nil
+		}
+	} else {
+		indicator.averageUp = (indicator.averageUp*(period-1) + up) / period
+		indicator.averageDn = (indicator.averageDn*(period-1) + down) / period
+	}
+	indicator.Value = rsiValue(indicator.averageUp, indicator.averageDn)
+	return indicator.Machine.Signal(indicator.Value <= indicator.Oversold,
+		indicator.Value >= indicator.Overbought), nil
+}
+
+// rsiValue counts index by average gain and loss.
+func rsiValue(up, down float64) float64 {
+	if down == 0 {
+		if up == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+up/down)
+}
+
+// Reset forgets entered position, so the next entering signal opens it.
+func (indicator *RSI) Reset() {
+	indicator.Machine.Reset()
+}
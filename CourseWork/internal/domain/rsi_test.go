@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRSI(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		isError bool
+	}{
+		{name: "default", params: IndicatorParams{}},
+		{name: "custom", params: IndicatorParams{Period: 2, Oversold: 20, Overbought: 80}},
+		{name: "negative period", params: IndicatorParams{Period: -1}, isError: true},
+		{name: "huge threshold", params: IndicatorParams{Overbought: 101}, isError: true},
+		{name: "crossed thresholds", params: IndicatorParams{Oversold: 80, Overbought: 20}, isError: true},
+		{name: "unknown direction", params: IndicatorParams{Direction: "up"}, isError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rsi, err := NewRSI(test.params)
+			if test.isError {
+				assert.Error(t, err)
+				assert.Nil(t, rsi)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, rsi)
+		})
+	}
+}
+
+func TestRSI_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		closes  []float64
+		signals []Signal
+	}{
+		{
+			name:    "oversold and overbought",
+			params:  IndicatorParams{Period: 2},
+			closes:  []float64{10, 9, 8, 9, 11, 11},
+			signals: []Signal{WaitToSet, WaitToSet, OpenLong, WaitToSell, CloseLong, WaitToBuy},
+		},
+		{
+			name:    "both directions",
+			params:  IndicatorParams{Period: 2, Direction: BothDirection},
+			closes:  []float64{10, 9, 8, 9, 11, 11},
+			signals: []Signal{WaitToSet, WaitToSet, OpenLong, WaitToSell, ReverseToShort, WaitToBuy},
+		},
+		{
+			name:    "short only",
+			params:  IndicatorParams{Period: 2, Direction: ShortDirection},
+			closes:  []float64{10, 9, 8, 9, 11, 11},
+			signals: []Signal{WaitToSet, WaitToSet, WaitToSell, WaitToSell, OpenShort, WaitToBuy},
+		},
+		{
+			name:    "flat market",
+			params:  IndicatorParams{Period: 2},
+			closes:  []float64{10, 10, 10, 10},
+			signals: []Signal{WaitToSet, WaitToSet, WaitToBuy, WaitToBuy},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rsi, err := NewRSI(test.params)
+			assert.NoError(t, err)
+			assertSignals(t, rsi, test.closes, test.signals)
+		})
+	}
+
+	rsi, err := NewRSI(IndicatorParams{})
+	assert.NoError(t, err)
+	_, err = rsi.Add(Candle{Close: -1, Time: 1})
+	assert.Error(t, err)
+	_, err = rsi.Add(Candle{Time: 1})
+	assert.NoError(t, err)
+	_, err = rsi.Add(Candle{Time: 1})
+	assert.ErrorIs(t, err, ErrSameTimestamp)
+}
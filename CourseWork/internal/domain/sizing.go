@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	FixedSizing    SizingMode = "fixed"
+	NotionalSizing SizingMode = "notional"
+	EquitySizing   SizingMode = "equity"
+	ATRSizing      SizingMode = "atr"
+)
+
+const (
+	DefaultContracts = 1
+	DefaultATRPeriod = 14
+)
+
+var (
+	ErrZeroSize    = errors.New("order size is zero")
+	ErrATRIsNotSet = errors.New("atr isn't counted yet")
+)
+
+// SizingMode describes how order size is counted.
+type SizingMode string
+
+// Sizing consists of order size parameters from Config, fixed size is default.
+// Contracts is used by fixed mode and Notional by notional mode.
+// Equity mode spends Fraction of equity on the order,
+// ATR mode risks Fraction of equity on one ATR move.
+// Equity is Capital with user's total PnL.
+type Sizing struct {
+	Mode      SizingMode `json:"mode,omitempty"`
+	Contracts int64      `json:"contracts,omitempty"`
+	Notional  float64    `json:"notional,omitempty"`
+	Capital   float64    `json:"capital,omitempty"`
+	Fraction  float64    `json:"fraction,omitempty"`
+	ATRPeriod int32      `json:"atr_period,omitempty"`
+}
+
+// Validate checks Sizing parameters required by its mode.
+func (sizing Sizing) Validate() error {
+	if sizing.Contracts < 0 || sizing.Notional < 0 || sizing.Capital < 0 {
+		return fmt.Errorf("sizing values should be non-negative")
+	}
+	if sizing.Fraction < 0 || sizing.Fraction > 1 {
+		return fmt.Errorf("sizing fraction is out of bounds")
+	}
+	if _, err := periodOrDefault(sizing.ATRPeriod, DefaultATRPeriod); err != nil {
+		return err
+	}
+	switch sizing.Mode {
+	case "", FixedSizing:
+	case NotionalSizing:
+		if sizing.Notional == 0 {
+			return fmt.Errorf("notional is empty")
+		}
+	case EquitySizing, ATRSizing:
+		if sizing.Capital == 0 || sizing.Fraction == 0 {
+			return fmt.Errorf("capital or fraction is empty")
+		}
+	default:
+		return fmt.Errorf("unsupported sizing mode")
+	}
+	return nil
+}
+
+// Sizer counts order sizes by Sizing and keeps ATR of the last candles.
+type Sizer struct {
+	Sizing    Sizing
+	ranges    *window
+	prevClose float64
+}
+
+// NewSizer returns pointer to Sizer with validated Sizing.
+func NewSizer(sizing Sizing) (*Sizer, error) {
+	if err := sizing.Validate(); err != nil {
+		return nil, err
+	}
+	period, _ := periodOrDefault(sizing.ATRPeriod, DefaultATRPeriod)
+	return &Sizer{
+		Sizing: sizing,
+		ranges: newWindow(period),
+	}, nil
+}
+
+// Add updates ATR by candle's true range.
+func (sizer *Sizer) Add(candle Candle) {
+	trueRange := candle.High - candle.Low
+	if sizer.prevClose > 0 {
+		trueRange = math.Max(trueRange, math.Abs(candle.High-sizer.prevClose))
+		trueRange = math.Max(trueRange, math.Abs(candle.Low-sizer.prevClose))
+	}
+	sizer.ranges.push(trueRange)
+	sizer.prevClose = candle.Close
+}
+
+// ATR returns average true range, it isn't set until the window is full.
+func (sizer Sizer) ATR() (float64, bool) {
+	if !sizer.ranges.isFull() {
+		return 0, false
+	}
+	return sizer.ranges.mean(), true
+}
+
+// Volatility returns ATR as a fraction of the last close, it isn't set until ATR is set.
+func (sizer Sizer) Volatility() (float64, bool) {
+	atr, ok := sizer.ATR()
+	if !ok || sizer.prevClose <= 0 {
+		return 0, false
+	}
+	return atr / sizer.prevClose, true
+}
+
+// Size counts order size by price and user's total PnL.
+func (sizer Sizer) Size(price, pnl float64) (int64, error) {
+	var size float64
+	equity := sizer.Sizing.Capital + pnl
+	switch sizer.Sizing.Mode {
+	case NotionalSizing:
+		if price <= 0 {
+			return 0, ErrZeroSize
+		}
+		size = sizer.Sizing.Notional / price
+	case EquitySizing:
+		if price <= 0 {
+			return 0, ErrZeroSize
+		}
+		size = equity * sizer.Sizing.Fraction / price
+	case ATRSizing:
+		atr, ok := sizer.ATR()
+		if !ok {
+			return 0, ErrATRIsNotSet
+		}
+		if atr == 0 {
+			return 0, ErrZeroSize
+		}
+		size = equity * sizer.Sizing.Fraction / atr
+	default:
+		if sizer.Sizing.Contracts == 0 {
+			return DefaultContracts, nil
+		}
+		return sizer.Sizing.Contracts, nil
+	}
+	if size < 1 {
+		return 0, ErrZeroSize
+	}
+	return int64(math.Floor(size)), nil
+}
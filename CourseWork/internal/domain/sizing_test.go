@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizing_Validate(t *testing.T) {
+	assert.NoError(t, Sizing{}.Validate())
+	assert.NoError(t, Sizing{Mode: FixedSizing, Contracts: 5}.Validate())
+	assert.NoError(t, Sizing{Mode: NotionalSizing, Notional: 100}.Validate())
+	assert.NoError(t, Sizing{Mode: EquitySizing, Capital: 100, Fraction: 0.5}.Validate())
+	assert.NoError(t, Sizing{Mode: ATRSizing, Capital: 100, Fraction: 0.01,
+		ATRPeriod: 3}.Validate())
+	assert.Error(t, Sizing{Mode: "unknown"}.Validate())
+	assert.Error(t, Sizing{Contracts: -1}.Validate())
+	assert.Error(t, Sizing{Mode: NotionalSizing}.Validate())
+	assert.Error(t, Sizing{Mode: EquitySizing, Capital: 100}.Validate())
+	assert.Error(t, Sizing{Mode: EquitySizing, Capital: 100, Fraction: 2}.Validate())
+	assert.Error(t, Sizing{Mode: ATRSizing, Capital: 100, Fraction: 0.1,
+		ATRPeriod: -1}.Validate())
+}
+
+func TestSizer_Size(t *testing.T) {
+	tests := []struct {
+		name   string
+		sizing Sizing
+		price  float64
+		pnl    float64
+		size   int64
+		err    error
+	}{
+		{name: "default", price: 10, size: DefaultContracts},
+		{name: "fixed", sizing: Sizing{Mode: FixedSizing, Contracts: 3}, price: 10, size: 3},
+		{name: "notional", sizing: Sizing{Mode: NotionalSizing, Notional: 105},
+			price: 10, size: 10},
+		{name: "notional too small", sizing: Sizing{Mode: NotionalSizing, Notional: 5},
+			price: 10, err: ErrZeroSize},
+		{name: "equity", sizing: Sizing{Mode: EquitySizing, Capital: 1000, Fraction: 0.5},
+			price: 10, pnl: -200, size: 40},
+		{name: "equity without price", sizing: Sizing{Mode: EquitySizing, Capital: 1000,
+			Fraction: 0.5}, err: ErrZeroSize},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sizer, err := NewSizer(test.sizing)
+			assert.NoError(t, err)
+			size, err := sizer.Size(test.price, test.pnl)
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, size, test.size)
+		})
+	}
+}
+
+func TestSizer_ATR(t *testing.T) {
+	sizer, err := NewSizer(Sizing{Mode: ATRSizing, Capital: 1000, Fraction: 0.01,
+		ATRPeriod: 2})
+	assert.NoError(t, err)
+	_, err = NewSizer(Sizing{Mode: ATRSizing})
+	assert.Error(t, err)
+
+	sizer.Add(Candle{High: 11, Low: 9, Close: 10})
+	_, ok := sizer.ATR()
+	assert.False(t, ok)
+	_, err = sizer.Size(10, 0)
+	assert.ErrorIs(t, err, ErrATRIsNotSet)
+	// true range is counted by previous close: 10 - 7 = 3
+	sizer.Add(Candle{High: 9, Low: 7, Close: 8})
+	atr, ok := sizer.ATR()
+	assert.True(t, ok)
+	assert.InDelta(t, atr, 2.5, 1e-9)
+	volatility, ok := sizer.Volatility()
+	assert.True(t, ok)
+	assert.InDelta(t, volatility, 2.5/8, 1e-9)
+	size, err := sizer.Size(8, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, size, int64(4))
+}
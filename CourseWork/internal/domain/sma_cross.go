@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"fmt"
+)
+
+// SMACross is moving average crossover implementation of Indicator.
+// Long position is opened when fast average crosses slow one upwards
+// and short position when downwards.
+type SMACross struct {
+	FastPeriod   int32
+	SlowPeriod   int32
+	Fast         float64
+	Slow         float64
+	Machine      PositionMachine
+	fast         *window
+	slow         *window
+	isAbove      bool
+	isCrossReady bool
+	guard        candleGuard
+}
+
+// NewSMACross returns pointer to SMACross with params or default values.
+func NewSMACross(params IndicatorParams) (*SMACross, error) {
+	fast, err := periodOrDefault(params.FastPeriod, DefaultFastSMAPeriod)
+	if err != nil {
+		return nil, err
+	}
+	slow, err := periodOrDefault(params.SlowPeriod, DefaultSlowSMAPeriod)
+	if err != nil {
+		return nil, err
+	}
+	machine, err := NewPositionMachine(params.Direction)
+	if err != nil {
+		return nil, err
+	}
+	if fast >= slow {
+		return nil, fmt.Errorf("fast period should be less than slow period")
+	}
+	return &SMACross{
+		FastPeriod: fast,
+		SlowPeriod: slow,
+		fast:       newWindow(fast),
+		slow:       newWindow(slow),
+		Machine:    machine,
+	}, nil
+}
+
+// Add is adding implementation of Indicator.
+func (indicator *SMACross) Add(candle Candle) (Signal, error) {
+	if err := indicator.guard.check(candle, "sma cross"); err != nil {
+		return "", err
+	}
+	indicator.fast.push(candle.Close)
+	indicator.slow.push(candle.Close)
+	if !indicator.slow.isFull() {
+		return WaitToSet, nil
+	}
+	indicator.Fast = indicator.fast.mean()
+	indicator.Slow = indicator.slow.mean()
+	isAbove := indicator.Fast > indicator.Slow
+	isCrossedUp := indicator.isCrossReady && isAbove && !indicator.isAbove
+	isCrossedDown := indicator.isCrossReady && !isAbove && indicator.isAbove
+	indicator.isAbove = isAbove
+	indicator.isCrossReady = true
+	return indicator.Machine.Signal(isCrossedUp, isCrossedDown), nil
+}
+
+// Reset forgets entered position, so the next entering signal opens it.
+func (indicator *SMACross) Reset() {
+	indicator.Machine.Reset()
+}
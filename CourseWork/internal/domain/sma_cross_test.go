@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSMACross(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		isError bool
+	}{
+		{name: "default", params: IndicatorParams{}},
+		{name: "custom", params: IndicatorParams{FastPeriod: 1, SlowPeriod: 3}},
+		{name: "crossed periods", params: IndicatorParams{FastPeriod: 3, SlowPeriod: 3}, isError: true},
+		{name: "negative period", params: IndicatorParams{SlowPeriod: -3}, isError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cross, err := NewSMACross(test.params)
+			if test.isError {
+				assert.Error(t, err)
+				assert.Nil(t, cross)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, cross)
+		})
+	}
+}
+
+func TestSMACross_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  IndicatorParams
+		closes  []float64
+		signals []Signal
+	}{
+		{
+			name:    "cross up and down",
+			params:  IndicatorParams{FastPeriod: 1, SlowPeriod: 3},
+			closes:  []float64{3, 2, 1, 4, 5, 1},
+			signals: []Signal{WaitToSet, WaitToSet, WaitToBuy, OpenLong, WaitToSell, CloseLong},
+		},
+		{
+			name:    "fast is above from start",
+			params:  IndicatorParams{FastPeriod: 1, SlowPeriod: 2},
+			closes:  []float64{1, 2, 3},
+			signals: []Signal{WaitToSet, WaitToBuy, WaitToBuy},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cross, err := NewSMACross(test.params)
+			assert.NoError(t, err)
+			assertSignals(t, cross, test.closes, test.signals)
+		})
+	}
+
+	cross, err := NewSMACross(IndicatorParams{})
+	assert.NoError(t, err)
+	_, err = cross.Add(Candle{Close: -1, Time: 1})
+	assert.Error(t, err)
+}
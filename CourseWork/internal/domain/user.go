@@ -1,16 +1,50 @@
 package domain
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrEmptyPassword     = errors.New("password is empty")
+	ErrIncorrectPassword = errors.New("incorrect username or password")
+)
+
+const (
+	LiveMode  TradingMode = "live"
+	PaperMode TradingMode = "paper"
 )
 
+const (
+	UserRole  Role = "user"
+	AdminRole Role = "admin"
+)
+
+// TradingMode describes whether orders are sent to stock market or simulated.
+type TradingMode string
+
+// Role describes user's permissions, admins manage running pairs.
+type Role string
+
 // User describes user's structure with identifying parameters.
+// PrivateKey is sealed before storing and Password is replaced by PasswordHash.
+// Role isn't read from requests, it's set in db only.
+// Risk of shared user is read by GetRisk and edited by SetRisk.
 type User struct {
-	Username   string `json:"username"`
-	PublicKey  string `json:"public_key"`
-	PrivateKey string `json:"private_key"`
-	TelegramID int64  `json:"telegram_id"`
-	limits     map[string]float64
+	Username     string     `json:"username"`
+	Password     string     `json:"password,omitempty"`
+	PasswordHash string     `json:"-"`
+	PublicKey    string     `json:"public_key"`
+	PrivateKey   string     `json:"private_key"`
+	TelegramID   int64      `json:"telegram_id"`
+	Risk         RiskLimits `json:"risk"`
+	Role         Role       `json:"-"`
+	limits       map[string]float64
+	muRisk       *sync.RWMutex
 }
 
 // NewUser returns pointer to User structure.
@@ -20,7 +54,50 @@ func NewUser(username string) *User {
 		PublicKey:  "",
 		PrivateKey: "",
 		limits:     make(map[string]float64),
+		muRisk:     &sync.RWMutex{},
+	}
+}
+
+// GetRisk returns copy of user's RiskLimits.
+func (user *User) GetRisk() RiskLimits {
+	user.muRisk.RLock()
+	defer user.muRisk.RUnlock()
+	return user.Risk
+}
+
+// SetRisk replaces user's RiskLimits.
+func (user *User) SetRisk(limits RiskLimits) {
+	user.muRisk.Lock()
+	defer user.muRisk.Unlock()
+	user.Risk = limits
+}
+
+// IsAdmin checks if user has admin role.
+func (user User) IsAdmin() bool {
+	return user.Role == AdminRole
+}
+
+// HashPassword replaces user's password by its bcrypt hash.
+func (user *User) HashPassword() error {
+	if len(user.Password) == 0 {
+		return ErrEmptyPassword
 	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("can't hash password <%w>", err)
+	}
+	user.PasswordHash = string(hash)
+	user.Password = ""
+	return nil
+}
+
+// CheckPassword compares password with user's hash.
+func (user User) CheckPassword(password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash),
+		[]byte(password)); err != nil {
+		return ErrIncorrectPassword
+	}
+	return nil
 }
 
 func (user User) AddLimit(pairName string, limit float64) error {
@@ -40,38 +117,105 @@ func (user User) GetLimit(pairName string) float64 {
 }
 
 // OrderInfo consists of order's information to notify users about their deals.
+// Username, Interval and Indicator describe the owner and the source of the order.
+// Amount is filled part of requested Size, which is rested or cancelled.
+// Position is a state of user's position after the order.
+// Mismatch is set by reconciliation if stock market's fills differ from Amount.
 type OrderInfo struct {
-	Name    string
-	OrderID string
-	Price   float64
-	Amount  int64
-	Side    string
+	ID        int64          `json:"id"`
+	Name      string         `json:"name"`
+	OrderID   string         `json:"order_id"`
+	Price     float64        `json:"price"`
+	Amount    int64          `json:"amount"`
+	Side      string         `json:"side"`
+	Type      OrderType      `json:"type"`
+	Size      int64          `json:"size"`
+	Status    string         `json:"status"`
+	Mismatch  string         `json:"mismatch,omitempty"`
+	Username  string         `json:"username"`
+	Interval  CandleInterval `json:"interval"`
+	Indicator string         `json:"indicator"`
+	CreatedAt time.Time      `json:"created_at"`
+	Position  *Position      `json:"-"`
 }
 
 func (orderInfo OrderInfo) String() string {
-	return fmt.Sprintf("Name: <%s>,\n"+
+	message := fmt.Sprintf("Name: <%s>,\n"+
 		"OrderID: <%s>,\n"+
+		"Type: <%s>,\n"+
 		"Price: <%.2f>,\n"+
-		"Amount: <%d>,\n"+
-		"Side: <%s>", orderInfo.Name, orderInfo.OrderID, orderInfo.Price, orderInfo.Amount, orderInfo.Side)
+		"Amount: <%d/%d>,\n"+
+		"Side: <%s>", orderInfo.Name, orderInfo.OrderID, orderInfo.Type, orderInfo.Price,
+		orderInfo.Amount, orderInfo.Size, orderInfo.Side)
+	if orderInfo.Position != nil {
+		message += ",\n" + orderInfo.Position.String()
+	}
+	return message
 }
 
 // Config consists of necessary information for trading staring.
+// WarmUp is the number of history candles, which prime Indicator on start.
+// Filters combine signals of Indicator with indicators of other intervals.
 type Config struct {
-	PairName      string         `json:"pair_name"`
-	PairInterval  CandleInterval `json:"pair_interval"`
-	IndicatorName string         `json:"indicator_name"`
-	Limit         float64        `json:"limit"`
+	PairName      string          `json:"pair_name"`
+	PairInterval  CandleInterval  `json:"pair_interval"`
+	IndicatorName string          `json:"indicator_name"`
+	Params        IndicatorParams `json:"params"`
+	Limit         float64         `json:"limit"`
+	Mode          TradingMode     `json:"mode"`
+	Sizing        Sizing          `json:"sizing"`
+	Order         OrderOptions    `json:"order"`
+	WarmUp        int32           `json:"warm_up,omitempty"`
+	Filters       []FilterConfig  `json:"filters,omitempty"`
+}
+
+// TradingMode returns Config's trading mode, live mode is default.
+func (config Config) TradingMode() TradingMode {
+	if len(config.Mode) == 0 {
+		return LiveMode
+	}
+	return config.Mode
+}
+
+// Subscription describes user's Config of running pair.
+type Subscription struct {
+	Username string
+	Config   Config
 }
 
 // Validate checks Config for correct namings.
 func (config Config) Validate() error {
-	if config.PairInterval != Candle1m && config.PairInterval != Candle2m &&
-		config.PairInterval != Candle5m && config.PairInterval != Candle10m {
-		return fmt.Errorf("unsupported candle type")
+	if err := config.PairInterval.Validate(); err != nil {
+		return err
 	}
 	if len(config.PairName) == 0 {
 		return fmt.Errorf("pair name is empty")
 	}
-	return nil
+	if len(config.Mode) != 0 && config.Mode != LiveMode && config.Mode != PaperMode {
+		return fmt.Errorf("unsupported trading mode")
+	}
+	if err := config.Order.Validate(); err != nil {
+		return err
+	}
+	if _, err := periodOrDefault(config.WarmUp, DefaultWarmUp); err != nil {
+		return fmt.Errorf("warm up %w", err)
+	}
+	if len(config.Filters) > maxFilters {
+		return fmt.Errorf("too many filters")
+	}
+	for _, filter := range config.Filters {
+		if err := filter.Validate(config.PairInterval); err != nil {
+			return err
+		}
+	}
+	return config.Sizing.Validate()
+}
+
+// WarmUpSize returns the number of history candles, DefaultWarmUp is default.
+func (config Config) WarmUpSize() int32 {
+	size, err := periodOrDefault(config.WarmUp, DefaultWarmUp)
+	if err != nil {
+		return DefaultWarmUp
+	}
+	return size
 }
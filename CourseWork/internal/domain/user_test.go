@@ -17,6 +17,11 @@ func TestNewUser(t *testing.T) {
 	assert.NotNil(t, newUser.limits)
 }
 
+func TestConfig_TradingMode(t *testing.T) {
+	assert.Equal(t, Config{}.TradingMode(), LiveMode)
+	assert.Equal(t, Config{Mode: PaperMode}.TradingMode(), PaperMode)
+}
+
 func TestUser_AddLimit(t *testing.T) {
 	setupUser()
 	eps := float64(7.)/3 - float64(4.)/3 - 1.
@@ -44,3 +49,60 @@ func TestUser_GetLimit(t *testing.T) {
 	assert.Equal(t, limit, 0.)
 	assert.NoError(t, err)
 }
+
+func TestUser_IsAdmin(t *testing.T) {
+	setupUser()
+	assert.False(t, user.IsAdmin())
+	user.Role = AdminRole
+	assert.True(t, user.IsAdmin())
+}
+
+func TestUser_SetRisk(t *testing.T) {
+	setupUser()
+	limits := RiskLimits{MaxPosition: 3}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		user.SetRisk(limits)
+	}()
+	_ = user.GetRisk()
+	<-done
+	assert.Equal(t, user.GetRisk(), limits)
+}
+
+func TestUser_HashPassword(t *testing.T) {
+	setupUser()
+	assert.ErrorIs(t, user.HashPassword(), ErrEmptyPassword)
+	user.Password = "password"
+	assert.NoError(t, user.HashPassword())
+	assert.Empty(t, user.Password)
+	assert.NotEqual(t, user.PasswordHash, "password")
+	assert.NoError(t, user.CheckPassword("password"))
+	assert.ErrorIs(t, user.CheckPassword("wrong"), ErrIncorrectPassword)
+	assert.ErrorIs(t, user.CheckPassword(""), ErrIncorrectPassword)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	config := Config{PairName: "name", PairInterval: Candle1m}
+	assert.NoError(t, config.Validate())
+	config.Mode = PaperMode
+	assert.NoError(t, config.Validate())
+	config.Mode = "unknown"
+	assert.Error(t, config.Validate())
+	config.Mode = LiveMode
+	config.PairInterval = ""
+	assert.Error(t, config.Validate())
+	config.PairInterval = Candle1m
+	config.PairName = ""
+	assert.Error(t, config.Validate())
+	config.PairName = "name"
+	config.Sizing.Mode = NotionalSizing
+	assert.Error(t, config.Validate())
+	config.Sizing.Mode = FixedSizing
+	config.WarmUp = -1
+	assert.Error(t, config.Validate())
+	assert.Equal(t, int32(DefaultWarmUp), config.WarmUpSize())
+	config.WarmUp = 10
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, int32(10), config.WarmUpSize())
+}
@@ -0,0 +1,656 @@
+// Package fakekraken implements local stand-in of Kraken Futures websocket
+// and REST API for offline tests.
+package fakekraken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	SocketPath        = "/ws/v1"
+	OrderPath         = "/derivatives/api/v3/sendorder"
+	CancelPath        = "/derivatives/api/v3/cancelorder"
+	OpenOrdersPath    = "/derivatives/api/v3/openorders"
+	FillsPath         = "/derivatives/api/v3/fills"
+	OpenPositionsPath = "/derivatives/api/v3/openpositions"
+	AccountsPath      = "/derivatives/api/v3/accounts"
+	ChartsPath        = "/api/charts/v1/trade/"
+	candlesPrefix     = "candles_trade_"
+	derivativePath    = "/derivatives"
+	heartbeatFeed     = "heartbeat"
+	DefaultHeartbeat  = 100 * time.Millisecond
+	placedStatus      = "placed"
+	cancelledStatus   = "cancelled"
+	notFoundStatus    = "notFound"
+	untouchedStatus   = "untouched"
+	placeType         = "PLACE"
+	cancelType        = "CANCEL"
+	iocOrder          = "ioc"
+	limitOrder        = "lmt"
+	takerFill         = "taker"
+	successResult     = "success"
+	errorResult       = "error"
+	authError         = "authenticationError"
+	executionType     = "EXECUTION"
+	subscribeEvent    = "subscribe"
+	subscribedEvent   = "subscribed"
+	unsubscribeEvent  = "unsubscribe"
+	unsubscribedEvent = "unsubscribed"
+	infoEvent         = "info"
+	protocolVersion   = 1
+	fakeOrderIDPrefix = "fake"
+)
+
+// subscriptionMessage describes subscription request and response.
+type subscriptionMessage struct {
+	Event      string   `json:"event"`
+	Feed       string   `json:"feed"`
+	ProductIDs []string `json:"product_ids,omitempty"`
+	Version    int64    `json:"version,omitempty"`
+}
+
+// candleMessage describes candle feed message.
+type candleMessage struct {
+	Feed      string            `json:"feed"`
+	Candle    domain.CandleJSON `json:"candle"`
+	ProductID string            `json:"product_id"`
+	Time      int64             `json:"time"`
+}
+
+// candlesResponse describes charts response.
+type candlesResponse struct {
+	Candles     []domain.CandleJSON `json:"candles"`
+	MoreCandles bool                `json:"more_candles"`
+}
+
+// heartbeatMessage describes heartbeat feed message.
+type heartbeatMessage struct {
+	Feed string `json:"feed"`
+	Time int64  `json:"time"`
+}
+
+// Server is in-process Kraken Futures stand-in.
+type Server struct {
+	// Heartbeat is the delay between heartbeat messages.
+	Heartbeat time.Duration
+	// CandleDelay is the delay between scripted candles.
+	CandleDelay time.Duration
+	// FillLimit is the maximum filled size of ioc and limit orders, zero fills orders entirely.
+	FillLimit float64
+	// MutePings stops pong replies of new connections, so they look dead without heartbeats.
+	MutePings  bool
+	server     *httptest.Server
+	upgrader   websocket.Upgrader
+	candles    map[string][]domain.Candle
+	keys       map[string]string
+	orders     []url.Values
+	openOrders map[string][]domain.OpenOrder
+	fills      map[string][]domain.Fill
+	accounts   map[string]map[string]domain.Account
+	conns      []*websocket.Conn
+	subscribed map[string]int
+	mu         *sync.Mutex
+	done       chan struct{}
+}
+
+// NewServer returns started Server.
+func NewServer() *Server {
+	server := &Server{
+		Heartbeat:   DefaultHeartbeat,
+		CandleDelay: time.Millisecond,
+		candles:     make(map[string][]domain.Candle),
+		keys:        make(map[string]string),
+		orders:      make([]url.Values, 0),
+		openOrders:  make(map[string][]domain.OpenOrder),
+		fills:       make(map[string][]domain.Fill),
+		accounts:    make(map[string]map[string]domain.Account),
+		subscribed:  make(map[string]int),
+		mu:          &sync.Mutex{},
+		done:        make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(SocketPath, server.socketHandler)
+	mux.HandleFunc(OrderPath, server.orderHandler)
+	mux.HandleFunc(CancelPath, server.cancelHandler)
+	mux.HandleFunc(OpenOrdersPath, server.openOrdersHandler)
+	mux.HandleFunc(FillsPath, server.fillsHandler)
+	mux.HandleFunc(OpenPositionsPath, server.openPositionsHandler)
+	mux.HandleFunc(AccountsPath, server.accountsHandler)
+	mux.HandleFunc(ChartsPath, server.chartsHandler)
+	server.server = httptest.NewServer(mux)
+	return server
+}
+
+// SocketURL returns websocket url in KrakenSocket format.
+func (server *Server) SocketURL() string {
+	return "ws" + strings.TrimPrefix(server.server.URL, "http") + SocketPath + "?chart"
+}
+
+// OrderURL returns sendorder url in KrakenAPI format.
+func (server *Server) OrderURL() string {
+	return server.server.URL + OrderPath
+}
+
+// ChartsURL returns charts url in KrakenCharts format.
+func (server *Server) ChartsURL() string {
+	return server.server.URL + strings.TrimSuffix(ChartsPath, "/")
+}
+
+// AddKey registers user's keys. Private key should be base64 encoded.
+func (server *Server) AddKey(publicKey, privateKey string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.keys[publicKey] = privateKey
+}
+
+// AddCandles adds scripted candles, which are sent after product's subscription.
+func (server *Server) AddCandles(productID string, interval domain.CandleInterval,
+	candles ...domain.Candle) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	key := feedKey(productID, interval)
+	server.candles[key] = append(server.candles[key], candles...)
+}
+
+// AddFill adds fill of user's order, e.g. of resting order or of order from another client.
+// Open positions are counted by fills.
+func (server *Server) AddFill(publicKey string, fill domain.Fill) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.fills[publicKey] = append(server.fills[publicKey], fill)
+}
+
+// SetAccounts replaces user's accounts.
+func (server *Server) SetAccounts(publicKey string, accounts map[string]domain.Account) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.accounts[publicKey] = accounts
+}
+
+// Orders returns copies of received and authenticated orders.
+func (server *Server) Orders() []url.Values {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	orders := make([]url.Values, len(server.orders))
+	copy(orders, server.orders)
+	return orders
+}
+
+// Connections returns quantity of accepted socket connections.
+func (server *Server) Connections() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return len(server.conns)
+}
+
+// Subscribed returns quantity of connections, which are subscribed to product's feed.
+func (server *Server) Subscribed(productID string, interval domain.CandleInterval) int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.subscribed[feedKey(productID, interval)]
+}
+
+// DropConnections closes accepted socket connections like network failure does.
+func (server *Server) DropConnections() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, conn := range server.conns {
+		_ = conn.Close()
+	}
+}
+
+// Close stops all connections and server.
+func (server *Server) Close() {
+	close(server.done)
+	server.mu.Lock()
+	for _, conn := range server.conns {
+		_ = conn.Close()
+	}
+	server.mu.Unlock()
+	server.server.CloseClientConnections()
+	server.server.Close()
+}
+
+// socketHandler processes info and subscription handshake and sends feeds.
+func (server *Server) socketHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := server.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	server.mu.Lock()
+	server.conns = append(server.conns, conn)
+	if server.MutePings {
+		conn.SetPingHandler(func(string) error { return nil })
+	}
+	server.mu.Unlock()
+	writes := &sync.Mutex{}
+	write := func(v interface{}) error {
+		writes.Lock()
+		defer writes.Unlock()
+		return conn.WriteJSON(v)
+	}
+	if err = write(subscriptionMessage{Event: infoEvent, Version: protocolVersion}); err != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	feeds := make(map[string]chan struct{})
+	defer func() {
+		server.mu.Lock()
+		for key := range feeds {
+			server.subscribed[key]--
+		}
+		server.mu.Unlock()
+	}()
+	for {
+		var request subscriptionMessage
+		if err = conn.ReadJSON(&request); err != nil {
+			return
+		}
+		if request.Event != subscribeEvent && request.Event != unsubscribeEvent {
+			continue
+		}
+		response := subscriptionMessage{
+			Event:      subscribedEvent,
+			Feed:       request.Feed,
+			ProductIDs: request.ProductIDs,
+		}
+		if request.Event == unsubscribeEvent {
+			response.Event = unsubscribedEvent
+			server.mu.Lock()
+			for _, productID := range request.ProductIDs {
+				key := feedKey(productID, domain.CandleInterval(request.Feed))
+				if feedStop, ok := feeds[key]; ok {
+					close(feedStop)
+					delete(feeds, key)
+					server.subscribed[key]--
+				}
+			}
+			server.mu.Unlock()
+		}
+		if err = write(response); err != nil {
+			return
+		}
+		if request.Event == unsubscribeEvent {
+			continue
+		}
+		if request.Feed == heartbeatFeed {
+			go server.sendHeartbeats(write, stop)
+			continue
+		}
+		for _, productID := range request.ProductIDs {
+			key := feedKey(productID, domain.CandleInterval(request.Feed))
+			if _, ok := feeds[key]; ok {
+				continue
+			}
+			feedStop := make(chan struct{})
+			server.mu.Lock()
+			feeds[key] = feedStop
+			server.subscribed[key]++
+			server.mu.Unlock()
+			go server.sendCandles(write, stop, feedStop, productID, request.Feed)
+		}
+	}
+}
+
+// sendHeartbeats sends heartbeat messages until connection is closed.
+func (server *Server) sendHeartbeats(write func(interface{}) error, stop chan struct{}) {
+	ticker := time.NewTicker(server.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-server.done:
+			return
+		case <-ticker.C:
+			message := heartbeatMessage{Feed: heartbeatFeed, Time: time.Now().UnixMilli()}
+			if err := write(message); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendCandles sends scripted candles of the feed.
+func (server *Server) sendCandles(write func(interface{}) error, stop, feedStop chan struct{},
+	productID, feed string) {
+	server.mu.Lock()
+	candles := server.candles[feedKey(productID, domain.CandleInterval(feed))]
+	server.mu.Unlock()
+	for _, candle := range candles {
+		select {
+		case <-stop:
+			return
+		case <-feedStop:
+			return
+		case <-server.done:
+			return
+		case <-time.After(server.CandleDelay):
+		}
+		message := candleMessage{
+			Feed:      feed,
+			Candle:    candleJSON(candle),
+			ProductID: productID,
+			Time:      candle.Time,
+		}
+		if err := write(message); err != nil {
+			return
+		}
+	}
+}
+
+// orderHandler checks order's signature and fills it by limit price.
+// Post-only, stop and take-profit orders are rested, unfilled part of limit order
+// is rested and unfilled part of ioc order is cancelled.
+func (server *Server) orderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	values := r.URL.Query()
+	if !server.checkSign(r) {
+		writeJSON(w, map[string]string{"result": errorResult, "error": authError})
+		return
+	}
+	price, err := strconv.ParseFloat(values.Get("limitPrice"), 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	size, err := strconv.ParseFloat(values.Get("size"), 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var stopPrice float64
+	if len(values.Get("stopPrice")) != 0 {
+		if stopPrice, err = strconv.ParseFloat(values.Get("stopPrice"), 64); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	orderType := domain.OrderType(values.Get("orderType"))
+	if len(orderType) == 0 {
+		orderType = iocOrder
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.orders = append(server.orders, values)
+	orderID := fmt.Sprintf("%s-%d", fakeOrderIDPrefix, len(server.orders))
+	var filled float64
+	if orderType == iocOrder || orderType == limitOrder {
+		filled = size
+		if server.FillLimit > 0 && server.FillLimit < filled {
+			filled = server.FillLimit
+		}
+	}
+	placed := &domain.PlacedOrder{
+		OrderID:    orderID,
+		Type:       orderType,
+		Symbol:     values.Get("symbol"),
+		Side:       values.Get("side"),
+		Quantity:   size,
+		Filled:     filled,
+		LimitPrice: price,
+		StopPrice:  stopPrice,
+		ReduceOnly: values.Get("reduceOnly") == "true",
+	}
+	events := make([]*domain.OrderEvent, 0, 2)
+	publicKey := r.Header.Get("APIKey")
+	if filled > 0 {
+		server.fills[publicKey] = append(server.fills[publicKey], domain.Fill{
+			FillID:   fmt.Sprintf("%s-fill-%d", fakeOrderIDPrefix, len(server.orders)),
+			Symbol:   placed.Symbol,
+			Side:     placed.Side,
+			OrderID:  orderID,
+			Size:     filled,
+			Price:    price,
+			FillTime: time.Now().UTC(),
+			FillType: takerFill,
+		})
+		events = append(events, &domain.OrderEvent{
+			Price:  price,
+			Amount: filled,
+			Type:   executionType,
+			OrderPriorExecution: &domain.OrderPriorExecution{
+				Side: values.Get("side"),
+				Type: orderType,
+			},
+		})
+	}
+	switch {
+	case filled == size:
+	case orderType == iocOrder:
+		events = append(events, &domain.OrderEvent{Type: cancelType, Order: placed})
+	default:
+		events = append(events, &domain.OrderEvent{Type: placeType, Order: placed})
+		server.openOrders[publicKey] = append(server.openOrders[publicKey], domain.OpenOrder{
+			OrderID:      orderID,
+			Symbol:       placed.Symbol,
+			Side:         placed.Side,
+			Type:         orderType,
+			LimitPrice:   price,
+			StopPrice:    stopPrice,
+			UnfilledSize: size - filled,
+			FilledSize:   filled,
+			ReduceOnly:   placed.ReduceOnly,
+			Status:       untouchedStatus,
+		})
+	}
+
+	writeJSON(w, domain.OrderResponse{
+		Result: successResult,
+		SendStatus: &domain.SendStatus{
+			OrderID:     orderID,
+			Status:      placedStatus,
+			OrderEvents: events,
+		},
+	})
+}
+
+// cancelHandler checks request's signature and cancels user's resting order.
+func (server *Server) cancelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !server.checkSign(r) {
+		writeJSON(w, map[string]string{"result": errorResult, "error": authError})
+		return
+	}
+	orderID := r.URL.Query().Get("order_id")
+	publicKey := r.Header.Get("APIKey")
+	status := notFoundStatus
+	server.mu.Lock()
+	orders := server.openOrders[publicKey]
+	for i, order := range orders {
+		if order.OrderID == orderID {
+			server.openOrders[publicKey] = append(orders[:i], orders[i+1:]...)
+			status = cancelledStatus
+			break
+		}
+	}
+	server.mu.Unlock()
+	writeJSON(w, domain.CancelResponse{
+		Result:       successResult,
+		CancelStatus: &domain.CancelStatus{OrderID: orderID, Status: status},
+	})
+}
+
+// openOrdersHandler checks request's signature and returns user's resting orders.
+func (server *Server) openOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	if !server.checkPrivateGet(w, r) {
+		return
+	}
+	server.mu.Lock()
+	orders := make([]domain.OpenOrder, len(server.openOrders[r.Header.Get("APIKey")]))
+	copy(orders, server.openOrders[r.Header.Get("APIKey")])
+	server.mu.Unlock()
+	writeJSON(w, domain.OpenOrdersResponse{Result: successResult, OpenOrders: orders})
+}
+
+// chartsHandler returns scripted candles of symbol and resolution from the path,
+// which started at from seconds or later.
+func (server *Server) chartsHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, ChartsPath), "/")
+	if len(parts) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	server.mu.Lock()
+	scripted := server.candles[feedKey(parts[0], domain.CandleInterval(candlesPrefix+parts[1]))]
+	candles := make([]domain.CandleJSON, 0, len(scripted))
+	for _, candle := range scripted {
+		if candle.Time >= from*1000 {
+			candles = append(candles, candleJSON(candle))
+		}
+	}
+	server.mu.Unlock()
+	writeJSON(w, candlesResponse{Candles: candles})
+}
+
+// fillsHandler checks request's signature and returns user's fills.
+func (server *Server) fillsHandler(w http.ResponseWriter, r *http.Request) {
+	if !server.checkPrivateGet(w, r) {
+		return
+	}
+	server.mu.Lock()
+	fills := make([]domain.Fill, len(server.fills[r.Header.Get("APIKey")]))
+	copy(fills, server.fills[r.Header.Get("APIKey")])
+	server.mu.Unlock()
+	writeJSON(w, domain.FillsResponse{Result: successResult, Fills: fills})
+}
+
+// openPositionsHandler checks request's signature and returns positions counted by fills.
+func (server *Server) openPositionsHandler(w http.ResponseWriter, r *http.Request) {
+	if !server.checkPrivateGet(w, r) {
+		return
+	}
+	server.mu.Lock()
+	sizes := make(map[string]float64)
+	symbols := make([]string, 0)
+	for _, fill := range server.fills[r.Header.Get("APIKey")] {
+		if _, ok := sizes[fill.Symbol]; !ok {
+			symbols = append(symbols, fill.Symbol)
+		}
+		if fill.Side == string(domain.Sell) {
+			sizes[fill.Symbol] -= fill.Size
+		} else {
+			sizes[fill.Symbol] += fill.Size
+		}
+	}
+	server.mu.Unlock()
+	positions := make([]domain.OpenPosition, 0, len(symbols))
+	for _, symbol := range symbols {
+		position := domain.OpenPosition{Symbol: symbol, Side: domain.LongSide, Size: sizes[symbol]}
+		switch {
+		case sizes[symbol] == 0:
+			continue
+		case sizes[symbol] < 0:
+			position.Side = domain.ShortSide
+			position.Size = -sizes[symbol]
+		}
+		positions = append(positions, position)
+	}
+	writeJSON(w, domain.OpenPositionsResponse{Result: successResult, OpenPositions: positions})
+}
+
+// accountsHandler checks request's signature and returns user's accounts.
+func (server *Server) accountsHandler(w http.ResponseWriter, r *http.Request) {
+	if !server.checkPrivateGet(w, r) {
+		return
+	}
+	server.mu.Lock()
+	accounts := make(map[string]domain.Account, len(server.accounts[r.Header.Get("APIKey")]))
+	for name, account := range server.accounts[r.Header.Get("APIKey")] {
+		accounts[name] = account
+	}
+	server.mu.Unlock()
+	writeJSON(w, domain.AccountsResponse{Result: successResult, Accounts: accounts})
+}
+
+// checkPrivateGet checks method and signature of private GET request and writes failure.
+func (server *Server) checkPrivateGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return false
+	}
+	if !server.checkSign(r) {
+		writeJSON(w, map[string]string{"result": errorResult, "error": authError})
+		return false
+	}
+	return true
+}
+
+// checkSign checks request's APIKey and Authent headers like Kraken does.
+func (server *Server) checkSign(r *http.Request) bool {
+	server.mu.Lock()
+	privateKey, ok := server.keys[r.Header.Get("APIKey")]
+	server.mu.Unlock()
+	if !ok {
+		return false
+	}
+	apiPath := strings.TrimPrefix(r.URL.Path, derivativePath)
+	sha := sha256.New()
+	sha.Write([]byte(r.URL.RawQuery + apiPath))
+	secret, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha512.New, secret)
+	mac.Write(sha.Sum(nil))
+	sign, err := base64.StdEncoding.DecodeString(r.Header.Get("Authent"))
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(sign, mac.Sum(nil))
+}
+
+// candleJSON converts Candle to kraken's candle format.
+func candleJSON(candle domain.Candle) domain.CandleJSON {
+	return domain.CandleJSON{
+		Open:   strconv.FormatFloat(candle.Open, 'f', -1, 64),
+		Close:  strconv.FormatFloat(candle.Close, 'f', -1, 64),
+		High:   strconv.FormatFloat(candle.High, 'f', -1, 64),
+		Low:    strconv.FormatFloat(candle.Low, 'f', -1, 64),
+		Time:   candle.Time,
+		Volume: candle.Volume,
+	}
+}
+
+func feedKey(productID string, interval domain.CandleInterval) string {
+	return productID + "/" + string(interval)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(data)
+}
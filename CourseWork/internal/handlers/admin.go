@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/service"
+)
+
+// pairRequest describes admin's body of running pair.
+type pairRequest struct {
+	PairName     string                `json:"pair_name"`
+	PairInterval domain.CandleInterval `json:"pair_interval"`
+}
+
+// userRequest describes admin's body of user.
+type userRequest struct {
+	Username string `json:"username"`
+}
+
+// adminPairsHandler handles running pairs state algorithm.
+func (handler *Handler) adminPairsHandler(w http.ResponseWriter, r *http.Request) {
+	processJSON(w, http.StatusOK, handler.Trader.PairsInfo())
+}
+
+// adminPausePairHandler handles pausing pair's trading algorithm.
+func (handler *Handler) adminPausePairHandler(w http.ResponseWriter, r *http.Request) {
+	handler.processPair(w, r, handler.Trader.PausePair)
+}
+
+// adminResumePairHandler handles resuming pair's trading algorithm.
+func (handler *Handler) adminResumePairHandler(w http.ResponseWriter, r *http.Request) {
+	handler.processPair(w, r, handler.Trader.ResumePair)
+}
+
+// adminStopPairHandler handles force stopping pair algorithm.
+func (handler *Handler) adminStopPairHandler(w http.ResponseWriter, r *http.Request) {
+	handler.processPair(w, r, handler.Trader.StopPair)
+}
+
+// adminRemoveUserHandler handles removing user from all pairs algorithm.
+func (handler *Handler) adminRemoveUserHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	request := userRequest{}
+	if err = json.Unmarshal(data, &request); err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = handler.Trader.RemoveUser(request.Username); err != nil {
+		if errors.Is(err, service.ErrUserIsNotLogged) {
+			processError(w, http.StatusNotFound, err)
+			return
+		}
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// processPair reads pairRequest and applies action to the pair.
+func (handler *Handler) processPair(w http.ResponseWriter, r *http.Request,
+	action func(string, domain.CandleInterval) error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	request := pairRequest{}
+	if err = json.Unmarshal(data, &request); err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = action(request.PairName, request.PairInterval); err != nil {
+		if errors.Is(err, service.ErrPairNotFound) {
+			processError(w, http.StatusNotFound, err)
+			return
+		}
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -46,6 +46,26 @@ func (handler *Handler) loginHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
+// migrateUserHandler handles setting password of user stored before passwords.
+func (handler *Handler) migrateUserHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		processError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer r.Body.Close()
+	var input domain.User
+	if err = json.Unmarshal(data, &input); err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = handler.Trader.MigrateUser(input); err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // authHandler handles auth algorithm.
 func (handler *Handler) authHandler(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
@@ -77,3 +97,18 @@ func (handler *Handler) authHandler(next http.Handler) http.Handler {
 	}
 	return http.HandlerFunc(fn)
 }
+
+// adminHandler allows requests of admin users only, it should follow authHandler.
+// Role is read on every request, so revoked admin is rejected immediately.
+func (handler *Handler) adminHandler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		username := r.Context().Value(ctxKey("username")).(string)
+		role, err := handler.Trader.Users.GetRole(username)
+		if err != nil || role != domain.AdminRole {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
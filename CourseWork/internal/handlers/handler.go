@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
@@ -28,17 +31,36 @@ func (handler *Handler) InitRoutes() *chi.Mux {
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/register", handler.addUserHandler)
 		r.Post("/login", handler.loginHandler)
+		r.Post("/migrate", handler.migrateUserHandler)
 	})
 
 	r.Group(func(r chi.Router) {
 		r.Use(handler.authHandler)
 		r.Route("/users", func(r chi.Router) {
 			r.Post("/set_keys", handler.setKeys)
+			r.Post("/set_risk", handler.setRisk)
 		})
 		r.Route("/pair", func(r chi.Router) {
 			r.Post("/start", handler.startPairHandler)
 			r.Post("/stop", handler.stopPairHandler)
 		})
+		r.Route("/orders", func(r chi.Router) {
+			r.Get("/", handler.ordersHandler)
+			r.Get("/open", handler.openOrdersHandler)
+			r.Post("/cancel", handler.cancelOrderHandler)
+		})
+		r.Get("/pairs/{name}/candles", handler.candlesHandler)
+		r.Get("/portfolio", handler.portfolioHandler)
+		r.Post("/reconciliation", handler.reconciliationHandler)
+		r.Get("/audit", handler.auditHandler)
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(handler.adminHandler)
+			r.Get("/pairs", handler.adminPairsHandler)
+			r.Post("/pairs/pause", handler.adminPausePairHandler)
+			r.Post("/pairs/resume", handler.adminResumePairHandler)
+			r.Post("/pairs/stop", handler.adminStopPairHandler)
+			r.Post("/users/remove", handler.adminRemoveUserHandler)
+		})
 	})
 
 	return r
@@ -78,7 +100,28 @@ func (handler *Handler) setKeys(w http.ResponseWriter, r *http.Request) {
 		processError(w, http.StatusBadRequest, err)
 		return
 	}
-	if err = handler.Trader.Users.SetKeys(username, user.PublicKey, user.PrivateKey); err != nil {
+	if err = handler.Trader.SetKeys(username, user.PublicKey, user.PrivateKey); err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// setRisk handles setting risk limits algorithm.
+func (handler *Handler) setRisk(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(ctxKey("username")).(string)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	limits := domain.RiskLimits{}
+	if err = json.Unmarshal(data, &limits); err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = handler.Trader.SetRisk(username, limits); err != nil {
 		processError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -127,6 +170,204 @@ func (handler *Handler) stopPairHandler(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 }
 
+// ordersHandler handles user's orders history algorithm.
+func (handler *Handler) ordersHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(ctxKey("username")).(string)
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	page, err := handler.Trader.GetOrders(username, filter)
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	processJSON(w, http.StatusOK, page)
+}
+
+// candlesHandler handles stored candles of pair algorithm.
+func (handler *Handler) candlesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseCandleFilter(chi.URLParam(r, "name"), r.URL.Query())
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	candles, err := handler.Trader.GetCandles(filter)
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	processJSON(w, http.StatusOK, candles)
+}
+
+// openOrdersHandler handles user's resting orders algorithm.
+func (handler *Handler) openOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(ctxKey("username")).(string)
+	orders, err := handler.Trader.OpenOrders(username, r.URL.Query().Get("pair"))
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	processJSON(w, http.StatusOK, orders)
+}
+
+// cancelOrderHandler handles resting order cancellation algorithm.
+func (handler *Handler) cancelOrderHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(ctxKey("username")).(string)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	request := cancelRequest{}
+	if err = json.Unmarshal(data, &request); err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = handler.Trader.CancelOrder(username, request.PairName, request.OrderID); err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// cancelRequest describes order cancellation body, pair chooses live or paper exchange.
+type cancelRequest struct {
+	PairName string `json:"pair_name"`
+	OrderID  string `json:"order_id"`
+}
+
+// portfolioHandler handles user's positions and PnL algorithm.
+func (handler *Handler) portfolioHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(ctxKey("username")).(string)
+	processJSON(w, http.StatusOK, handler.Trader.Portfolio(username))
+}
+
+// reconciliationHandler handles comparison of user's orders and positions with exchange.
+// It marks mismatches and applies late fills, so it isn't GET.
+func (handler *Handler) reconciliationHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(ctxKey("username")).(string)
+	report, err := handler.Trader.Reconcile(username)
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	processJSON(w, http.StatusOK, report)
+}
+
+// auditHandler handles user's trading decisions chain algorithm.
+func (handler *Handler) auditHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(ctxKey("username")).(string)
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	events, err := handler.Trader.GetAudit(username, filter)
+	if err != nil {
+		processError(w, http.StatusBadRequest, err)
+		return
+	}
+	processJSON(w, http.StatusOK, events)
+}
+
+// parseOrderFilter reads OrderFilter from query, dates are in RFC3339 format.
+func parseOrderFilter(query url.Values) (domain.OrderFilter, error) {
+	filter := domain.OrderFilter{
+		PairName: query.Get("pair"),
+		Side:     query.Get("side"),
+	}
+	var err error
+	if filter.From, err = parseQueryTime(query, "from"); err != nil {
+		return domain.OrderFilter{}, err
+	}
+	if filter.To, err = parseQueryTime(query, "to"); err != nil {
+		return domain.OrderFilter{}, err
+	}
+	if filter.Cursor, err = parseQueryInt(query, "cursor"); err != nil {
+		return domain.OrderFilter{}, err
+	}
+	if filter.Limit, err = parseQueryInt(query, "limit"); err != nil {
+		return domain.OrderFilter{}, err
+	}
+	return filter, nil
+}
+
+// parseCandleFilter reads pair's candles filter from query.
+func parseCandleFilter(pairName string, query url.Values) (domain.CandleFilter, error) {
+	filter := domain.CandleFilter{
+		PairName: pairName,
+		Interval: domain.CandleInterval(query.Get("interval")),
+	}
+	var err error
+	if filter.From, err = parseQueryTime(query, "from"); err != nil {
+		return domain.CandleFilter{}, err
+	}
+	if filter.To, err = parseQueryTime(query, "to"); err != nil {
+		return domain.CandleFilter{}, err
+	}
+	if filter.Limit, err = parseQueryInt(query, "limit"); err != nil {
+		return domain.CandleFilter{}, err
+	}
+	return filter, nil
+}
+
+// parseAuditFilter reads AuditFilter from query, dates are in RFC3339 format.
+func parseAuditFilter(query url.Values) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{OrderID: query.Get("order_id")}
+	var err error
+	if filter.From, err = parseQueryTime(query, "from"); err != nil {
+		return domain.AuditFilter{}, err
+	}
+	if filter.To, err = parseQueryTime(query, "to"); err != nil {
+		return domain.AuditFilter{}, err
+	}
+	if filter.Limit, err = parseQueryInt(query, "limit"); err != nil {
+		return domain.AuditFilter{}, err
+	}
+	return filter, nil
+}
+
+// parseQueryTime returns zero time if parameter is absent.
+func parseQueryTime(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s parameter <%w>", name, err)
+	}
+	return parsed, nil
+}
+
+// parseQueryInt returns zero if parameter is absent.
+func parseQueryInt(query url.Values, name string) (int64, error) {
+	value := query.Get(name)
+	if len(value) == 0 {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s parameter <%w>", name, err)
+	}
+	return parsed, nil
+}
+
+// processJSON sends status code with JSON body.
+func processJSON(w http.ResponseWriter, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		processError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
 // processError sends status code with error text.
 func processError(w http.ResponseWriter, status int, err error) {
 	w.WriteHeader(status)
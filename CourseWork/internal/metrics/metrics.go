@@ -0,0 +1,114 @@
+// Package metrics keeps Prometheus collectors of trader.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "trader"
+
+const (
+	Success = "success"
+	Failure = "failure"
+)
+
+var (
+	// CandlesReceived counts candles received by pairs, backfilled candles are included.
+	CandlesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "candles_received_total",
+		Help:      "Candles received by pairs.",
+	}, []string{"pair", "interval"})
+	// Signals counts Indicators' signals by their types.
+	Signals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signals_total",
+		Help:      "Signals of users' indicators by type.",
+	}, []string{"pair", "interval", "signal"})
+	// Orders counts orders sent to stock market or paper exchange by trading mode and outcome.
+	Orders = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_total",
+		Help:      "Orders sent to stock market or paper exchange by mode and outcome.",
+	}, []string{"mode", "outcome"})
+	// OrderLatency observes duration of order sending by trading mode and outcome.
+	OrderLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "order_latency_seconds",
+		Help:      "Duration of order sending by mode and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"mode", "outcome"})
+	// Reconnects counts websocket reconnection attempts by outcome.
+	Reconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "socket_reconnects_total",
+		Help:      "Websocket reconnection attempts by outcome.",
+	}, []string{"outcome"})
+	// DeliveryFailures counts messages, which weren't delivered by message writers, e.g. telegram.
+	DeliveryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "message_delivery_failures_total",
+		Help:      "Messages to users, which weren't delivered by message writers.",
+	}, []string{"kind"})
+	// AuditDropped counts audit events, which were dropped because audit buffer was full.
+	AuditDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_events_dropped_total",
+		Help:      "Audit events dropped because audit buffer was full.",
+	})
+	// ActivePairs is quantity of running pairs by name and interval.
+	ActivePairs = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_pairs",
+		Help:      "Running pairs by name and interval.",
+	})
+	// ActiveUsers is quantity of users, which are subscribed on any running pair.
+	ActiveUsers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_users",
+		Help:      "Users subscribed on running pairs.",
+	})
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		CandlesReceived,
+		Signals,
+		Orders,
+		OrderLatency,
+		Reconnects,
+		DeliveryFailures,
+		AuditDropped,
+		ActivePairs,
+		ActiveUsers,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler returns http handler, which exposes collectors in Prometheus format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Outcome returns Success if err is nil and Failure otherwise.
+func Outcome(err error) string {
+	if err != nil {
+		return Failure
+	}
+	return Success
+}
+
+// ObserveOrder counts order of trading mode, which sending started at start, by its outcome.
+func ObserveOrder(mode string, start time.Time, err error) {
+	outcome := Outcome(err)
+	Orders.WithLabelValues(mode, outcome).Inc()
+	OrderLatency.WithLabelValues(mode, outcome).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutcome(t *testing.T) {
+	assert.Equal(t, Outcome(nil), Success)
+	assert.Equal(t, Outcome(errors.New("error")), Failure)
+}
+
+func TestHandler(t *testing.T) {
+	CandlesReceived.WithLabelValues("PI_XBTUSD", "candles_trade_1m").Inc()
+	ActivePairs.Set(3)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	body, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body),
+		`trader_candles_received_total{interval="candles_trade_1m",pair="PI_XBTUSD"} 1`)
+	assert.Contains(t, string(body), "trader_active_pairs 3")
+	assert.Contains(t, string(body), "go_goroutines")
+}
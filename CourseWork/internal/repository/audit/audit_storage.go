@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrNotConnected = errors.New("there is no db connection")
+)
+
+// AuditStorage appends audit events of users' trading decisions, events are never updated.
+type AuditStorage struct {
+	pool   *pgxpool.Pool
+	Config repository.ConnectionConfig
+}
+
+func (storage *AuditStorage) Connect() error {
+	pool, err := repository.Connect(storage.Config)
+	if err != nil {
+		return err
+	}
+	storage.pool = pool
+	return nil
+}
+
+// AddEvent appends audit event.
+func (storage AuditStorage) AddEvent(event domain.AuditEvent) error {
+	if storage.pool == nil {
+		return ErrNotConnected
+	}
+	var data interface{}
+	if len(event.Data) != 0 {
+		data = string(event.Data)
+	}
+	_, err := storage.pool.Exec(context.Background(),
+		"INSERT INTO audit_events(trace_id, stage, time, username, name, pair_interval, "+
+			"order_id, error, data) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		event.TraceID, string(event.Stage), event.Time, event.Username, event.PairName,
+		string(event.Interval), event.OrderID, event.Error, data)
+	if err != nil {
+		return fmt.Errorf("can't add to db <%w>", err)
+	}
+	return nil
+}
+
+// GetEvents returns user's audit events by AuditFilter.
+func (storage AuditStorage) GetEvents(filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	if storage.pool == nil {
+		return nil, ErrNotConnected
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	query, args := eventsQuery(filter)
+	rows, err := storage.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't read from db <%w>", err)
+	}
+	defer rows.Close()
+
+	events := make([]domain.AuditEvent, 0)
+	for rows.Next() {
+		var event domain.AuditEvent
+		var stage, interval string
+		var data []byte
+		err = rows.Scan(&event.ID, &event.TraceID, &stage, &event.Time, &event.Username,
+			&event.PairName, &interval, &event.OrderID, &event.Error, &data)
+		if err != nil {
+			return nil, fmt.Errorf("can't read from db <%w>", err)
+		}
+		event.Stage = domain.AuditStage(stage)
+		event.Interval = domain.CandleInterval(interval)
+		event.Data = data
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("buf db error <%w>", err)
+	}
+	return events, nil
+}
+
+// eventsQuery builds select query by AuditFilter, OrderID selects all events
+// of decisions, which have the order. Events without trace id aren't decisions' stages,
+// so they aren't selected by OrderID.
+func eventsQuery(filter domain.AuditFilter) (string, []interface{}) {
+	conditions := []string{"username = $1"}
+	args := []interface{}{filter.Username}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if len(filter.OrderID) != 0 {
+		addCondition("trace_id IN (SELECT trace_id FROM audit_events "+
+			"WHERE username = $1 AND order_id = $%d AND trace_id <> '')", filter.OrderID)
+	}
+	if !filter.From.IsZero() {
+		addCondition("time >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("time <= $%d", filter.To)
+	}
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf("SELECT id, trace_id, stage, time, username, name, pair_interval, "+
+		"order_id, error, data FROM audit_events WHERE %s "+
+		"ORDER BY id LIMIT $%d", strings.Join(conditions, " AND "), len(args))
+	return query, args
+}
+
+func (storage AuditStorage) Shutdown() {
+	if storage.pool != nil {
+		storage.pool.Close()
+	}
+}
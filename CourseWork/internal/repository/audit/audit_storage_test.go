@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsQuery(t *testing.T) {
+	query, args := eventsQuery(domain.AuditFilter{Username: "username", Limit: 10})
+	assert.Contains(t, query, "WHERE username = $1 ORDER BY id LIMIT $2")
+	assert.Equal(t, args, []interface{}{"username", int64(10)})
+
+	from := time.Unix(1, 0)
+	to := time.Unix(2, 0)
+	query, args = eventsQuery(domain.AuditFilter{Username: "username", OrderID: "id",
+		From: from, To: to, Limit: 10})
+	assert.Contains(t, query, "WHERE username = $1 AND trace_id IN (SELECT trace_id "+
+		"FROM audit_events WHERE username = $1 AND order_id = $2 AND trace_id <> '') AND "+
+		"time >= $3 AND time <= $4 ORDER BY id LIMIT $5")
+	assert.Equal(t, args, []interface{}{"username", "id", from, to, int64(10)})
+}
+
+func TestAuditStorage_NotConnected(t *testing.T) {
+	storage := AuditStorage{}
+	assert.ErrorIs(t, storage.AddEvent(domain.AuditEvent{}), ErrNotConnected)
+	_, err := storage.GetEvents(domain.AuditFilter{Username: "username"})
+	assert.ErrorIs(t, err, ErrNotConnected)
+}
@@ -0,0 +1,108 @@
+package candles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrNotConnected = errors.New("there is no db connection")
+)
+
+// CandleStorage keeps closed candles of pairs by pair's name, interval and time.
+type CandleStorage struct {
+	pool   *pgxpool.Pool
+	Config repository.ConnectionConfig
+}
+
+func (storage *CandleStorage) Connect() error {
+	pool, err := repository.Connect(storage.Config)
+	if err != nil {
+		return err
+	}
+	storage.pool = pool
+	return nil
+}
+
+// AddCandle saves pair's candle, candle with the same time is overwritten.
+func (storage CandleStorage) AddCandle(pairName string, candle domain.Candle) error {
+	if storage.pool == nil {
+		return ErrNotConnected
+	}
+	_, err := storage.pool.Exec(context.Background(),
+		"INSERT INTO candles(name, pair_interval, time, open, close, high, low, volume) "+
+			"VALUES($1, $2, $3, $4, $5, $6, $7, $8) "+
+			"ON CONFLICT (name, pair_interval, time) DO UPDATE SET open = EXCLUDED.open, "+
+			"close = EXCLUDED.close, high = EXCLUDED.high, low = EXCLUDED.low, "+
+			"volume = EXCLUDED.volume", strings.ToUpper(pairName), string(candle.Interval),
+		candle.Time, candle.Open, candle.Close, candle.High, candle.Low, candle.Volume)
+	if err != nil {
+		return fmt.Errorf("can't add to db <%w>", err)
+	}
+	return nil
+}
+
+// GetCandles returns pair's candles by CandleFilter.
+func (storage CandleStorage) GetCandles(filter domain.CandleFilter) ([]domain.Candle, error) {
+	if storage.pool == nil {
+		return nil, ErrNotConnected
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	query, args := candlesQuery(filter)
+	rows, err := storage.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't read from db <%w>", err)
+	}
+	defer rows.Close()
+
+	candles := make([]domain.Candle, 0, filter.Limit)
+	for rows.Next() {
+		candle := domain.Candle{Interval: filter.Interval}
+		err = rows.Scan(&candle.Time, &candle.Open, &candle.Close, &candle.High,
+			&candle.Low, &candle.Volume)
+		if err != nil {
+			return nil, fmt.Errorf("can't read from db <%w>", err)
+		}
+		candles = append(candles, candle)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("buf db error <%w>", err)
+	}
+	return candles, nil
+}
+
+// candlesQuery builds select query by CandleFilter, From and To are compared
+// with candle's start time in milliseconds.
+func candlesQuery(filter domain.CandleFilter) (string, []interface{}) {
+	conditions := []string{"name = $1", "pair_interval = $2"}
+	args := []interface{}{strings.ToUpper(filter.PairName), string(filter.Interval)}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if !filter.From.IsZero() {
+		addCondition("time >= $%d", filter.From.UnixMilli())
+	}
+	if !filter.To.IsZero() {
+		addCondition("time <= $%d", filter.To.UnixMilli())
+	}
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf("SELECT time, open, close, high, low, volume "+
+		"FROM candles WHERE %s "+
+		"ORDER BY time LIMIT $%d", strings.Join(conditions, " AND "), len(args))
+	return query, args
+}
+
+func (storage CandleStorage) Shutdown() {
+	if storage.pool != nil {
+		storage.pool.Close()
+	}
+}
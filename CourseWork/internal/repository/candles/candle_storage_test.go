@@ -0,0 +1,32 @@
+package candles
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCandlesQuery(t *testing.T) {
+	query, args := candlesQuery(domain.CandleFilter{PairName: "pi_xbtusd",
+		Interval: domain.Candle1m, Limit: 10})
+	assert.Contains(t, query, "WHERE name = $1 AND pair_interval = $2 ORDER BY time LIMIT $3")
+	assert.Equal(t, args, []interface{}{"PI_XBTUSD", string(domain.Candle1m), int64(10)})
+
+	from := time.Unix(1, 0)
+	to := time.Unix(2, 0)
+	query, args = candlesQuery(domain.CandleFilter{PairName: "PI_XBTUSD",
+		Interval: domain.Candle5m, From: from, To: to, Limit: 10})
+	assert.Contains(t, query, "WHERE name = $1 AND pair_interval = $2 AND "+
+		"time >= $3 AND time <= $4 ORDER BY time LIMIT $5")
+	assert.Equal(t, args, []interface{}{"PI_XBTUSD", string(domain.Candle5m),
+		int64(1000), int64(2000), int64(10)})
+}
+
+func TestCandleStorage_NotConnected(t *testing.T) {
+	storage := CandleStorage{}
+	assert.ErrorIs(t, storage.AddCandle("pair", domain.Candle{}), ErrNotConnected)
+	_, err := storage.GetCandles(domain.CandleFilter{PairName: "pair", Interval: domain.Candle1m})
+	assert.ErrorIs(t, err, ErrNotConnected)
+}
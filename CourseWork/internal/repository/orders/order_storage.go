@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -15,23 +18,13 @@ var (
 
 type OrderStorage struct {
 	pool   *pgxpool.Pool
-	Config ConnectionConfig
-}
-
-type ConnectionConfig struct {
-	Username string
-	Password string
-	NameDB   string
-	Port     string
+	Config repository.ConnectionConfig
 }
 
 func (storage *OrderStorage) Connect() error {
-	dsn := fmt.Sprintf("postgres://%s:%s@localhost:%s/%s"+
-		"?sslmode=disable", storage.Config.Username, storage.Config.Password,
-		storage.Config.Port, storage.Config.NameDB)
-	pool, err := pgxpool.Connect(context.Background(), dsn)
+	pool, err := repository.Connect(storage.Config)
 	if err != nil {
-		return fmt.Errorf("can't connect db <%w>", err)
+		return err
 	}
 	storage.pool = pool
 	return nil
@@ -41,39 +34,126 @@ func (storage OrderStorage) AddOrder(info domain.OrderInfo) error {
 	if storage.pool == nil {
 		return ErrNotConnected
 	}
+	if info.CreatedAt.IsZero() {
+		info.CreatedAt = time.Now()
+	}
 	_, err := storage.pool.Exec(context.Background(),
-		"INSERT INTO orders(name, orderID, price, amount, side) VALUES($1, $2, $3, $4, $5)", info.Name,
-		info.OrderID, info.Price, info.Amount, info.Side)
+		"INSERT INTO orders(name, orderID, price, amount, side, username, pair_interval, "+
+			"indicator_name, created_at, order_type, size, status) "+
+			"VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)", info.Name,
+		info.OrderID, info.Price, info.Amount, info.Side, info.Username, string(info.Interval),
+		info.Indicator, info.CreatedAt, string(info.Type), info.Size, info.Status)
 	if err != nil {
 		return fmt.Errorf("can't add to db <%w>", err)
 	}
 	return nil
 }
 
-func (storage OrderStorage) GetOrders(offset int64) ([]domain.OrderInfo, error) {
-	rows, err := storage.pool.Query(context.Background(), "SELECT * FROM orders")
+// GetOrders returns user's orders page by OrderFilter.
+func (storage OrderStorage) GetOrders(filter domain.OrderFilter) (domain.OrderPage, error) {
+	if storage.pool == nil {
+		return domain.OrderPage{}, ErrNotConnected
+	}
+	if err := filter.Validate(); err != nil {
+		return domain.OrderPage{}, err
+	}
+	query, args := ordersQuery(filter)
+	rows, err := storage.pool.Query(context.Background(), query, args...)
 	if err != nil {
-		return nil, err
+		return domain.OrderPage{}, fmt.Errorf("can't read from db <%w>", err)
 	}
 	defer rows.Close()
 
-	var counter int64
-	var tmp domain.OrderInfo
-	var id int64
-	infos := make([]domain.OrderInfo, 0)
-
-	for rows.Next() && (counter < offset) {
-		err = rows.Scan(&id, &tmp.Name, &tmp.OrderID, &tmp.Price, &tmp.Amount, &tmp.Side)
+	infos := make([]domain.OrderInfo, 0, filter.Limit)
+	for rows.Next() {
+		var info domain.OrderInfo
+		var interval, orderType string
+		err = rows.Scan(&info.ID, &info.Name, &info.OrderID, &info.Price, &info.Amount,
+			&info.Side, &info.Username, &interval, &info.Indicator, &info.CreatedAt,
+			&orderType, &info.Size, &info.Status, &info.Mismatch)
 		if err != nil {
-			return nil, fmt.Errorf("can't read from db <%w>", err)
+			return domain.OrderPage{}, fmt.Errorf("can't read from db <%w>", err)
 		}
-		infos = append(infos, tmp)
-		counter++
+		info.Interval = domain.CandleInterval(interval)
+		info.Type = domain.OrderType(orderType)
+		infos = append(infos, info)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("buf db error <%w>", err)
+		return domain.OrderPage{}, fmt.Errorf("buf db error <%w>", err)
+	}
+	return newOrderPage(infos, filter.Limit), nil
+}
+
+// ordersQuery builds select query by OrderFilter, one extra row shows next page existence.
+func ordersQuery(filter domain.OrderFilter) (string, []interface{}) {
+	conditions := []string{"username = $1"}
+	args := []interface{}{filter.Username}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if len(filter.OrderID) != 0 {
+		addCondition("orderID = $%d", filter.OrderID)
+	}
+	if len(filter.PairName) != 0 {
+		addCondition("name = $%d", filter.PairName)
+	}
+	if len(filter.Side) != 0 {
+		addCondition("side = $%d", filter.Side)
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at <= $%d", filter.To)
+	}
+	if filter.Cursor != 0 {
+		addCondition("id < $%d", filter.Cursor)
+	}
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf("SELECT id, name, orderID, price, amount, side, username, "+
+		"pair_interval, indicator_name, created_at, order_type, size, status, mismatch "+
+		"FROM orders WHERE %s "+
+		"ORDER BY id DESC LIMIT $%d", strings.Join(conditions, " AND "), len(args))
+	return query, args
+}
+
+// newOrderPage cuts extra order and sets cursor of the next page.
+func newOrderPage(infos []domain.OrderInfo, limit int64) domain.OrderPage {
+	page := domain.OrderPage{Orders: infos}
+	if int64(len(infos)) > limit {
+		page.Orders = infos[:limit]
+		page.NextCursor = page.Orders[limit-1].ID
 	}
-	return infos, nil
+	return page
+}
+
+// MarkMismatch sets reconciliation mismatch of user's order.
+func (storage OrderStorage) MarkMismatch(username, orderID, mismatch string) error {
+	if storage.pool == nil {
+		return ErrNotConnected
+	}
+	_, err := storage.pool.Exec(context.Background(),
+		"UPDATE orders SET mismatch = $1 WHERE username = $2 AND orderID = $3",
+		mismatch, username, orderID)
+	if err != nil {
+		return fmt.Errorf("can't update order in db <%w>", err)
+	}
+	return nil
+}
+
+// SetFill sets filled amount and average fill price of user's order.
+func (storage OrderStorage) SetFill(username, orderID string, amount int64, price float64) error {
+	if storage.pool == nil {
+		return ErrNotConnected
+	}
+	_, err := storage.pool.Exec(context.Background(),
+		"UPDATE orders SET amount = $1, price = $2 WHERE username = $3 AND orderID = $4",
+		amount, price, username, orderID)
+	if err != nil {
+		return fmt.Errorf("can't update order in db <%w>", err)
+	}
+	return nil
 }
 
 func (storage OrderStorage) Shutdown() {
@@ -0,0 +1,46 @@
+package orders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrdersQuery(t *testing.T) {
+	query, args := ordersQuery(domain.OrderFilter{Username: "name", Limit: 10})
+	assert.Contains(t, query, "WHERE username = $1 ORDER BY id DESC LIMIT $2")
+	assert.Equal(t, args, []interface{}{"name", int64(11)})
+
+	from := time.Unix(1, 0)
+	to := time.Unix(2, 0)
+	query, args = ordersQuery(domain.OrderFilter{Username: "name", PairName: "pair",
+		Side: "buy", From: from, To: to, Cursor: 5, Limit: 10})
+	assert.Contains(t, query, "WHERE username = $1 AND name = $2 AND side = $3 AND "+
+		"created_at >= $4 AND created_at <= $5 AND id < $6 ORDER BY id DESC LIMIT $7")
+	assert.Equal(t, args, []interface{}{"name", "pair", "buy", from, to, int64(5), int64(11)})
+
+	query, args = ordersQuery(domain.OrderFilter{Username: "name", OrderID: "id", Limit: 1})
+	assert.Contains(t, query, "WHERE username = $1 AND orderID = $2 ORDER BY id DESC LIMIT $3")
+	assert.Equal(t, args, []interface{}{"name", "id", int64(2)})
+}
+
+func TestNewOrderPage(t *testing.T) {
+	infos := []domain.OrderInfo{{ID: 3}, {ID: 2}, {ID: 1}}
+	page := newOrderPage(infos, 3)
+	assert.Equal(t, page.Orders, infos)
+	assert.Zero(t, page.NextCursor)
+
+	page = newOrderPage(infos, 2)
+	assert.Equal(t, page.Orders, infos[:2])
+	assert.Equal(t, page.NextCursor, int64(2))
+}
+
+func TestOrderStorage_NotConnected(t *testing.T) {
+	storage := OrderStorage{}
+	assert.ErrorIs(t, storage.AddOrder(domain.OrderInfo{}), ErrNotConnected)
+	_, err := storage.GetOrders(domain.OrderFilter{Username: "name"})
+	assert.ErrorIs(t, err, ErrNotConnected)
+	assert.ErrorIs(t, storage.MarkMismatch("name", "id", "mismatch"), ErrNotConnected)
+}
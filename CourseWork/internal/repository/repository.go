@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ConnectionConfig consists of postgres connection parameters.
+type ConnectionConfig struct {
+	Username string
+	Password string
+	NameDB   string
+	Port     string
+}
+
+// DSN returns connection string of local postgres.
+func (config ConnectionConfig) DSN() string {
+	return fmt.Sprintf("postgres://%s:%s@localhost:%s/%s"+
+		"?sslmode=disable", config.Username, config.Password,
+		config.Port, config.NameDB)
+}
+
+// Connect returns connection pool by ConnectionConfig.
+func Connect(config ConnectionConfig) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.Connect(context.Background(), config.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("can't connect db <%w>", err)
+	}
+	return pool, nil
+}
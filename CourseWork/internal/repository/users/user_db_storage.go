@@ -0,0 +1,250 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// UserDBStorage implements UserRepository over postgres,
+// loaded users are cached in UserStorage.
+type UserDBStorage struct {
+	*UserStorage
+	pool *pgxpool.Pool
+}
+
+// NewUserDBStorage returns pointer to UserDBStorage connected to postgres.
+func NewUserDBStorage(config repository.ConnectionConfig, signKey string,
+	ttlHours int64) (*UserDBStorage, error) {
+	cache, err := NewUserStorage(signKey, ttlHours)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := repository.Connect(config)
+	if err != nil {
+		return nil, err
+	}
+	return &UserDBStorage{UserStorage: cache, pool: pool}, nil
+}
+
+// AddUser adds user to db and cache.
+func (u UserDBStorage) AddUser(user *domain.User) error {
+	if len(user.Username) == 0 || len(user.PublicKey) == 0 || len(user.PrivateKey) == 0 {
+		return ErrIncorrectUserValues
+	}
+	risk, err := json.Marshal(user.Risk)
+	if err != nil {
+		return fmt.Errorf("can't encode risk limits <%w>", err)
+	}
+	tag, err := u.pool.Exec(context.Background(),
+		"INSERT INTO users(username, password_hash, public_key, private_key, telegram_id, risk) "+
+			"VALUES($1, $2, $3, $4, $5, $6) ON CONFLICT (username) DO NOTHING",
+		user.Username, user.PasswordHash, user.PublicKey, user.PrivateKey, user.TelegramID, risk)
+	if err != nil {
+		return fmt.Errorf("can't add user to db <%w>", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrExistedUser
+	}
+	return u.UserStorage.AddUser(user)
+}
+
+// DeleteUser deletes user with his subscriptions from db and cache.
+func (u UserDBStorage) DeleteUser(username string) error {
+	tag, err := u.pool.Exec(context.Background(),
+		"DELETE FROM users WHERE username = $1", username)
+	if err != nil {
+		return fmt.Errorf("can't delete user from db <%w>", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNonExistentUser
+	}
+	if err = u.UserStorage.DeleteUser(username); err != nil &&
+		!errors.Is(err, ErrNonExistentUser) {
+		return err
+	}
+	return nil
+}
+
+// GetUser returns User from cache or loads it from db.
+func (u UserDBStorage) GetUser(username string) (*domain.User, error) {
+	if user, err := u.UserStorage.GetUser(username); err == nil {
+		return user, nil
+	}
+	user := domain.NewUser(username)
+	var risk []byte
+	var role string
+	err := u.pool.QueryRow(context.Background(),
+		"SELECT password_hash, public_key, private_key, telegram_id, risk, role FROM users "+
+			"WHERE username = $1", username).Scan(&user.PasswordHash, &user.PublicKey,
+		&user.PrivateKey, &user.TelegramID, &risk, &role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNonExistentUser
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read user from db <%w>", err)
+	}
+	if err = json.Unmarshal(risk, &user.Risk); err != nil {
+		return nil, fmt.Errorf("can't decode risk limits <%w>", err)
+	}
+	user.Role = domain.Role(role)
+	if err = u.UserStorage.AddUser(user); errors.Is(err, ErrExistedUser) {
+		// user was loaded concurrently
+		return u.UserStorage.GetUser(username)
+	}
+	return user, nil
+}
+
+// GetRole returns user's role from db, so granted and revoked roles are applied
+// without the app restart.
+func (u UserDBStorage) GetRole(username string) (domain.Role, error) {
+	var role string
+	err := u.pool.QueryRow(context.Background(),
+		"SELECT role FROM users WHERE username = $1", username).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNonExistentUser
+	}
+	if err != nil {
+		return "", fmt.Errorf("can't read user from db <%w>", err)
+	}
+	return domain.Role(role), nil
+}
+
+// SetKeys edits user's keys in db and cache.
+func (u UserDBStorage) SetKeys(username, public, private string) error {
+	if len(username) == 0 || len(public) == 0 || len(private) == 0 {
+		return ErrIncorrectUserValues
+	}
+	user, err := u.GetUser(username)
+	if err != nil {
+		return err
+	}
+	if user.PublicKey == public && user.PrivateKey == private {
+		return ErrNothingToChange
+	}
+	_, err = u.pool.Exec(context.Background(),
+		"UPDATE users SET public_key = $1, private_key = $2 WHERE username = $3",
+		public, private, username)
+	if err != nil {
+		return fmt.Errorf("can't update user in db <%w>", err)
+	}
+	return u.UserStorage.SetKeys(username, public, private)
+}
+
+// MigrateUser sets password hash and sealed private key of user stored without password
+// in db and cache.
+func (u UserDBStorage) MigrateUser(username, passwordHash, private string) error {
+	if len(passwordHash) == 0 || len(private) == 0 {
+		return ErrIncorrectUserValues
+	}
+	if _, err := u.GetUser(username); err != nil {
+		return err
+	}
+	tag, err := u.pool.Exec(context.Background(),
+		"UPDATE users SET password_hash = $1, private_key = $2 "+
+			"WHERE username = $3 AND password_hash = ''",
+		passwordHash, private, username)
+	if err != nil {
+		return fmt.Errorf("can't update user in db <%w>", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMigratedUser
+	}
+	return u.UserStorage.MigrateUser(username, passwordHash, private)
+}
+
+// SetRisk edits user's RiskLimits in db and cache.
+func (u UserDBStorage) SetRisk(username string, limits domain.RiskLimits) error {
+	if _, err := u.GetUser(username); err != nil {
+		return err
+	}
+	risk, err := json.Marshal(limits)
+	if err != nil {
+		return fmt.Errorf("can't encode risk limits <%w>", err)
+	}
+	_, err = u.pool.Exec(context.Background(),
+		"UPDATE users SET risk = $1 WHERE username = $2", risk, username)
+	if err != nil {
+		return fmt.Errorf("can't update user in db <%w>", err)
+	}
+	return u.UserStorage.SetRisk(username, limits)
+}
+
+// AddSubscription stores user's pair Config in db and cache.
+func (u UserDBStorage) AddSubscription(username string, config domain.Config) error {
+	if _, err := u.GetUser(username); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("can't encode subscription <%w>", err)
+	}
+	_, err = u.pool.Exec(context.Background(),
+		"INSERT INTO subscriptions(username, pair_name, pair_interval, config) "+
+			"VALUES($1, $2, $3, $4) ON CONFLICT (username, pair_name, pair_interval) "+
+			"DO UPDATE SET config = EXCLUDED.config",
+		username, config.PairName, string(config.PairInterval), encoded)
+	if err != nil {
+		return fmt.Errorf("can't add subscription to db <%w>", err)
+	}
+	return u.UserStorage.AddSubscription(username, config)
+}
+
+// DeleteSubscription deletes user's pair Config from db and cache.
+func (u UserDBStorage) DeleteSubscription(username string, config domain.Config) error {
+	_, err := u.pool.Exec(context.Background(),
+		"DELETE FROM subscriptions WHERE username = $1 AND pair_name = $2 AND pair_interval = $3",
+		username, config.PairName, string(config.PairInterval))
+	if err != nil {
+		return fmt.Errorf("can't delete subscription from db <%w>", err)
+	}
+	return u.UserStorage.DeleteSubscription(username, config)
+}
+
+// GetSubscriptions returns all subscriptions stored in db.
+func (u UserDBStorage) GetSubscriptions() ([]domain.Subscription, error) {
+	rows, err := u.pool.Query(context.Background(),
+		"SELECT username, config FROM subscriptions ORDER BY username, pair_name, pair_interval")
+	if err != nil {
+		return nil, fmt.Errorf("can't read subscriptions from db <%w>", err)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]domain.Subscription, 0)
+	for rows.Next() {
+		var subscription domain.Subscription
+		var encoded []byte
+		if err = rows.Scan(&subscription.Username, &encoded); err != nil {
+			return nil, fmt.Errorf("can't read subscription from db <%w>", err)
+		}
+		if err = json.Unmarshal(encoded, &subscription.Config); err != nil {
+			return nil, fmt.Errorf("can't decode subscription <%w>", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("buf db error <%w>", err)
+	}
+	return subscriptions, nil
+}
+
+// GenerateJWT loads user from db if it's necessary and generates JWT token.
+func (u UserDBStorage) GenerateJWT(user domain.User) (string, error) {
+	if _, err := u.GetUser(user.Username); err != nil {
+		return "", err
+	}
+	return u.UserStorage.GenerateJWT(user)
+}
+
+// Shutdown closes db connection.
+func (u UserDBStorage) Shutdown() {
+	if u.pool != nil {
+		u.pool.Close()
+	}
+}
@@ -0,0 +1,112 @@
+package users
+
+import (
+	"os"
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDBName is env variable with port of test postgres, db tests are skipped without it.
+const testDBName = "TEST_DB_PORT"
+
+// newCachedStorage returns UserDBStorage without db, which serves cached users only.
+func newCachedStorage(t *testing.T, cached ...*domain.User) UserDBStorage {
+	cache, err := NewUserStorage("key", 1)
+	assert.NoError(t, err)
+	for _, user := range cached {
+		assert.NoError(t, cache.AddUser(user))
+	}
+	return UserDBStorage{UserStorage: cache}
+}
+
+// newTestDBStorage returns UserDBStorage connected to docker-compose postgres on TEST_DB_PORT.
+func newTestDBStorage(t *testing.T) *UserDBStorage {
+	port := os.Getenv(testDBName)
+	if len(port) == 0 {
+		t.Skipf("%s isn't set", testDBName)
+	}
+	storage, err := NewUserDBStorage(repository.ConnectionConfig{
+		Username: "user",
+		Password: "passwd",
+		NameDB:   "fintech",
+		Port:     port,
+	}, "key", 1)
+	if err != nil {
+		t.Fatalf("can't connect test db <%s>", err)
+	}
+	t.Cleanup(storage.Shutdown)
+	return storage
+}
+
+func TestUserDBStorage_GetUserCached(t *testing.T) {
+	cached := &domain.User{Username: "name", PublicKey: "public", PrivateKey: "private"}
+	storage := newCachedStorage(t, cached)
+	// cached user is returned without db query
+	loaded, err := storage.GetUser(cached.Username)
+	assert.NoError(t, err)
+	assert.Same(t, cached, loaded)
+}
+
+func TestUserDBStorage_SetKeysCached(t *testing.T) {
+	cached := &domain.User{Username: "name", PublicKey: "public", PrivateKey: "private"}
+	storage := newCachedStorage(t, cached)
+	assert.ErrorIs(t, storage.SetKeys("", "public", "private"), ErrIncorrectUserValues)
+	assert.ErrorIs(t, storage.SetKeys(cached.Username, "public", ""), ErrIncorrectUserValues)
+	assert.ErrorIs(t, storage.SetKeys(cached.Username, "public", "private"), ErrNothingToChange)
+	assert.ErrorIs(t, storage.AddUser(&domain.User{Username: "name"}), ErrIncorrectUserValues)
+}
+
+func TestUserDBStorage_CRUD(t *testing.T) {
+	storage := newTestDBStorage(t)
+	username := "user-db-storage-test"
+	_ = storage.DeleteUser(username)
+
+	user := domain.NewUser(username)
+	user.PasswordHash, user.PublicKey, user.PrivateKey, user.TelegramID =
+		"hash", "public", "private", 1
+	assert.NoError(t, storage.AddUser(user))
+	assert.ErrorIs(t, storage.AddUser(&domain.User{Username: username,
+		PublicKey: "public", PrivateKey: "private"}), ErrExistedUser)
+
+	// the other storage loads user from db
+	other := newTestDBStorage(t)
+	loaded, err := other.GetUser(username)
+	assert.NoError(t, err)
+	assert.Equal(t, loaded.PasswordHash, "hash")
+	assert.Equal(t, loaded.PublicKey, "public")
+	assert.Equal(t, loaded.TelegramID, int64(1))
+	assert.Equal(t, loaded.Role, domain.UserRole)
+	role, err := other.GetRole(username)
+	assert.NoError(t, err)
+	assert.Equal(t, role, domain.UserRole)
+	_, err = other.GetRole("not a user")
+	assert.ErrorIs(t, err, ErrNonExistentUser)
+
+	assert.NoError(t, other.SetKeys(username, "other public", "other private"))
+	limits := domain.RiskLimits{MaxDailyLoss: 10}
+	assert.NoError(t, other.SetRisk(username, limits))
+	config := domain.Config{PairName: "PI_XBTUSD", PairInterval: domain.Candle1m,
+		IndicatorName: "donchian"}
+	assert.NoError(t, other.AddSubscription(username, config))
+
+	reloaded, err := newTestDBStorage(t).GetUser(username)
+	assert.NoError(t, err)
+	assert.Equal(t, reloaded.PublicKey, "other public")
+	assert.Equal(t, reloaded.PrivateKey, "other private")
+	assert.Equal(t, reloaded.Risk, limits)
+	subscriptions, err := storage.GetSubscriptions()
+	assert.NoError(t, err)
+	assert.Contains(t, subscriptions, domain.Subscription{Username: username, Config: config})
+
+	assert.NoError(t, other.DeleteSubscription(username, config))
+	subscriptions, err = storage.GetSubscriptions()
+	assert.NoError(t, err)
+	assert.NotContains(t, subscriptions, domain.Subscription{Username: username, Config: config})
+	assert.NoError(t, storage.DeleteUser(username))
+	assert.ErrorIs(t, storage.DeleteUser(username), ErrNonExistentUser)
+	_, err = newTestDBStorage(t).GetUser(username)
+	assert.ErrorIs(t, err, ErrNonExistentUser)
+}
@@ -3,6 +3,7 @@ package users
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,14 +16,22 @@ var (
 	ErrNonExistentUser     = errors.New("the user does not exist")
 	ErrIncorrectUserValues = errors.New("the user's id, public key or private key is empty")
 	ErrNothingToChange     = errors.New("nothing to change or already changed")
+	ErrMigratedUser        = errors.New("the user already has password")
 )
 
 // UserStorage implements UserRepository.
 type UserStorage struct {
-	muUsers  *sync.RWMutex
-	users    map[string]*domain.User
-	signKey  string
-	ttlHours int64
+	muUsers       *sync.RWMutex
+	users         map[string]*domain.User
+	subscriptions map[string]map[subscriptionKey]domain.Config
+	signKey       string
+	ttlHours      int64
+}
+
+// subscriptionKey identifies user's pair subscription.
+type subscriptionKey struct {
+	pairName string
+	interval domain.CandleInterval
 }
 
 // NewUserStorage returns pointer to UserStorage.
@@ -34,9 +43,10 @@ func NewUserStorage(signKey string, ttlHours int64) (*UserStorage, error) {
 		return nil, fmt.Errorf("ttl hourse should be more than zero")
 	}
 	storage := &UserStorage{users: make(map[string]*domain.User),
-		muUsers:  &sync.RWMutex{},
-		signKey:  signKey,
-		ttlHours: ttlHours}
+		subscriptions: make(map[string]map[subscriptionKey]domain.Config),
+		muUsers:       &sync.RWMutex{},
+		signKey:       signKey,
+		ttlHours:      ttlHours}
 	return storage, nil
 }
 
@@ -68,6 +78,7 @@ func (u UserStorage) DeleteUser(username string) error {
 		return ErrNonExistentUser
 	}
 	delete(u.users, username)
+	delete(u.subscriptions, username)
 	return nil
 }
 
@@ -82,6 +93,17 @@ func (u UserStorage) GetUser(username string) (*domain.User, error) {
 	return user, nil
 }
 
+// GetRole returns user's role.
+func (u UserStorage) GetRole(username string) (domain.Role, error) {
+	u.muUsers.RLock()
+	defer u.muUsers.RUnlock()
+	user, ok := u.users[username]
+	if !ok {
+		return "", ErrNonExistentUser
+	}
+	return user.Role, nil
+}
+
 // SetKeys edits user's keys.
 func (u UserStorage) SetKeys(username, public, private string) error {
 	if len(username) == 0 || len(public) == 0 || len(private) == 0 {
@@ -99,15 +121,91 @@ func (u UserStorage) SetKeys(username, public, private string) error {
 	return nil
 }
 
-// GenerateJWT generates JWT token.
+// MigrateUser sets password hash and sealed private key of user stored without password.
+func (u UserStorage) MigrateUser(username, passwordHash, private string) error {
+	if len(passwordHash) == 0 || len(private) == 0 {
+		return ErrIncorrectUserValues
+	}
+	user, err := u.GetUser(username)
+	if err != nil {
+		return err
+	}
+	u.muUsers.Lock()
+	defer u.muUsers.Unlock()
+	if len(user.PasswordHash) != 0 {
+		return ErrMigratedUser
+	}
+	user.PasswordHash = passwordHash
+	user.PrivateKey = private
+	return nil
+}
+
+// SetRisk edits user's RiskLimits.
+func (u UserStorage) SetRisk(username string, limits domain.RiskLimits) error {
+	user, err := u.GetUser(username)
+	if err != nil {
+		return err
+	}
+	user.SetRisk(limits)
+	return nil
+}
+
+// AddSubscription stores user's pair Config.
+func (u UserStorage) AddSubscription(username string, config domain.Config) error {
+	u.muUsers.Lock()
+	defer u.muUsers.Unlock()
+	if _, ok := u.users[username]; !ok {
+		return ErrNonExistentUser
+	}
+	if _, ok := u.subscriptions[username]; !ok {
+		u.subscriptions[username] = make(map[subscriptionKey]domain.Config)
+	}
+	u.subscriptions[username][subscriptionKey{config.PairName, config.PairInterval}] = config
+	return nil
+}
+
+// DeleteSubscription deletes user's pair Config.
+func (u UserStorage) DeleteSubscription(username string, config domain.Config) error {
+	u.muUsers.Lock()
+	defer u.muUsers.Unlock()
+	delete(u.subscriptions[username], subscriptionKey{config.PairName, config.PairInterval})
+	return nil
+}
+
+// GetSubscriptions returns all stored subscriptions sorted by username and pair.
+func (u UserStorage) GetSubscriptions() ([]domain.Subscription, error) {
+	u.muUsers.RLock()
+	defer u.muUsers.RUnlock()
+	subscriptions := make([]domain.Subscription, 0)
+	for username, configs := range u.subscriptions {
+		for _, config := range configs {
+			subscriptions = append(subscriptions, domain.Subscription{
+				Username: username,
+				Config:   config,
+			})
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		first, second := subscriptions[i], subscriptions[j]
+		if first.Username != second.Username {
+			return first.Username < second.Username
+		}
+		if first.Config.PairName != second.Config.PairName {
+			return first.Config.PairName < second.Config.PairName
+		}
+		return first.Config.PairInterval < second.Config.PairInterval
+	})
+	return subscriptions, nil
+}
+
+// GenerateJWT checks user's password and generates JWT token.
 func (u UserStorage) GenerateJWT(user domain.User) (string, error) {
 	storageUser, err := u.GetUser(user.Username)
 	if err != nil {
 		return "", err
 	}
-	if storageUser.PublicKey != user.PublicKey ||
-		storageUser.PrivateKey != user.PrivateKey {
-		return "", fmt.Errorf("incorrect public or private key")
+	if err = storageUser.CheckPassword(user.Password); err != nil {
+		return "", err
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &userClaims{
@@ -69,6 +69,19 @@ func TestUserStorage_GetUser(t *testing.T) {
 	assert.Nil(t, storageUser)
 }
 
+func TestUserStorage_GetRole(t *testing.T) {
+	storage, err := NewUserStorage("key", 1)
+	assert.NoError(t, err)
+	admin := domain.NewUser("admin")
+	admin.PublicKey, admin.PrivateKey, admin.Role = "0", "0", domain.AdminRole
+	_ = storage.AddUser(admin)
+	role, err := storage.GetRole(admin.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, role, domain.AdminRole)
+	_, err = storage.GetRole("not a user")
+	assert.ErrorIs(t, err, ErrNonExistentUser)
+}
+
 func TestUserStorage_SetKeys(t *testing.T) {
 	storage, err := NewUserStorage("key", 1)
 	assert.NoError(t, err)
@@ -85,9 +98,43 @@ func TestUserStorage_SetKeys(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNonExistentUser)
 }
 
+func TestUserStorage_MigrateUser(t *testing.T) {
+	storage, err := NewUserStorage("key", 1)
+	assert.NoError(t, err)
+	legacy := &domain.User{Username: "legacy", PublicKey: "public", PrivateKey: "plain"}
+	assert.ErrorIs(t, storage.MigrateUser(legacy.Username, "hash", "sealed"), ErrNonExistentUser)
+	assert.NoError(t, storage.AddUser(legacy))
+	assert.ErrorIs(t, storage.MigrateUser(legacy.Username, "", "sealed"), ErrIncorrectUserValues)
+	assert.NoError(t, storage.MigrateUser(legacy.Username, "hash", "sealed"))
+	assert.Equal(t, legacy.PasswordHash, "hash")
+	assert.Equal(t, legacy.PrivateKey, "sealed")
+	assert.ErrorIs(t, storage.MigrateUser(legacy.Username, "other", "sealed"), ErrMigratedUser)
+}
+
+func TestUserStorage_SetRisk(t *testing.T) {
+	storage, err := NewUserStorage("key", 1)
+	assert.NoError(t, err)
+	user := domain.NewUser("name")
+	user.PublicKey, user.PrivateKey = "0", "0"
+	limits := domain.RiskLimits{StopLoss: 0.1}
+	assert.ErrorIs(t, storage.SetRisk(user.Username, limits), ErrNonExistentUser)
+	_ = storage.AddUser(user)
+	assert.NoError(t, storage.SetRisk(user.Username, limits))
+	storageUser, err := storage.GetUser(user.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, storageUser.GetRisk(), limits)
+}
+
 func TestUserStorage_GenerateJWT(t *testing.T) {
 	storage, err := NewUserStorage("key", 1)
 	assert.NoError(t, err)
+	user := &domain.User{
+		Username:   "name",
+		Password:   "password",
+		PublicKey:  "0",
+		PrivateKey: "0",
+	}
+	assert.NoError(t, user.HashPassword())
 	_ = storage.AddUser(user)
 	jwt, err := storage.GenerateJWT(domain.User{Username: "x"})
 	assert.Error(t, err)
@@ -97,9 +144,45 @@ func TestUserStorage_GenerateJWT(t *testing.T) {
 	assert.Error(t, err)
 	assert.Empty(t, jwt)
 
-	jwt, err = storage.GenerateJWT(*user)
+	jwt, err = storage.GenerateJWT(domain.User{Username: user.Username,
+		PublicKey: user.PublicKey, PrivateKey: user.PrivateKey})
+	assert.ErrorIs(t, err, domain.ErrIncorrectPassword)
+	assert.Empty(t, jwt)
+
+	jwt, err = storage.GenerateJWT(domain.User{Username: user.Username, Password: "password"})
 	assert.NoError(t, err)
 	username, err := storage.ParseToken(jwt)
 	assert.NoError(t, err)
 	assert.Equal(t, username, user.Username)
 }
+
+func TestUserStorage_Subscriptions(t *testing.T) {
+	storage, err := NewUserStorage("key", 1)
+	assert.NoError(t, err)
+	config := domain.Config{PairName: "b", PairInterval: domain.Candle1m}
+	err = storage.AddSubscription(user.Username, config)
+	assert.ErrorIs(t, err, ErrNonExistentUser)
+
+	_ = storage.AddUser(user)
+	assert.NoError(t, storage.AddSubscription(user.Username, config))
+	otherConfig := domain.Config{PairName: "a", PairInterval: domain.Candle1m}
+	assert.NoError(t, storage.AddSubscription(user.Username, otherConfig))
+	config.Limit = 0.5
+	assert.NoError(t, storage.AddSubscription(user.Username, config))
+	subscriptions, err := storage.GetSubscriptions()
+	assert.NoError(t, err)
+	assert.Equal(t, subscriptions, []domain.Subscription{
+		{Username: user.Username, Config: otherConfig},
+		{Username: user.Username, Config: config},
+	})
+
+	assert.NoError(t, storage.DeleteSubscription(user.Username, otherConfig))
+	subscriptions, err = storage.GetSubscriptions()
+	assert.NoError(t, err)
+	assert.Equal(t, len(subscriptions), 1)
+
+	assert.NoError(t, storage.DeleteUser(user.Username))
+	subscriptions, err = storage.GetSubscriptions()
+	assert.NoError(t, err)
+	assert.Empty(t, subscriptions)
+}
@@ -0,0 +1,139 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// KeySize is a length of master and data keys in bytes (AES-256).
+const KeySize = 32
+
+var (
+	ErrInvalidMasterKey = errors.New("master key should be base64 of 32 bytes")
+	ErrMalformedSecret  = errors.New("sealed secret is malformed")
+)
+
+// Keeper seals secrets with envelope encryption: every secret is encrypted
+// by its own data key, which is encrypted by the master key.
+type Keeper struct {
+	master cipher.AEAD
+}
+
+// NewKeeper returns pointer to Keeper by base64 encoded master key.
+func NewKeeper(masterKey string) (*Keeper, error) {
+	key, err := base64.StdEncoding.DecodeString(masterKey)
+	if err != nil || len(key) != KeySize {
+		return nil, ErrInvalidMasterKey
+	}
+	master, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Keeper{master: master}, nil
+}
+
+// GenerateMasterKey returns new random base64 encoded master key.
+func GenerateMasterKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", fmt.Errorf("can't generate master key <%w>", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Seal encrypts secret and returns it with encrypted data key.
+func (keeper Keeper) Seal(secret []byte) (string, error) {
+	dataKey := make([]byte, KeySize)
+	defer Wipe(dataKey)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("can't generate data key <%w>", err)
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(keeper.master, dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(data, secret)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(wrappedKey) + "." +
+		base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts sealed secret, caller should Wipe it after usage.
+func (keeper Keeper) Open(sealed string) ([]byte, error) {
+	parts := strings.Split(sealed, ".")
+	if len(parts) != 2 {
+		return nil, ErrMalformedSecret
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformedSecret
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedSecret
+	}
+	dataKey, err := open(keeper.master, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer Wipe(dataKey)
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, ciphertext)
+}
+
+// Wipe overwrites secret with zeros.
+func Wipe(secret []byte) {
+	for i := range secret {
+		secret[i] = 0
+	}
+}
+
+// newAEAD returns AES-GCM by key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("can't create cipher <%w>", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("can't create gcm <%w>", err)
+	}
+	return aead, nil
+}
+
+// seal encrypts plaintext and prepends random nonce.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("can't generate nonce <%w>", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts ciphertext with prepended nonce.
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrMalformedSecret
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't decrypt secret <%w>", ErrMalformedSecret)
+	}
+	return plaintext, nil
+}
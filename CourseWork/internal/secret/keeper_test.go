@@ -0,0 +1,57 @@
+package secret
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKeeper(t *testing.T) {
+	keeper, err := NewKeeper("short")
+	assert.Nil(t, keeper)
+	assert.ErrorIs(t, err, ErrInvalidMasterKey)
+
+	keeper, err = NewKeeper(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Nil(t, keeper)
+	assert.ErrorIs(t, err, ErrInvalidMasterKey)
+
+	masterKey, err := GenerateMasterKey()
+	assert.NoError(t, err)
+	keeper, err = NewKeeper(masterKey)
+	assert.NoError(t, err)
+	assert.NotNil(t, keeper)
+}
+
+func TestKeeper_SealOpen(t *testing.T) {
+	masterKey, err := GenerateMasterKey()
+	assert.NoError(t, err)
+	keeper, err := NewKeeper(masterKey)
+	assert.NoError(t, err)
+
+	sealed, err := keeper.Seal([]byte("private"))
+	assert.NoError(t, err)
+	assert.NotContains(t, sealed, base64.StdEncoding.EncodeToString([]byte("private")))
+	otherSealed, err := keeper.Seal([]byte("private"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, sealed, otherSealed)
+
+	secret, err := keeper.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, string(secret), "private")
+	Wipe(secret)
+	assert.Equal(t, secret, make([]byte, len("private")))
+
+	otherMasterKey, err := GenerateMasterKey()
+	assert.NoError(t, err)
+	otherKeeper, err := NewKeeper(otherMasterKey)
+	assert.NoError(t, err)
+	_, err = otherKeeper.Open(sealed)
+	assert.ErrorIs(t, err, ErrMalformedSecret)
+
+	for _, malformed := range []string{"", "x", "x.y", strings.Replace(sealed, ".", "", 1)} {
+		_, err = keeper.Open(malformed)
+		assert.ErrorIs(t, err, ErrMalformedSecret)
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+var ErrPairNotFound = errors.New("pair is not running")
+
+// PairsInfo returns state of all running pairs sorted by name and interval.
+func (trader AlgoTrader) PairsInfo() []domain.PairInfo {
+	trader.muPairs.Lock()
+	defer trader.muPairs.Unlock()
+	infos := make([]domain.PairInfo, 0)
+	for _, intervals := range trader.Pairs {
+		for _, pair := range intervals {
+			infos = append(infos, pair.Info())
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Name != infos[j].Name {
+			return infos[i].Name < infos[j].Name
+		}
+		first, _ := infos[i].Interval.Duration()
+		second, _ := infos[j].Interval.Duration()
+		return first < second
+	})
+	return infos
+}
+
+// PausePair skips entering order events of running pair, its candles are still read,
+// so indicators stay warm, open positions are still marked and closed.
+func (trader AlgoTrader) PausePair(pairName string, interval domain.CandleInterval) error {
+	trader.muPairs.Lock()
+	defer trader.muPairs.Unlock()
+	pair, ok := trader.Pairs[pairName][interval]
+	if !ok {
+		return ErrPairNotFound
+	}
+	pair.Pause()
+	trader.log.Printf("ADMIN: pair <%s> <%s> is paused", pair.Name, pair.Interval)
+	return nil
+}
+
+// ResumePair restores order events of paused pair, indicators are synced with Ledger positions.
+func (trader AlgoTrader) ResumePair(pairName string, interval domain.CandleInterval) error {
+	trader.muPairs.Lock()
+	defer trader.muPairs.Unlock()
+	pair, ok := trader.Pairs[pairName][interval]
+	if !ok {
+		return ErrPairNotFound
+	}
+	pair.Resume(func(username string) bool {
+		position, _ := trader.Ledger.Position(username, pair.Name)
+		return position.Size == 0
+	})
+	trader.log.Printf("ADMIN: pair <%s> <%s> is resumed", pair.Name, pair.Interval)
+	return nil
+}
+
+// StopPair stops running pair with all its subscribers. Unlike stopped by error pair,
+// subscriptions are deleted, so pair isn't restored after restart.
+func (trader AlgoTrader) StopPair(pairName string, interval domain.CandleInterval) error {
+	trader.muPairs.Lock()
+	defer trader.muPairs.Unlock()
+	defer trader.observePairs()
+	pair, ok := trader.Pairs[pairName][interval]
+	if !ok {
+		return ErrPairNotFound
+	}
+	for _, user := range pair.Users {
+		trader.deleteSubscription(pair, user.Username)
+	}
+	// pair could wait for the trader's loop to send the last candle
+	go pair.Stop(trader.wg)
+	trader.deletePair(pair)
+	trader.log.Printf("ADMIN: pair <%s> <%s> is stopped", pair.Name, pair.Interval)
+	trader.MessageWriters.WriteErrorsToAll(fmt.Sprintf("pair <%s> <%s> is stopped by admin",
+		pair.Name, pair.Interval), pair.Users)
+	return nil
+}
+
+// RemoveUser unsubscribes user from all running pairs and stops pairs left without users.
+func (trader AlgoTrader) RemoveUser(username string) error {
+	trader.muPairs.Lock()
+	defer trader.muPairs.Unlock()
+	defer trader.observePairs()
+	user, ok := trader.Pairs.User(username)
+	if !ok {
+		return ErrUserIsNotLogged
+	}
+	for _, intervals := range trader.Pairs {
+		for _, pair := range intervals {
+			if !pair.IsUserLogged(user) {
+				continue
+			}
+			trader.deleteSubscription(pair, username)
+			if err := pair.DeleteUser(user); err != nil {
+				return fmt.Errorf("can't remove user from pair <%w>", err)
+			}
+			if len(pair.Users) == 0 {
+				go pair.Stop(trader.wg)
+				trader.deletePair(pair)
+			}
+		}
+	}
+	trader.log.Printf("ADMIN: user <%s> is removed from all pairs", username)
+	trader.MessageWriters.WriteErrorsToAll("you are removed from all pairs by admin",
+		[]*domain.User{user})
+	return nil
+}
+
+// deleteSubscription deletes stored subscription of user on pair, muPairs should be locked.
+func (trader AlgoTrader) deleteSubscription(pair *Pair, username string) {
+	config, ok := pair.Config(username)
+	if !ok {
+		return
+	}
+	if err := trader.Users.DeleteSubscription(username, config); err != nil {
+		trader.log.Printf("DB: subscription is not deleted <%s>", err)
+	}
+}
+
+// deletePair deletes pair from Pairs, muPairs should be locked.
+func (trader AlgoTrader) deletePair(pair *Pair) {
+	delete(trader.Pairs[pair.Name], pair.Interval)
+	trader.log.Printf("DELETE: pair <%s> <%s> was deleted by admin", pair.Name, pair.Interval)
+	if len(trader.Pairs[pair.Name]) == 0 {
+		delete(trader.Pairs, pair.Name)
+		trader.log.Printf("DELETE: pair <%s> was deleted entirely", pair.Name)
+	}
+}
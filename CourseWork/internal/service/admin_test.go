@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	mock_service "github.com/agandreev/tfs-go-hw/CourseWork/internal/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// addAdminPair adds stoppable pair made by options to trader's running pairs.
+func addAdminPair(t *testing.T, trader *AlgoTrader, options ...pairOption) *Pair {
+	pair := makePair(append([]pairOption{withLog(trader.log), withStopped()}, options...)...)
+	assert.NoError(t, trader.Pairs.AddPair(pair))
+	trader.wg.Add(1)
+	return pair
+}
+
+func TestAlgoTrader_PairsInfo(t *testing.T) {
+	trader := setupTrader()
+	first, second := domain.NewUser("first"), domain.NewUser("second")
+	addAdminPair(t, trader, withName("b"), withInterval(domain.Candle5m), withUsers(t, config, first))
+	addAdminPair(t, trader, withName("b"), withUsers(t, config, second, first))
+	addAdminPair(t, trader, withName("a"), withInterval(domain.Candle10m), withUsers(t, config, first))
+
+	infos := trader.PairsInfo()
+	assert.Len(t, infos, 3)
+	assert.Equal(t, infos[0].Name, "a")
+	assert.Equal(t, infos[1].Interval, domain.Candle1m)
+	assert.Equal(t, infos[2].Interval, domain.Candle5m)
+	assert.Len(t, infos[1].Subscribers, 2)
+	assert.Equal(t, infos[1].Subscribers[0].Username, first.Username)
+	assert.Equal(t, infos[1].Subscribers[0].Indicator, DonchianName)
+	assert.Equal(t, infos[1].SocketStatus, domain.UnknownSocket)
+}
+
+func TestAlgoTrader_PausePair(t *testing.T) {
+	trader := setupTrader()
+	flat, long := domain.NewUser("flat"), domain.NewUser("long")
+	pair := addAdminPair(t, trader, withName("name"), withUsers(t, config, flat, long))
+	_, err := trader.Ledger.Fill(domain.OrderInfo{Username: long.Username, Name: "name",
+		Side: string(domain.Buy), Price: 10, Amount: 1})
+	assert.NoError(t, err)
+
+	assert.NoError(t, trader.PausePair("name", domain.Candle1m))
+	assert.True(t, pair.IsPaused())
+	assert.True(t, trader.PairsInfo()[0].Paused)
+	// both indicators entered long position while pair was paused
+	for _, indicator := range pair.Indicators {
+		indicator.(*domain.Donchian).Machine.State = domain.LongState
+	}
+	assert.NoError(t, trader.ResumePair("name", domain.Candle1m))
+	assert.False(t, pair.IsPaused())
+	assert.Equal(t, domain.FlatState, pair.Indicators[flat.Username].(*domain.Donchian).Machine.State)
+	assert.Equal(t, domain.LongState, pair.Indicators[long.Username].(*domain.Donchian).Machine.State)
+
+	assert.ErrorIs(t, trader.PausePair("name", domain.Candle5m), ErrPairNotFound)
+	assert.ErrorIs(t, trader.ResumePair("other", domain.Candle1m), ErrPairNotFound)
+}
+
+func TestAlgoTrader_StopPair(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockUserRepository(c)
+	trader := setupTrader()
+	trader.Users = repo
+	first, second := domain.NewUser("first"), domain.NewUser("second")
+	addAdminPair(t, trader, withName("name"), withUsers(t, config, first, second))
+	addAdminPair(t, trader, withName("name"), withInterval(domain.Candle5m), withUsers(t, config, first))
+
+	repo.EXPECT().DeleteSubscription(first.Username, gomock.Any()).Return(nil)
+	repo.EXPECT().DeleteSubscription(second.Username, gomock.Any()).Return(nil)
+	assert.NoError(t, trader.StopPair("name", domain.Candle1m))
+	assert.False(t, trader.Pairs.IsExist("name", domain.Candle1m))
+	assert.True(t, trader.Pairs.IsExist("name", domain.Candle5m))
+
+	repo.EXPECT().DeleteSubscription(first.Username, gomock.Any()).Return(nil)
+	assert.NoError(t, trader.StopPair("name", domain.Candle5m))
+	assert.Empty(t, trader.Pairs)
+	assert.ErrorIs(t, trader.StopPair("name", domain.Candle5m), ErrPairNotFound)
+	trader.wg.Wait()
+}
+
+func TestAlgoTrader_RemoveUser(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockUserRepository(c)
+	trader := setupTrader()
+	trader.Users = repo
+	first, second := domain.NewUser("first"), domain.NewUser("second")
+	shared := addAdminPair(t, trader, withName("shared"), withUsers(t, config, first, second))
+	addAdminPair(t, trader, withName("own"), withUsers(t, config, first))
+	addAdminPair(t, trader, withName("other"), withUsers(t, config, second))
+
+	repo.EXPECT().DeleteSubscription(first.Username, domain.Config{PairName: "shared",
+		PairInterval: domain.Candle1m, IndicatorName: DonchianName}).Return(nil)
+	repo.EXPECT().DeleteSubscription(first.Username, domain.Config{PairName: "own",
+		PairInterval: domain.Candle1m, IndicatorName: DonchianName}).Return(nil)
+	assert.NoError(t, trader.RemoveUser(first.Username))
+	assert.False(t, shared.IsUserLogged(first))
+	assert.True(t, shared.IsUserLogged(second))
+	assert.False(t, trader.Pairs.IsExist("own", domain.Candle1m))
+	assert.True(t, trader.Pairs.IsExist("other", domain.Candle1m))
+
+	assert.ErrorIs(t, trader.RemoveUser(first.Username), ErrUserIsNotLogged)
+}
@@ -1,11 +1,15 @@
 package service
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/metrics"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository/users"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/secret"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,14 +20,36 @@ type UserRepository interface {
 	AddUser(user *domain.User) error
 	DeleteUser(string) error
 	GetUser(string) (*domain.User, error)
+	GetRole(string) (domain.Role, error)
 	SetKeys(string, string, string) error
+	MigrateUser(string, string, string) error
+	SetRisk(string, domain.RiskLimits) error
 	GenerateJWT(domain.User) (string, error)
 	ParseToken(string) (string, error)
+	AddSubscription(string, domain.Config) error
+	DeleteSubscription(string, domain.Config) error
+	GetSubscriptions() ([]domain.Subscription, error)
 }
 
 type OrderRepository interface {
 	AddOrder(domain.OrderInfo) error
-	GetOrders(int64) ([]domain.OrderInfo, error)
+	GetOrders(domain.OrderFilter) (domain.OrderPage, error)
+	MarkMismatch(string, string, string) error
+	SetFill(string, string, int64, float64) error
+	Connect() error
+	Shutdown()
+}
+
+type CandleRepository interface {
+	AddCandle(string, domain.Candle) error
+	GetCandles(domain.CandleFilter) ([]domain.Candle, error)
+	Connect() error
+	Shutdown()
+}
+
+type AuditRepository interface {
+	AddEvent(domain.AuditEvent) error
+	GetEvents(domain.AuditFilter) ([]domain.AuditEvent, error)
 	Connect() error
 	Shutdown()
 }
@@ -36,6 +62,29 @@ type MessageWriter interface {
 
 type StockMarketAPI interface {
 	AddOrder(domain.StockMarketEvent, *domain.User) (domain.OrderInfo, error)
+	CancelOrder(string, *domain.User) error
+	OpenOrders(*domain.User) ([]domain.OpenOrder, error)
+}
+
+// OrderRestorer is implemented by simulated StockMarketAPI, which keeps accounts in memory.
+type OrderRestorer interface {
+	Restore(domain.OrderInfo) error
+}
+
+// Endpoints consists of stock market's socket, order and charts urls.
+type Endpoints struct {
+	SocketURL string
+	OrderURL  string
+	ChartsURL string
+}
+
+// DefaultEndpoints returns Kraken Futures urls.
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		SocketURL: DefaultSocketURL,
+		OrderURL:  DefaultOrderURL,
+		ChartsURL: DefaultChartsURL,
+	}
 }
 
 // AlgoTrader describes trading service.
@@ -43,44 +92,90 @@ type AlgoTrader struct {
 	Users             UserRepository
 	Pairs             Pairs
 	Orders            OrderRepository
+	Candles           CandleRepository
+	Audit             AuditRepository
 	muPairs           *sync.Mutex
 	API               StockMarketAPI
+	Paper             StockMarketAPI
 	MessageWriters    MessageWriters
+	keeper            *secret.Keeper
 	wg                *sync.WaitGroup
 	reconnectionTimes int64
+	backoff           Backoff
+	socket            StockMarketSocket
+	history           CandleHistory
+	endpoints         Endpoints
+	Ledger            *Ledger
+	Modes             *TradingModes
+	Risk              *RiskManager
+	Allocator         *Allocator
+	Reconciler        *Reconciler
+	Auditor           *Auditor
 	signals           chan domain.StockMarketEvent
+	ticks             chan PairTick
 	errors            chan PairError
 	stop              chan struct{}
 	log               *logrus.Logger
 }
 
 // NewAlgoTrader returns pointer to AlgoTrader structure.
-func NewAlgoTrader(users UserRepository, orders OrderRepository,
-	log *logrus.Logger, reconnections int64) *AlgoTrader {
+// Backfill and warm-up read stored candles first and charts otherwise.
+// Stages of trading decisions are appended to audit.
+func NewAlgoTrader(users UserRepository, orders OrderRepository, candles CandleRepository,
+	audit AuditRepository, endpoints Endpoints, keeper *secret.Keeper, log *logrus.Logger,
+	reconnections int64) *AlgoTrader {
+	api := NewKrakenAPI(endpoints.OrderURL, keeper)
+	history := NewStoredHistory(candles, NewKrakenCharts(endpoints.ChartsURL))
+	socket := NewKrakenSocket(endpoints, reconnections, log)
+	socket.History = history
 	algoTrader := &AlgoTrader{
 		Users:             users,
 		Pairs:             make(Pairs),
 		Orders:            orders,
-		API:               NewKrakenAPI(),
+		Candles:           candles,
+		Audit:             audit,
+		Auditor:           NewAuditor(audit, log),
+		API:               api,
+		Paper:             NewPaperExchange(DefaultPaperCash),
 		MessageWriters:    *NewMessageWriters(log),
+		keeper:            keeper,
 		wg:                &sync.WaitGroup{},
 		reconnectionTimes: reconnections,
+		backoff:           DefaultBackoff(),
+		socket:            socket,
+		history:           history,
+		endpoints:         endpoints,
 		muPairs:           &sync.Mutex{},
+		Ledger:            NewLedger(),
+		Modes:             NewTradingModes(),
+		Risk:              NewRiskManager(),
 		signals:           make(chan domain.StockMarketEvent),
+		ticks:             make(chan PairTick),
 		errors:            make(chan PairError),
 		stop:              make(chan struct{}),
 		log:               log,
 	}
+	algoTrader.Allocator = NewAllocator(algoTrader.Ledger)
+	algoTrader.Reconciler = NewReconciler(users, orders, api, algoTrader.Ledger,
+		algoTrader.Modes, &algoTrader.MessageWriters, log)
 	return algoTrader
 }
 
 // Run runs infinite loop, which processes signal reading for order creating,
-// and reads errors to reconnect sockets.
+// and reads errors to reconnect sockets. Reconciliation is run periodically.
 func (trader AlgoTrader) Run() error {
 	err := trader.Orders.Connect()
 	if err != nil {
 		return fmt.Errorf("error while try to run <%w>", err)
 	}
+	if err = trader.Candles.Connect(); err != nil {
+		return fmt.Errorf("error while try to run <%w>", err)
+	}
+	if err = trader.Audit.Connect(); err != nil {
+		return fmt.Errorf("error while try to run <%w>", err)
+	}
+	trader.Auditor.Run()
+	trader.Reconciler.Run()
 	go func() {
 		isOut := false
 		for {
@@ -91,6 +186,8 @@ func (trader AlgoTrader) Run() error {
 					break
 				}
 				trader.stockEventHandler(event)
+			case tick := <-trader.ticks:
+				trader.stockTickHandler(tick)
 			case pairErr := <-trader.errors:
 				trader.stockErrorHandler(pairErr)
 			}
@@ -101,77 +198,476 @@ func (trader AlgoTrader) Run() error {
 		trader.log.Println("STOP: all signals were processed gracefully")
 		trader.stop <- struct{}{}
 	}()
+	return trader.restorePairs()
+}
+
+// restorePairs runs pairs of subscriptions stored in UserRepository.
+func (trader *AlgoTrader) restorePairs() error {
+	subscriptions, err := trader.Users.GetSubscriptions()
+	if err != nil {
+		return fmt.Errorf("can't restore pairs in trader <%w>", err)
+	}
+	restored := make(map[string]struct{})
+	for _, subscription := range subscriptions {
+		if _, ok := restored[subscription.Username]; !ok {
+			restored[subscription.Username] = struct{}{}
+			if err = trader.restoreLedger(subscription.Username); err != nil {
+				trader.log.Printf("RESTORE: ledger of user <%s> is broken <%s>",
+					subscription.Username, err)
+			}
+		}
+		if err = trader.addPair(subscription.Username, subscription.Config); err != nil {
+			trader.log.Printf("RESTORE: pair <%s> <%s> of user <%s> is broken <%s>",
+				subscription.Config.PairName, subscription.Config.PairInterval,
+				subscription.Username, err)
+			continue
+		}
+		trader.log.Printf("RESTORE: pair <%s> <%s> of user <%s> was restored",
+			subscription.Config.PairName, subscription.Config.PairInterval,
+			subscription.Username)
+	}
 	return nil
 }
 
 // stockEventHandler process Indicator signals and transfer information.
+// Order is placed after muPairs is released, so slow stock market doesn't block pairs.
+// Only placed orders are counted into user's daily orders.
 func (trader AlgoTrader) stockEventHandler(event domain.StockMarketEvent) {
+	event, user, err := trader.decide(event)
+	if user == nil {
+		return
+	}
+	if err != nil {
+		eventError := domain.StockMarketEventError{Event: event,
+			ErrorMessage: err.Error()}
+		trader.MessageWriters.WriteErrors(eventError.String(), *user)
+		return
+	}
+	if err = trader.placeOrder(event, user); err == nil {
+		trader.Risk.Count(user.Username)
+	}
+}
+
+// decide counts order volume of running pair's event. Close and reverse signals close
+// the whole position of Ledger, orders are clipped by pair's allocation and then
+// by risk limits. It returns nil user if pair or user isn't running.
+func (trader AlgoTrader) decide(event domain.StockMarketEvent) (domain.StockMarketEvent,
+	*domain.User, error) {
 	trader.muPairs.Lock()
 	defer trader.muPairs.Unlock()
-	for _, user := range trader.Pairs[event.Name][event.Interval].Users {
-		orderInfo, err := trader.API.AddOrder(event, user)
-		if err != nil {
-			trader.log.Printf("ERROR: order is broken <%s>", err)
-			eventError := domain.StockMarketEventError{Event: event,
-				ErrorMessage: err.Error()}
-			trader.MessageWriters.WriteErrors(eventError.String(), *user)
-			continue
-		}
-		trader.MessageWriters.WriteMessages(orderInfo, *user)
-		if err = trader.Orders.AddOrder(orderInfo); err != nil {
-			trader.log.Printf("DB: <%s>", err)
-			continue
+	pair, ok := trader.Pairs[event.Name][event.Interval]
+	if !ok {
+		return event, nil, nil
+	}
+	user, ok := pair.User(event.Username)
+	if !ok {
+		return event, nil, nil
+	}
+	pnl := trader.Ledger.Portfolio(user.Username).TotalPnL
+	position, _ := trader.Ledger.Position(user.Username, event.Name)
+	event, err := trader.sizeEvent(pair, event, user, position, pnl)
+	if err != nil {
+		trader.auditDecision(event, domain.SizeCheck, position, err)
+		trader.log.Printf("SIZE: order of user <%s> is skipped <%s>", user.Username, err)
+		return event, user, err
+	}
+	event, err = trader.Allocator.Check(event, user, trader.Pairs)
+	if err != nil {
+		trader.auditDecision(event, domain.AllocationCheck, position, err)
+		trader.log.Printf("ALLOCATION: order of user <%s> is rejected <%s>", user.Username, err)
+		return event, user, err
+	}
+	event, err = trader.Risk.Check(event, user, position, pnl)
+	trader.auditDecision(event, domain.RiskCheck, position, err)
+	if err != nil {
+		trader.log.Printf("RISK: order of user <%s> is rejected <%s>", user.Username, err)
+		return event, user, err
+	}
+	return event, user, nil
+}
+
+// sizeEvent counts order volume by user's Sizer and position.
+// Close order is sized by position only, reverse order, which can't be sized,
+// is shrunk to the close of position.
+func (trader AlgoTrader) sizeEvent(pair *Pair, event domain.StockMarketEvent, user *domain.User,
+	position domain.Position, pnl float64) (domain.StockMarketEvent, error) {
+	if event.Signal.IsReduceOnly() {
+		event.Volume = event.Signal.OrderVolume(0, position.Size)
+		return event, nil
+	}
+	volume, err := pair.Size(user.Username, event.Close, pnl)
+	if err != nil {
+		exit, ok := event.Signal.Exit()
+		if !ok || exit.OrderVolume(0, position.Size) == 0 {
+			return event, err
 		}
-		trader.log.Println("DB: order is created successfully")
+		trader.log.Printf("SIZE: reverse order of user <%s> is shrunk to close <%s>",
+			user.Username, err)
+		event.Signal = exit
 	}
+	event.Volume = event.Signal.OrderVolume(volume, position.Size)
+	return event, nil
 }
 
-// stockErrorHandler tries to recover dead pair and delete it.
-func (trader AlgoTrader) stockErrorHandler(pairErr PairError) {
+// riskExit describes user's order, which closes position by stop-loss or take-profit.
+type riskExit struct {
+	event domain.StockMarketEvent
+	user  *domain.User
+}
+
+// stockTickHandler marks positions by candle close, closes positions by
+// stop-loss or take-profit and pauses users by daily loss.
+// Orders are placed after muPairs is released.
+func (trader AlgoTrader) stockTickHandler(tick PairTick) {
+	trader.Ledger.Mark(tick.Name, tick.Candle.Close)
+	exits, paused := trader.checkTick(tick)
+	for _, user := range paused {
+		trader.MessageWriters.WriteErrors(fmt.Sprintf(
+			"max daily loss <%.2f> is reached, pairs are paused till the end of the day",
+			user.GetRisk().MaxDailyLoss), *user)
+	}
+	for _, exit := range exits {
+		_ = trader.placeOrder(exit.event, exit.user)
+	}
+}
+
+// checkTick returns risk exits of running pair's users and users paused by daily loss.
+func (trader AlgoTrader) checkTick(tick PairTick) ([]riskExit, []*domain.User) {
 	trader.muPairs.Lock()
 	defer trader.muPairs.Unlock()
-	trader.wg.Done()
-	pair := trader.Pairs[pairErr.Name][pairErr.Interval]
-	// try to recover pair or delete it
-	if err := trader.RunPair(pair); err != nil {
-		if len(pair.Users) == 0 {
-			delete(trader.Pairs[pair.Name], pair.Interval)
-			trader.log.Printf("DELETE: pair <%s> <%s> by error <%s>",
-				pair.Name, pair.Interval, pairErr.Message)
-			if len(trader.Pairs[pair.Name]) == 0 {
-				delete(trader.Pairs, pair.Name)
-				trader.log.Printf("DELETE: pair <%s> was deleted entirely by error <%s>",
-					pair.Name, pairErr.Message)
+	pair, ok := trader.Pairs[tick.Name][tick.Interval]
+	if !ok {
+		return nil, nil
+	}
+	exits := make([]riskExit, 0)
+	paused := make([]*domain.User, 0)
+	for _, user := range pair.Users {
+		position, ok := trader.Ledger.Position(user.Username, tick.Name)
+		if ok {
+			if signal, reason, ok := user.GetRisk().Exit(position); ok {
+				trader.log.Printf("RISK: position <%s> of user <%s> is closed by %s",
+					tick.Name, user.Username, reason)
+				event := domain.StockMarketEvent{
+					Signal:    signal,
+					Name:      tick.Name,
+					Interval:  tick.Interval,
+					Username:  user.Username,
+					Indicator: reason,
+					Volume:    abs(position.Size),
+					Close:     tick.Candle.Close,
+					Order:     domain.OrderOptions{ReduceOnly: true},
+					TraceID: domain.NewExitTraceID(tick.Name, tick.Interval, tick.Candle.Time,
+						user.Username, reason),
+				}
+				trader.Auditor.Write(domain.NewAuditEvent(domain.DecisionStage, event,
+					domain.DecisionAudit{
+						Check:    domain.ExitCheck,
+						Reason:   reason,
+						Volume:   event.Volume,
+						Position: position.Size,
+					}))
+				exits = append(exits, riskExit{event: event, user: user})
 			}
 		}
-		trader.MessageWriters.WriteErrorsToAll(err.Error(), pair.Users)
+		if trader.Risk.CheckLoss(user, trader.Ledger.Portfolio(user.Username).TotalPnL) {
+			trader.log.Printf("RISK: pairs of user <%s> are paused by daily loss", user.Username)
+			paused = append(paused, user)
+		}
 	}
+	return exits, paused
 }
 
-// ShutDown gracefully stops all running pairs.
+// placeOrder sends order to user's StockMarketAPI, applies its filled part to Ledger
+// and stores it. It returns error of StockMarketAPI.
+// Latency and outcome of every order are observed by metrics with user's trading mode.
+func (trader AlgoTrader) placeOrder(event domain.StockMarketEvent, user *domain.User) error {
+	trader.Auditor.Write(domain.NewAuditEvent(domain.OrderStage, event, event))
+	start := time.Now()
+	orderInfo, err := trader.stockMarketAPI(event.Name, user).AddOrder(event, user)
+	metrics.ObserveOrder(string(trader.Modes.Mode(user.Username, event.Name)), start, err)
+	response := domain.NewAuditEvent(domain.ResponseStage, event, orderInfo).WithError(err)
+	response.OrderID = orderInfo.OrderID
+	trader.Auditor.Write(response)
+	if err != nil {
+		trader.log.Printf("ERROR: order is broken <%s>", err)
+		eventError := domain.StockMarketEventError{Event: event,
+			ErrorMessage: err.Error()}
+		trader.MessageWriters.WriteErrors(eventError.String(), *user)
+		return err
+	}
+	orderInfo.Username = user.Username
+	orderInfo.Interval = event.Interval
+	orderInfo.Indicator = event.Indicator
+	orderInfo.CreatedAt = time.Now()
+	if orderInfo.Amount > 0 {
+		if position, err := trader.Ledger.Fill(orderInfo); err != nil {
+			trader.log.Printf("LEDGER: order isn't applied <%s>", err)
+		} else {
+			orderInfo.Position = &position
+		}
+	}
+	trader.MessageWriters.WriteMessages(orderInfo, *user)
+	err = trader.Orders.AddOrder(orderInfo)
+	storage := domain.NewAuditEvent(domain.StorageStage, event, nil).WithError(err)
+	storage.OrderID = orderInfo.OrderID
+	trader.Auditor.Write(storage)
+	if err != nil {
+		trader.log.Printf("DB: <%s>", err)
+		return nil
+	}
+	trader.log.Println("DB: order is created successfully")
+	trader.protect(event, orderInfo, user)
+	return nil
+}
+
+// protect places event's protective order, which is reduce-only and sized by filled part
+// of order, which opened position in its side. Protection is triggered from fill price.
+func (trader AlgoTrader) protect(event domain.StockMarketEvent, orderInfo domain.OrderInfo,
+	user *domain.User) {
+	if len(event.Protection.Type) == 0 || orderInfo.Position == nil {
+		return
+	}
+	size := orderInfo.Position.Size
+	exit := domain.CloseLong
+	if event.Signal.Side() == domain.Sell {
+		size = -size
+		exit = domain.CloseShort
+	}
+	if size > orderInfo.Amount {
+		size = orderInfo.Amount
+	}
+	if size <= 0 {
+		return
+	}
+	trader.log.Printf("PROTECT: %s order of user <%s> protects <%d> of <%s>",
+		event.Protection.Type, user.Username, size, event.Name)
+	_ = trader.placeOrder(domain.StockMarketEvent{
+		Signal:    exit,
+		Name:      event.Name,
+		Interval:  event.Interval,
+		Username:  event.Username,
+		Indicator: event.Indicator,
+		Volume:    size,
+		Close:     orderInfo.Price,
+		Order:     event.Protection,
+		TraceID:   event.TraceID,
+	}, user)
+}
+
+// auditDecision writes result of check of order event's volume.
+func (trader AlgoTrader) auditDecision(event domain.StockMarketEvent, check string,
+	position domain.Position, err error) {
+	trader.Auditor.Write(domain.NewAuditEvent(domain.DecisionStage, event, domain.DecisionAudit{
+		Check:    check,
+		Volume:   event.Volume,
+		Position: position.Size,
+	}).WithError(err))
+}
+
+// stockMarketAPI returns live or paper StockMarketAPI by user's pair mode.
+func (trader AlgoTrader) stockMarketAPI(pairName string, user *domain.User) StockMarketAPI {
+	if trader.Modes.Mode(user.Username, pairName) == domain.PaperMode {
+		return trader.Paper
+	}
+	return trader.API
+}
+
+// stockErrorHandler deletes dead pair, which socket couldn't reconnect
+// or which got broken candle. Subscriptions are kept, so pair is restored after restart.
+func (trader AlgoTrader) stockErrorHandler(pairErr PairError) {
+	trader.muPairs.Lock()
+	pair, ok := trader.Pairs[pairErr.Name][pairErr.Interval]
+	if !ok {
+		trader.muPairs.Unlock()
+		return
+	}
+	// pair could wait for the trader's loop to send the last candle
+	go pair.Stop(trader.wg)
+	delete(trader.Pairs[pair.Name], pair.Interval)
+	trader.log.Printf("DELETE: pair <%s> <%s> by error <%s>",
+		pair.Name, pair.Interval, pairErr.Message)
+	if len(trader.Pairs[pair.Name]) == 0 {
+		delete(trader.Pairs, pair.Name)
+		trader.log.Printf("DELETE: pair <%s> was deleted entirely by error <%s>",
+			pair.Name, pairErr.Message)
+	}
+	trader.observePairs()
+	trader.muPairs.Unlock()
+	trader.MessageWriters.WriteErrorsToAll(fmt.Sprintf("pair <%s> <%s> is stopped <%s>",
+		pair.Name, pair.Interval, pairErr.Message), pair.Users)
+}
+
+// observePairs sets active pairs and users gauges, muPairs should be locked.
+func (trader AlgoTrader) observePairs() {
+	pairs, users := trader.Pairs.Active()
+	metrics.ActivePairs.Set(float64(pairs))
+	metrics.ActiveUsers.Set(float64(users))
+}
+
+// ShutDown gracefully stops all running pairs. Pairs are stopped after muPairs is released,
+// because they could wait for the trader's loop to send their last candles.
 func (trader AlgoTrader) ShutDown() {
 	trader.muPairs.Lock()
-	trader.Pairs.Shutdown(trader.wg)
+	pairs := trader.Pairs.Detach()
+	trader.observePairs()
 	trader.muPairs.Unlock()
+	pairs.Shutdown(trader.wg)
 	trader.log.Println("STOP: All pairs were interrupted gracefully")
 	close(trader.signals)
 	close(trader.errors)
 	<-trader.stop
+	trader.Reconciler.Shutdown()
+	trader.Auditor.Shutdown()
 	trader.Orders.Shutdown()
+	trader.Candles.Shutdown()
+	trader.Audit.Shutdown()
 	trader.MessageWriters.Shutdown()
 }
 
-// AddUser adds user to UserRepository.
+// restoreLedger replays user's stored orders from the oldest into Ledger,
+// paper orders are replayed into paper accounts too.
+func (trader *AlgoTrader) restoreLedger(username string) error {
+	infos := make([]domain.OrderInfo, 0)
+	filter := domain.OrderFilter{Username: username, Limit: domain.MaxOrdersLimit}
+	for {
+		page, err := trader.Orders.GetOrders(filter)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, page.Orders...)
+		if page.NextCursor == 0 {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	for i := len(infos) - 1; i >= 0; i-- {
+		if _, err := trader.Ledger.Fill(infos[i]); err != nil {
+			return err
+		}
+		restorer, ok := trader.Paper.(OrderRestorer)
+		if !ok || !IsPaperOrder(infos[i].OrderID) || infos[i].Amount == 0 {
+			continue
+		}
+		if err := restorer.Restore(infos[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddUser hashes user's password, seals private key and adds user to UserRepository.
 func (trader *AlgoTrader) AddUser(user domain.User) error {
-	if err := trader.Users.AddUser(&user); err != nil {
+	if err := user.Risk.Validate(); err != nil {
+		return fmt.Errorf("trader add user error <%w>", err)
+	}
+	if err := user.HashPassword(); err != nil {
+		return fmt.Errorf("trader add user error <%w>", err)
+	}
+	sealedKey, err := trader.sealKey(user.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("trader add user error <%w>", err)
+	}
+	user.PrivateKey = sealedKey
+	if err = trader.Users.AddUser(&user); err != nil {
 		return fmt.Errorf("trader add user error <%w>", err)
 	}
 	trader.log.Printf("ADD: user: <%s> was added", user.Username)
 	return nil
 }
 
+// MigrateUser sets password of user stored before passwords and seals user's plaintext
+// private key. User proves the account by stored public and private keys like before.
+func (trader *AlgoTrader) MigrateUser(input domain.User) error {
+	user, err := trader.Users.GetUser(input.Username)
+	if err != nil {
+		return fmt.Errorf("trader migrate user error <%w>", err)
+	}
+	if len(user.PasswordHash) != 0 {
+		return fmt.Errorf("trader migrate user error <%w>", users.ErrMigratedUser)
+	}
+	if len(input.PrivateKey) == 0 ||
+		subtle.ConstantTimeCompare([]byte(user.PublicKey), []byte(input.PublicKey)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(user.PrivateKey), []byte(input.PrivateKey)) != 1 {
+		return fmt.Errorf("trader migrate user error <%w>", domain.ErrIncorrectPassword)
+	}
+	if err = input.HashPassword(); err != nil {
+		return fmt.Errorf("trader migrate user error <%w>", err)
+	}
+	sealedKey, err := trader.sealKey(input.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("trader migrate user error <%w>", err)
+	}
+	if err = trader.Users.MigrateUser(input.Username, input.PasswordHash, sealedKey); err != nil {
+		return fmt.Errorf("trader migrate user error <%w>", err)
+	}
+	trader.log.Printf("EDIT: user <%s> was migrated", input.Username)
+	return nil
+}
+
+// SetKeys seals private key and edits user's keys in UserRepository.
+func (trader *AlgoTrader) SetKeys(username, public, private string) error {
+	user, err := trader.Users.GetUser(username)
+	if err != nil {
+		return fmt.Errorf("trader set keys error <%w>", err)
+	}
+	if user.PublicKey == public && len(private) != 0 {
+		// sealed keys differ every time, so plaintext keys are compared
+		if storedKey, err := trader.keeper.Open(user.PrivateKey); err == nil {
+			isEqual := string(storedKey) == private
+			secret.Wipe(storedKey)
+			if isEqual {
+				return fmt.Errorf("trader set keys error <%w>", users.ErrNothingToChange)
+			}
+		}
+	}
+	sealedKey, err := trader.sealKey(private)
+	if err != nil {
+		return fmt.Errorf("trader set keys error <%w>", err)
+	}
+	if err = trader.Users.SetKeys(username, public, sealedKey); err != nil {
+		return fmt.Errorf("trader set keys error <%w>", err)
+	}
+	trader.log.Printf("EDIT: keys of user <%s> were changed", username)
+	return nil
+}
+
+// SetRisk validates and edits user's RiskLimits in UserRepository.
+func (trader *AlgoTrader) SetRisk(username string, limits domain.RiskLimits) error {
+	if err := limits.Validate(); err != nil {
+		return fmt.Errorf("trader set risk error <%w>", err)
+	}
+	if err := trader.Users.SetRisk(username, limits); err != nil {
+		return fmt.Errorf("trader set risk error <%w>", err)
+	}
+	trader.log.Printf("EDIT: risk limits of user <%s> were changed", username)
+	return nil
+}
+
+// sealKey seals private key, empty key stays empty to be rejected by UserRepository.
+func (trader AlgoTrader) sealKey(privateKey string) (string, error) {
+	if len(privateKey) == 0 {
+		return "", nil
+	}
+	return trader.keeper.Seal([]byte(privateKey))
+}
+
 // AddPair adds pair and run it if it doesn't exist, else just sign user.
+// User's subscription is stored to be restored after restart.
 func (trader *AlgoTrader) AddPair(username string, config domain.Config) error {
+	if err := trader.addPair(username, config); err != nil {
+		return err
+	}
+	if err := trader.Users.AddSubscription(username, config); err != nil {
+		trader.log.Printf("DB: subscription is not stored <%s>", err)
+	}
+	return nil
+}
+
+// addPair adds pair and run it if it doesn't exist, else just sign user.
+// User's Indicator is warmed up and new pair is connected without muPairs,
+// because history is requested and connection is retried with backoff.
+// New pair is added to Pairs only after it's run.
+func (trader *AlgoTrader) addPair(username string, config domain.Config) error {
 	if err := config.Validate(); err != nil {
 		return err
 	}
@@ -182,64 +678,140 @@ func (trader *AlgoTrader) AddPair(username string, config domain.Config) error {
 	if err = user.AddLimit(config.PairName, config.Limit); err != nil {
 		return fmt.Errorf("can't add pair in trader: <%w>", err)
 	}
-	trader.muPairs.Lock()
-	defer trader.muPairs.Unlock()
-	if intervals, ok := trader.Pairs[config.PairName]; ok {
-		if pair, ok := intervals[config.PairInterval]; ok {
-			// pair name and pair interval are existing
-			if err = pair.AddUser(user); err != nil {
-				return err
-			}
-			trader.log.Printf("ADD: user <%s> was added to existed pair <%s> <%s>",
-				username, pair.Name, pair.Interval)
-		} else {
-			// pair name is existing, but pair interval not
-			if err = trader.AddAndRunPair(config.PairName, config.PairInterval,
-				config.IndicatorName, user); err != nil {
-				return err
-			}
-		}
-	} else {
-		trader.Pairs[config.PairName] = make(map[domain.CandleInterval]*Pair)
-		// pair name is existing, but pair interval not
-		if err = trader.AddAndRunPair(config.PairName, config.PairInterval,
-			config.IndicatorName, user); err != nil {
-			return err
-		}
+	pair, err := trader.newPair(config, user)
+	if err != nil {
+		return err
+	}
+	running, err := trader.addToRunningPair(user, config, pair)
+	if err != nil {
+		return err
+	}
+	if running != nil {
+		running.SubscribeFilters()
+		return nil
+	}
+	if err = trader.RunPair(pair); err != nil {
+		return fmt.Errorf("can't run pair in trader: <%w>", err)
+	}
+	trader.log.Printf("RUN: pair <%s> <%s> was run",
+		pair.Name, pair.Interval)
+	registered, err := trader.registerPair(pair, user, config)
+	if registered {
+		return nil
+	}
+	pair.Stop(trader.wg)
+	if err != nil {
+		return err
+	}
+	// the same pair was added meanwhile, so user is moved to it
+	if running, err = trader.addToRunningPair(user, config, pair); err != nil {
+		return err
 	}
+	if running == nil {
+		return fmt.Errorf("can't add pair in trader: <%w>", ErrPairNotFound)
+	}
+	running.SubscribeFilters()
 	return nil
 }
 
-// AddAndRunPair adds and runs pair.
-func (trader *AlgoTrader) AddAndRunPair(pairName string, pairInterval domain.CandleInterval,
-	indicatorName string, user *domain.User) error {
-	pair, err := NewPair(pairName, pairInterval, indicatorName, trader.log)
+// newPair creates pair of config with warmed up user. Pair isn't run and added to Pairs.
+func (trader *AlgoTrader) newPair(config domain.Config, user *domain.User) (*Pair, error) {
+	pair, err := NewPair(config, trader.socket, trader.log)
 	if err != nil {
-		return fmt.Errorf("can't create pair in trader: <%w>", err)
+		return nil, fmt.Errorf("can't create pair in trader: <%w>", err)
 	}
-	if err = trader.Pairs.AddPair(pair, user); err != nil {
-		return fmt.Errorf("can't add pair in trader: <%w>", err)
+	pair.Store = trader.Candles
+	pair.Audit = trader.Auditor
+	if err = pair.AddUser(user, config); err != nil {
+		return nil, fmt.Errorf("can't add pair in trader: <%w>", err)
+	}
+	trader.warmUp(pair, user.Username)
+	return pair, nil
+}
+
+// addToRunningPair moves user of warmed up pair to running pair of config, if it exists.
+// It returns running pair, which should subscribe user's filters after muPairs is released.
+func (trader *AlgoTrader) addToRunningPair(user *domain.User, config domain.Config,
+	warmed *Pair) (*Pair, error) {
+	trader.muPairs.Lock()
+	defer trader.muPairs.Unlock()
+	if err := trader.checkMode(user.Username, config); err != nil {
+		return nil, fmt.Errorf("can't add pair in trader: <%w>", err)
+	}
+	pair, ok := trader.Pairs[config.PairName][config.PairInterval]
+	if !ok {
+		return nil, nil
+	}
+	defer trader.observePairs()
+	if err := pair.MoveUser(user, warmed); err != nil {
+		return nil, err
+	}
+	trader.Modes.Set(user.Username, config.PairName, config.Mode)
+	trader.log.Printf("ADD: user <%s> was added to existed pair <%s> <%s>",
+		user.Username, pair.Name, pair.Interval)
+	return pair, nil
+}
+
+// registerPair adds run pair to Pairs, if the same pair wasn't added meanwhile.
+func (trader *AlgoTrader) registerPair(pair *Pair, user *domain.User,
+	config domain.Config) (bool, error) {
+	trader.muPairs.Lock()
+	defer trader.muPairs.Unlock()
+	defer trader.observePairs()
+	if err := trader.checkMode(user.Username, config); err != nil {
+		return false, fmt.Errorf("can't add pair in trader: <%w>", err)
 	}
+	if trader.Pairs.IsExist(pair.Name, pair.Interval) {
+		return false, nil
+	}
+	if err := trader.Pairs.AddPair(pair); err != nil {
+		return false, fmt.Errorf("can't add pair in trader: <%w>", err)
+	}
+	trader.Modes.Set(user.Username, config.PairName, config.Mode)
 	trader.log.Printf("ADD: user <%s> was added and pair <%s> <%s> was created",
 		user.Username, pair.Name, pair.Interval)
-	if err = trader.RunPair(pair); err != nil {
-		return fmt.Errorf("can't run pair in trader: <%w>", err)
+	return true, nil
+}
+
+// checkMode checks that user's other intervals of the pair are traded in the config's mode,
+// because positions are kept by pair. muPairs should be locked.
+func (trader AlgoTrader) checkMode(username string, config domain.Config) error {
+	for interval, pair := range trader.Pairs[config.PairName] {
+		if interval == config.PairInterval {
+			continue
+		}
+		if subscribed, ok := pair.Config(username); ok &&
+			subscribed.TradingMode() != config.TradingMode() {
+			return ErrModeConflict
+		}
 	}
-	trader.log.Printf("RUN: pair <%s> <%s> was run",
-		pair.Name, pair.Interval)
 	return nil
 }
 
+// warmUp primes user's Indicator by history candles,
+// pair is run without warm-up if history isn't available.
+func (trader AlgoTrader) warmUp(pair *Pair, username string) {
+	count, err := pair.WarmUp(trader.history, username)
+	if err != nil {
+		trader.log.Printf("WARM-UP: pair <%s> <%s> of user <%s> isn't warmed up <%s>",
+			pair.Name, pair.Interval, username, err)
+		return
+	}
+	trader.log.Printf("WARM-UP: pair <%s> <%s> of user <%s> was warmed up by <%d> candles",
+		pair.Name, pair.Interval, username, count)
+}
+
 // RunPair runs pair and reconnect it if it's possible.
+// Connection lost later is restored by pair's socket.
+// Reconnection sleeps with backoff, so muPairs shouldn't be locked.
 func (trader AlgoTrader) RunPair(pair *Pair) error {
-	if err := pair.Run(trader.signals, trader.errors); err != nil {
+	if err := pair.Run(trader.signals, trader.ticks, trader.errors); err != nil {
 		trader.log.Printf("can't run pair <%s>", err)
 		// reconnection
-		ticker := time.NewTicker(5 * time.Second)
 		var i int64
 		for i = 0; i < trader.reconnectionTimes; i++ {
-			<-ticker.C
-			if err = pair.Run(trader.signals, trader.errors); err == nil {
+			time.Sleep(trader.backoff.Delay(i))
+			if err = pair.Run(trader.signals, trader.ticks, trader.errors); err == nil {
 				break
 			}
 		}
@@ -251,7 +823,8 @@ func (trader AlgoTrader) RunPair(pair *Pair) error {
 	return nil
 }
 
-// DeletePair deletes pair if it is possible.
+// DeletePair deletes pair if it is possible. Pair left without users is stopped
+// after muPairs is released, because it could wait for the trader's loop.
 func (trader AlgoTrader) DeletePair(username string, config domain.Config) error {
 	if err := config.Validate(); err != nil {
 		return fmt.Errorf("can't validate config to delete pair in trader: <%w>", err)
@@ -260,29 +833,122 @@ func (trader AlgoTrader) DeletePair(username string, config domain.Config) error
 	if err != nil {
 		return err
 	}
+	stopped, err := trader.detachUser(user, config)
+	if err != nil {
+		return err
+	}
+	if stopped != nil {
+		stopped.Stop(trader.wg)
+	}
+	if err = trader.Users.DeleteSubscription(username, config); err != nil {
+		trader.log.Printf("DB: subscription is not deleted <%s>", err)
+	}
+	return nil
+}
+
+// detachUser deletes user from pair of config and returns pair, which is left without
+// users and is deleted from Pairs, to be stopped.
+func (trader AlgoTrader) detachUser(user *domain.User, config domain.Config) (*Pair, error) {
 	trader.muPairs.Lock()
 	defer trader.muPairs.Unlock()
-	if intervals, ok := trader.Pairs[config.PairName]; ok {
-		if pair, ok := intervals[config.PairInterval]; ok {
-			if err = pair.DeleteUser(user); err != nil {
-				return fmt.Errorf("can't validate config to delete pair in trader: <%w>", err)
-			}
-			if len(pair.Users) == 0 {
-				pair.Stop(trader.wg)
-				delete(trader.Pairs[pair.Name], pair.Interval)
-				trader.log.Printf("DELETE: pair <%s> <%s> was deleted by <%s>",
-					pair.Name, pair.Interval, user.Username)
-				if len(trader.Pairs[pair.Name]) == 0 {
-					delete(trader.Pairs, pair.Name)
-					trader.log.Printf("DELETE: pair <%s> was deleted entirely",
-						pair.Name)
-				}
-			}
-		}
+	defer trader.observePairs()
+	pair, ok := trader.Pairs[config.PairName][config.PairInterval]
+	if !ok {
+		return nil, nil
+	}
+	if err := pair.DeleteUser(user); err != nil {
+		return nil, fmt.Errorf("can't validate config to delete pair in trader: <%w>", err)
 	}
+	if len(pair.Users) != 0 {
+		return nil, nil
+	}
+	delete(trader.Pairs[pair.Name], pair.Interval)
+	trader.log.Printf("DELETE: pair <%s> <%s> was deleted by <%s>",
+		pair.Name, pair.Interval, user.Username)
+	if len(trader.Pairs[pair.Name]) == 0 {
+		delete(trader.Pairs, pair.Name)
+		trader.log.Printf("DELETE: pair <%s> was deleted entirely",
+			pair.Name)
+	}
+	return pair, nil
+}
+
+// GetCandles returns stored candles of pair.
+func (trader AlgoTrader) GetCandles(filter domain.CandleFilter) ([]domain.Candle, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("can't validate candles filter <%w>", err)
+	}
+	candles, err := trader.Candles.GetCandles(filter)
+	if err != nil {
+		return nil, fmt.Errorf("can't get candles <%w>", err)
+	}
+	return candles, nil
+}
+
+// GetOrders returns page of user's orders.
+func (trader AlgoTrader) GetOrders(username string, filter domain.OrderFilter) (domain.OrderPage, error) {
+	filter.Username = username
+	if err := filter.Validate(); err != nil {
+		return domain.OrderPage{}, fmt.Errorf("can't validate orders filter <%w>", err)
+	}
+	page, err := trader.Orders.GetOrders(filter)
+	if err != nil {
+		return domain.OrderPage{}, fmt.Errorf("can't get orders <%w>", err)
+	}
+	return page, nil
+}
+
+// OpenOrders returns user's resting orders of pair's StockMarketAPI.
+func (trader AlgoTrader) OpenOrders(username, pairName string) ([]domain.OpenOrder, error) {
+	user, err := trader.Users.GetUser(username)
+	if err != nil {
+		return nil, fmt.Errorf("can't get open orders <%w>", err)
+	}
+	orders, err := trader.stockMarketAPI(pairName, user).OpenOrders(user)
+	if err != nil {
+		return nil, fmt.Errorf("can't get open orders <%w>", err)
+	}
+	return orders, nil
+}
+
+// CancelOrder cancels user's resting order of pair's StockMarketAPI.
+func (trader AlgoTrader) CancelOrder(username, pairName, orderID string) error {
+	if len(orderID) == 0 {
+		return fmt.Errorf("can't cancel order <order id is empty>")
+	}
+	user, err := trader.Users.GetUser(username)
+	if err != nil {
+		return fmt.Errorf("can't cancel order <%w>", err)
+	}
+	if err = trader.stockMarketAPI(pairName, user).CancelOrder(orderID, user); err != nil {
+		return fmt.Errorf("can't cancel order <%w>", err)
+	}
+	trader.log.Printf("CANCEL: order <%s> of user <%s> was cancelled", orderID, username)
 	return nil
 }
 
+// Reconcile compares user's stored orders and positions with stock market's ones.
+func (trader AlgoTrader) Reconcile(username string) (domain.Reconciliation, error) {
+	return trader.Reconciler.Reconcile(username)
+}
+
+// GetAudit returns user's audit events of trading decisions.
+func (trader AlgoTrader) GetAudit(username string, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	filter.Username = username
+	return trader.Auditor.Events(filter)
+}
+
+// Portfolio returns user's positions, PnL and allocations of running pairs.
+func (trader AlgoTrader) Portfolio(username string) domain.Portfolio {
+	portfolio := trader.Ledger.Portfolio(username)
+	trader.muPairs.Lock()
+	defer trader.muPairs.Unlock()
+	if user, ok := trader.Pairs.User(username); ok {
+		portfolio.Allocations = trader.Allocator.Allocations(user, trader.Pairs)
+	}
+	return portfolio
+}
+
 // AddMessageWriter adds message writer into slice.
 func (trader *AlgoTrader) AddMessageWriter(messageWriter MessageWriter) {
 	trader.MessageWriters.AddWriter(messageWriter)
@@ -1,36 +1,63 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/metrics"
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository/users"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/secret"
 	mock_service "github.com/agandreev/tfs-go-hw/CourseWork/internal/service/mocks"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 )
 
 var trader = setupTrader()
 
+func newKeeper() *secret.Keeper {
+	masterKey, err := secret.GenerateMasterKey()
+	if err != nil {
+		panic(err)
+	}
+	keeper, err := secret.NewKeeper(masterKey)
+	if err != nil {
+		panic(err)
+	}
+	return keeper
+}
+
 func setupTrader() *AlgoTrader {
 	log := logrus.New()
+	keeper := newKeeper()
 	trader := &AlgoTrader{
 		Users:             nil,
 		Pairs:             make(Pairs),
 		Orders:            nil,
-		API:               NewKrakenAPI(),
+		API:               NewKrakenAPI(DefaultOrderURL, keeper),
+		Paper:             NewPaperExchange(DefaultPaperCash),
 		MessageWriters:    *NewMessageWriters(log),
+		keeper:            keeper,
 		wg:                &sync.WaitGroup{},
 		reconnectionTimes: 1,
+		endpoints:         DefaultEndpoints(),
 		muPairs:           &sync.Mutex{},
+		Ledger:            NewLedger(),
+		Modes:             NewTradingModes(),
+		Risk:              NewRiskManager(),
 		signals:           make(chan domain.StockMarketEvent),
+		ticks:             make(chan PairTick),
 		errors:            make(chan PairError),
 		stop:              make(chan struct{}),
 		log:               log,
 	}
+	trader.Allocator = NewAllocator(trader.Ledger)
 	trader.log.SetOutput(io.Discard)
 	return trader
 }
@@ -48,11 +75,12 @@ func TestAlgoTrader_AddUser(t *testing.T) {
 			name: "Ok",
 			inputUser: domain.User{
 				Username:   "username",
+				Password:   "password",
 				PublicKey:  "x",
 				PrivateKey: "x",
 			},
 			mockBehavior: func(r *mock_service.MockUserRepository, user *domain.User) {
-				r.EXPECT().AddUser(user).Return(nil)
+				r.EXPECT().AddUser(sealed(user)).Return(nil)
 			},
 			expectedValue: nil,
 		},
@@ -60,12 +88,13 @@ func TestAlgoTrader_AddUser(t *testing.T) {
 			name: "Ok with tg",
 			inputUser: domain.User{
 				Username:   "username",
+				Password:   "password",
 				PublicKey:  "x",
 				PrivateKey: "x",
 				TelegramID: 1,
 			},
 			mockBehavior: func(r *mock_service.MockUserRepository, user *domain.User) {
-				r.EXPECT().AddUser(user).Return(nil)
+				r.EXPECT().AddUser(sealed(user)).Return(nil)
 			},
 			expectedValue: nil,
 		},
@@ -73,12 +102,13 @@ func TestAlgoTrader_AddUser(t *testing.T) {
 			name: "less len",
 			inputUser: domain.User{
 				Username:   "",
+				Password:   "password",
 				PublicKey:  "x",
 				PrivateKey: "x",
 				TelegramID: 1,
 			},
 			mockBehavior: func(r *mock_service.MockUserRepository, user *domain.User) {
-				r.EXPECT().AddUser(user).Return(users.ErrIncorrectUserValues)
+				r.EXPECT().AddUser(sealed(user)).Return(users.ErrIncorrectUserValues)
 			},
 			expectedValue: users.ErrIncorrectUserValues,
 		},
@@ -86,12 +116,13 @@ func TestAlgoTrader_AddUser(t *testing.T) {
 			name: "less len",
 			inputUser: domain.User{
 				Username:   "x",
+				Password:   "password",
 				PublicKey:  "",
 				PrivateKey: "x",
 				TelegramID: 1,
 			},
 			mockBehavior: func(r *mock_service.MockUserRepository, user *domain.User) {
-				r.EXPECT().AddUser(user).Return(users.ErrIncorrectUserValues)
+				r.EXPECT().AddUser(sealed(user)).Return(users.ErrIncorrectUserValues)
 			},
 			expectedValue: users.ErrIncorrectUserValues,
 		},
@@ -99,15 +130,26 @@ func TestAlgoTrader_AddUser(t *testing.T) {
 			name: "less len",
 			inputUser: domain.User{
 				Username:   "x",
+				Password:   "password",
 				PublicKey:  "x",
 				PrivateKey: "",
 				TelegramID: 1,
 			},
 			mockBehavior: func(r *mock_service.MockUserRepository, user *domain.User) {
-				r.EXPECT().AddUser(user).Return(users.ErrIncorrectUserValues)
+				r.EXPECT().AddUser(sealed(user)).Return(users.ErrIncorrectUserValues)
 			},
 			expectedValue: users.ErrIncorrectUserValues,
 		},
+		{
+			name: "empty password",
+			inputUser: domain.User{
+				Username:   "x",
+				PublicKey:  "x",
+				PrivateKey: "x",
+			},
+			mockBehavior:  func(r *mock_service.MockUserRepository, user *domain.User) {},
+			expectedValue: domain.ErrEmptyPassword,
+		},
 	}
 
 	for _, test := range tests {
@@ -130,3 +172,396 @@ func TestAlgoTrader_AddUser(t *testing.T) {
 		})
 	}
 }
+
+// sealedUserMatcher matches user, whose password is hashed and private key is sealed.
+type sealedUserMatcher struct {
+	user domain.User
+}
+
+// sealed returns gomock matcher of stored user.
+func sealed(user *domain.User) gomock.Matcher {
+	return sealedUserMatcher{user: *user}
+}
+
+func (matcher sealedUserMatcher) Matches(x interface{}) bool {
+	user, ok := x.(*domain.User)
+	if !ok || user.Username != matcher.user.Username ||
+		user.PublicKey != matcher.user.PublicKey ||
+		user.TelegramID != matcher.user.TelegramID || len(user.Password) != 0 {
+		return false
+	}
+	if user.CheckPassword(matcher.user.Password) != nil {
+		return false
+	}
+	if len(matcher.user.PrivateKey) == 0 {
+		return len(user.PrivateKey) == 0
+	}
+	privateKey, err := trader.keeper.Open(user.PrivateKey)
+	return err == nil && string(privateKey) == matcher.user.PrivateKey
+}
+
+func (matcher sealedUserMatcher) String() string {
+	return fmt.Sprintf("is sealed user %s", matcher.user.Username)
+}
+
+func TestAlgoTrader_SetKeys(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockUserRepository(c)
+	trader.Users = repo
+
+	user := domain.NewUser("username")
+	user.PublicKey = "public"
+	sealedKey, err := trader.keeper.Seal([]byte("private"))
+	assert.NoError(t, err)
+	user.PrivateKey = sealedKey
+	repo.EXPECT().GetUser(user.Username).Return(user, nil).Times(3)
+	repo.EXPECT().GetUser("x").Return(nil, users.ErrNonExistentUser)
+
+	assert.ErrorIs(t, trader.SetKeys("x", "public", "private"), users.ErrNonExistentUser)
+	assert.ErrorIs(t, trader.SetKeys(user.Username, "public", "private"),
+		users.ErrNothingToChange)
+	repo.EXPECT().SetKeys(user.Username, "public", gomock.Any()).DoAndReturn(
+		func(username, public, private string) error {
+			privateKey, err := trader.keeper.Open(private)
+			assert.NoError(t, err)
+			assert.Equal(t, string(privateKey), "other")
+			return nil
+		})
+	assert.NoError(t, trader.SetKeys(user.Username, "public", "other"))
+	repo.EXPECT().SetKeys(user.Username, "public", "").Return(users.ErrIncorrectUserValues)
+	assert.ErrorIs(t, trader.SetKeys(user.Username, "public", ""), users.ErrIncorrectUserValues)
+}
+
+func TestAlgoTrader_MigrateUser(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockUserRepository(c)
+	trader.Users = repo
+
+	legacy := domain.NewUser("legacy")
+	legacy.PublicKey = "public"
+	legacy.PrivateKey = "private"
+	migrated := domain.NewUser("migrated")
+	migrated.PasswordHash = "hash"
+	repo.EXPECT().GetUser(legacy.Username).Return(legacy, nil).Times(3)
+	repo.EXPECT().GetUser(migrated.Username).Return(migrated, nil)
+
+	assert.ErrorIs(t, trader.MigrateUser(domain.User{Username: migrated.Username,
+		Password: "password"}), users.ErrMigratedUser)
+	assert.ErrorIs(t, trader.MigrateUser(domain.User{Username: legacy.Username,
+		Password: "password", PublicKey: "public", PrivateKey: "other"}),
+		domain.ErrIncorrectPassword)
+	assert.ErrorIs(t, trader.MigrateUser(domain.User{Username: legacy.Username,
+		PublicKey: "public", PrivateKey: "private"}), domain.ErrEmptyPassword)
+	repo.EXPECT().MigrateUser(legacy.Username, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(username, passwordHash, private string) error {
+			checked := domain.User{PasswordHash: passwordHash}
+			assert.NoError(t, checked.CheckPassword("password"))
+			privateKey, err := trader.keeper.Open(private)
+			assert.NoError(t, err)
+			assert.Equal(t, string(privateKey), "private")
+			return nil
+		})
+	assert.NoError(t, trader.MigrateUser(domain.User{Username: legacy.Username,
+		Password: "password", PublicKey: "public", PrivateKey: "private"}))
+}
+
+func TestAlgoTrader_stockMarketAPI(t *testing.T) {
+	user := domain.NewUser("username")
+	assert.Equal(t, trader.stockMarketAPI("pair", user), trader.API)
+	trader.Modes.Set(user.Username, "pair", domain.PaperMode)
+	assert.Equal(t, trader.stockMarketAPI("pair", user), trader.Paper)
+	assert.Equal(t, trader.stockMarketAPI("other pair", user), trader.API)
+	assert.Equal(t, trader.stockMarketAPI("pair", domain.NewUser("other")), trader.API)
+}
+
+func TestAlgoTrader_GetOrders(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockOrderRepository(c)
+	trader.Orders = repo
+
+	page := domain.OrderPage{Orders: []domain.OrderInfo{{ID: 1, Username: "username"}}}
+	repo.EXPECT().GetOrders(domain.OrderFilter{Username: "username", PairName: "pair",
+		Limit: domain.DefaultOrdersLimit}).Return(page, nil)
+	result, err := trader.GetOrders("username", domain.OrderFilter{Username: "other", PairName: "pair"})
+	assert.NoError(t, err)
+	assert.Equal(t, result, page)
+
+	_, err = trader.GetOrders("username", domain.OrderFilter{Side: "wait to buy"})
+	assert.Error(t, err)
+}
+
+func TestAlgoTrader_Portfolio(t *testing.T) {
+	trader := setupTrader()
+	user := domain.NewUser("username")
+	trader.Pairs = makeAllocationPairs(t, user)
+	assert.Nil(t, trader.Portfolio(user.Username).Allocations)
+
+	user.Risk.Allocation = domain.Allocation{Budget: 100, Mode: domain.FixedAllocation,
+		Weights: map[string]float64{"first": 3, "second": 1}}
+	assert.Equal(t, trader.Portfolio(user.Username).Allocations,
+		map[string]float64{"first": 75, "second": 25})
+	assert.Nil(t, trader.Portfolio("other").Allocations)
+}
+
+func TestAlgoTrader_placeOrderMetrics(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockOrderRepository(c)
+	trader := setupTrader()
+	trader.Orders = repo
+	user := domain.NewUser("username")
+	trader.Modes.Set(user.Username, "name", domain.PaperMode)
+	event := domain.StockMarketEvent{Signal: domain.Buy, Name: "name", Volume: 1, Close: 10}
+	paperOrders := metrics.Orders.WithLabelValues(string(domain.PaperMode), metrics.Success)
+	liveOrders := metrics.Orders.WithLabelValues(string(domain.LiveMode), metrics.Failure)
+	paper, live := testutil.ToFloat64(paperOrders), testutil.ToFloat64(liveOrders)
+
+	repo.EXPECT().AddOrder(gomock.Any()).Return(nil)
+	assert.NoError(t, trader.placeOrder(event, user))
+	assert.Equal(t, testutil.ToFloat64(paperOrders), paper+1)
+	// live user without keys
+	assert.Error(t, trader.placeOrder(event, domain.NewUser("other")))
+	assert.Equal(t, testutil.ToFloat64(liveOrders), live+1)
+}
+
+func TestAlgoTrader_restoreLedger(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockOrderRepository(c)
+	trader := setupTrader()
+	trader.Orders = repo
+
+	// orders are stored from the newest
+	page := domain.OrderPage{Orders: []domain.OrderInfo{
+		{ID: 3, Name: "pair", OrderID: "paper-1-2-username", Price: 20, Amount: 2,
+			Side: string(domain.Sell), Username: "username"},
+		{ID: 2, Name: "pair", OrderID: "live", Price: 10, Amount: 1,
+			Side: string(domain.Buy), Username: "username"},
+		{ID: 1, Name: "pair", OrderID: "paper-1-1-username", Price: 10, Amount: 5,
+			Side: string(domain.Buy), Username: "username"},
+	}}
+	repo.EXPECT().GetOrders(domain.OrderFilter{Username: "username",
+		Limit: domain.MaxOrdersLimit}).Return(page, nil)
+	assert.NoError(t, trader.restoreLedger("username"))
+	position, ok := trader.Ledger.Position("username", "pair")
+	assert.True(t, ok)
+	assert.Equal(t, position.Size, int64(4))
+	account := trader.Paper.(*PaperExchange).Account("username")
+	assert.Equal(t, account.Cash, float64(DefaultPaperCash-10))
+	assert.Equal(t, account.Positions["pair"], int64(3))
+}
+
+func TestAlgoTrader_placeOrderProtection(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockOrderRepository(c)
+	api := mock_service.NewMockStockMarketAPI(c)
+	trader := setupTrader()
+	trader.Orders = repo
+	trader.API = api
+	user := domain.NewUser("username")
+	stop := domain.OrderOptions{Type: domain.StopOrder, Trigger: 0.1, ReduceOnly: true}
+	event := domain.StockMarketEvent{Signal: domain.OpenLong, Name: "name", Volume: 3,
+		Close: 10, Order: domain.OrderOptions{Type: domain.IOCOrder}, Protection: stop}
+
+	// stop is sized by filled part and triggered from fill price
+	repo.EXPECT().AddOrder(gomock.Any()).Return(nil).Times(3)
+	api.EXPECT().AddOrder(event, user).Return(domain.OrderInfo{Name: "name", Price: 11,
+		Amount: 2, Side: string(domain.Buy)}, nil)
+	api.EXPECT().AddOrder(domain.StockMarketEvent{Signal: domain.CloseLong, Name: "name",
+		Volume: 2, Close: 11, Order: stop}, user).Return(domain.OrderInfo{Name: "name",
+		Side: string(domain.Sell), Type: domain.StopOrder}, nil)
+	assert.NoError(t, trader.placeOrder(event, user))
+
+	// order, which reduces position, isn't protected
+	event.Signal = domain.Sell
+	api.EXPECT().AddOrder(event, user).Return(domain.OrderInfo{Name: "name", Price: 11,
+		Amount: 1, Side: string(domain.Sell)}, nil)
+	assert.NoError(t, trader.placeOrder(event, user))
+	position, _ := trader.Ledger.Position(user.Username, "name")
+	assert.Equal(t, position.Size, int64(1))
+}
+
+func TestAlgoTrader_checkTickExit(t *testing.T) {
+	trader := setupTrader()
+	trader.Auditor = NewAuditor(nil, trader.log)
+	user := domain.NewUser("username")
+	user.Risk.StopLoss = 0.1
+	trader.Pairs = makePairs(makePair(withUsers(t, config, user)))
+	_, err := trader.Ledger.Fill(domain.OrderInfo{Username: user.Username, Name: "name",
+		Side: string(domain.Buy), Price: 10, Amount: 2})
+	assert.NoError(t, err)
+	trader.Ledger.Mark("name", 8)
+
+	exits, _ := trader.checkTick(PairTick{Name: "name", Interval: domain.Candle1m,
+		Candle: domain.Candle{Time: 5, Close: 8}})
+	assert.Len(t, exits, 1)
+	traceID := domain.NewExitTraceID("name", domain.Candle1m, 5, user.Username,
+		domain.StopLossReason)
+	assert.Equal(t, exits[0].event.TraceID, traceID)
+	decision := <-trader.Auditor.events
+	assert.Equal(t, decision.TraceID, traceID)
+	assert.Equal(t, decision.Stage, domain.DecisionStage)
+	assert.JSONEq(t, string(decision.Data),
+		`{"check":"exit","reason":"stop-loss","volume":2,"position":2}`)
+}
+
+func TestAlgoTrader_decideWithoutSize(t *testing.T) {
+	trader := setupTrader()
+	user := domain.NewUser("username")
+	// ATR isn't set before the first candle
+	trader.Pairs = makePairs(makePair(withUsers(t, atrConfig, user)))
+	event := domain.StockMarketEvent{Signal: domain.OpenShort, Name: "name",
+		Interval: domain.Candle1m, Username: user.Username, Close: 10}
+	_, _, err := trader.decide(event)
+	assert.ErrorIs(t, err, domain.ErrATRIsNotSet)
+	event.Signal = domain.ReverseToShort
+	_, _, err = trader.decide(event)
+	assert.ErrorIs(t, err, domain.ErrATRIsNotSet)
+
+	_, err = trader.Ledger.Fill(domain.OrderInfo{Username: user.Username, Name: "name",
+		Side: string(domain.Buy), Price: 10, Amount: 3})
+	assert.NoError(t, err)
+	// close is sized by position
+	event.Signal = domain.CloseLong
+	decided, _, err := trader.decide(event)
+	assert.NoError(t, err)
+	assert.Equal(t, decided.Signal, domain.CloseLong)
+	assert.Equal(t, decided.Volume, int64(3))
+	// reverse is shrunk to close
+	event.Signal = domain.ReverseToShort
+	decided, _, err = trader.decide(event)
+	assert.NoError(t, err)
+	assert.Equal(t, decided.Signal, domain.CloseLong)
+	assert.Equal(t, decided.Volume, int64(3))
+}
+
+func TestAlgoTrader_checkMode(t *testing.T) {
+	trader := setupTrader()
+	pair := makePair()
+	paperConfig := config
+	paperConfig.Mode = domain.PaperMode
+	assert.NoError(t, pair.AddUser(domain.NewUser("username"), paperConfig))
+	trader.Pairs[pair.Name] = map[domain.CandleInterval]*Pair{pair.Interval: pair}
+
+	liveConfig := config
+	liveConfig.PairInterval = domain.Candle5m
+	assert.ErrorIs(t, trader.checkMode("username", liveConfig), ErrModeConflict)
+	assert.NoError(t, trader.checkMode("other", liveConfig))
+	paperConfig.PairInterval = domain.Candle5m
+	assert.NoError(t, trader.checkMode("username", paperConfig))
+}
+
+func TestAlgoTrader_AddPairWithoutLock(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockUserRepository(c)
+	socket := NewMockStockMarketSocket(c)
+	trader := setupTrader()
+	trader.Users = repo
+	trader.socket = socket
+	// history is requested without pairs lock
+	trader.history = historyFunc(func(string, domain.CandleInterval, int64) ([]domain.Candle, error) {
+		trader.muPairs.Lock()
+		trader.muPairs.Unlock()
+		return []domain.Candle{{Open: 1, High: 1, Low: 1, Close: 1, Time: 1}}, nil
+	})
+	trader.backoff = Backoff{Min: 10 * time.Millisecond, Max: 10 * time.Millisecond, Factor: 1}
+	user := domain.NewUser("username")
+	other := domain.NewUser("other")
+	repo.EXPECT().GetUser(user.Username).Return(user, nil)
+	repo.EXPECT().GetUser(other.Username).Return(other, nil)
+	gomock.InOrder(
+		socket.EXPECT().SubscribeCandle(gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any()).DoAndReturn(func(context.Context, Pair, chan domain.Candle,
+			chan PairError) error {
+			// pairs are available while connection is retried
+			trader.muPairs.Lock()
+			assert.False(t, trader.Pairs.IsExist(config.PairName, config.PairInterval))
+			trader.muPairs.Unlock()
+			return ErrConnectionIsLost
+		}),
+		socket.EXPECT().SubscribeCandle(gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any()).DoAndReturn(func(ctx context.Context, _ Pair, candles chan domain.Candle,
+			_ chan PairError) error {
+			go func() {
+				<-ctx.Done()
+				close(candles)
+			}()
+			return nil
+		}),
+	)
+
+	assert.NoError(t, trader.addPair(user.Username, config))
+	assert.True(t, trader.Pairs.IsExist(config.PairName, config.PairInterval))
+	assert.Equal(t, domain.LiveMode, trader.Modes.Mode(user.Username, config.PairName))
+
+	// user of running pair is warmed up before it's added
+	assert.NoError(t, trader.addPair(other.Username, config))
+	running := trader.Pairs[config.PairName][config.PairInterval]
+	assert.True(t, running.IsUserLogged(other))
+	running.muIndicators.Lock()
+	assert.Equal(t, int32(1), running.Indicators[other.Username].(*domain.Donchian).CandleQueue.Len())
+	running.muIndicators.Unlock()
+	trader.Pairs.Shutdown(trader.wg)
+}
+
+func TestAlgoTrader_DeletePairWhileTicking(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockUserRepository(c)
+	socket := NewMockStockMarketSocket(c)
+	trader := setupTrader()
+	trader.Users = repo
+	user := domain.NewUser("username")
+	repo.EXPECT().GetUser(user.Username).Return(user, nil)
+	repo.EXPECT().DeleteSubscription(user.Username, config).Return(nil)
+	socket.EXPECT().SubscribeCandle(gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any()).DoAndReturn(func(ctx context.Context, _ Pair, candles chan domain.Candle,
+		_ chan PairError) error {
+		go func() {
+			defer close(candles)
+			for i := int64(1); ; i++ {
+				select {
+				case <-ctx.Done():
+					return
+				case candles <- domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: i}:
+				}
+			}
+		}()
+		return nil
+	})
+	pair := makePair()
+	pair.socket = socket
+	assert.NoError(t, pair.AddUser(user, config))
+	trader.Pairs[pair.Name] = map[domain.CandleInterval]*Pair{pair.Interval: pair}
+	assert.NoError(t, trader.RunPair(pair))
+
+	// trader's loop handles ticks, pair waits for it to send the next tick
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case tick := <-trader.ticks:
+				trader.stockTickHandler(tick)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	defer close(stop)
+	deleted := make(chan error)
+	go func() {
+		deleted <- trader.DeletePair(user.Username, config)
+	}()
+	select {
+	case err := <-deleted:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("pair deletion is deadlocked")
+	}
+	assert.False(t, trader.Pairs.IsExist(pair.Name, pair.Interval))
+}
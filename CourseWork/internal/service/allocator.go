@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"math"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+var ErrAllocationExceeded = errors.New("pair's allocation is exceeded")
+
+// Allocator splits users' budgets across their subscribed pairs and limits notional
+// of pairs' positions by their allocations.
+type Allocator struct {
+	ledger *Ledger
+}
+
+// NewAllocator returns pointer to Allocator, which counts positions by ledger.
+func NewAllocator(ledger *Ledger) *Allocator {
+	return &Allocator{ledger: ledger}
+}
+
+// Allocations returns user's allocation of every subscribed pair by pair's name.
+// It returns nil if user's allocation is disabled.
+func (allocator *Allocator) Allocations(user *domain.User, pairs Pairs) map[string]float64 {
+	allocation := user.GetRisk().Allocation
+	if !allocation.IsEnabled() {
+		return nil
+	}
+	return allocation.Split(pairs.Volatilities(user.Username))
+}
+
+// Check clips order volume, so notional of pair's position by event's close doesn't
+// exceed pair's allocation. Orders, which reduce position, always pass.
+func (allocator *Allocator) Check(event domain.StockMarketEvent, user *domain.User,
+	pairs Pairs) (domain.StockMarketEvent, error) {
+	allocations := allocator.Allocations(user, pairs)
+	if allocations == nil || event.Close <= 0 {
+		return event, nil
+	}
+	position, _ := allocator.ledger.Position(user.Username, event.Name)
+	maxPosition := int64(math.Floor(allocations[event.Name] / event.Close))
+	volume, err := clipVolume(event.Signal, orderVolume(event.Volume), position.Size, maxPosition)
+	if errors.Is(err, ErrMaxPositionSize) {
+		return event, ErrAllocationExceeded
+	}
+	if err != nil {
+		return event, err
+	}
+	event.Volume = volume
+	return event, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocator_Allocations(t *testing.T) {
+	user := domain.NewUser("username")
+	allocationPairs := makeAllocationPairs(t, user)
+	allocator := NewAllocator(NewLedger())
+	assert.Nil(t, allocator.Allocations(user, allocationPairs))
+
+	// risk parity is equal until volatilities are known
+	user.Risk.Allocation = domain.Allocation{Budget: 90, Mode: domain.RiskParityAllocation}
+	assert.Equal(t, allocator.Allocations(user, allocationPairs),
+		map[string]float64{"first": 45, "second": 45})
+
+	// volatility of 5m candle is divided by square root of 5 minutes
+	candle := domain.Candle{High: 11, Low: 9, Close: 10}
+	allocationPairs["first"][domain.Candle1m].Sizers[user.Username].Add(candle)
+	allocationPairs["second"][domain.Candle5m].Sizers[user.Username].Add(candle)
+	allocations := allocator.Allocations(user, allocationPairs)
+	assert.InDelta(t, allocations["first"], 90/(1+math.Sqrt(5)), 1e-9)
+	assert.InDelta(t, allocations["second"], 90*math.Sqrt(5)/(1+math.Sqrt(5)), 1e-9)
+}
+
+func TestAllocator_Check(t *testing.T) {
+	user := domain.NewUser("username")
+	allocationPairs := makeAllocationPairs(t, user)
+	ledger := NewLedger()
+	allocator := NewAllocator(ledger)
+	event := domain.StockMarketEvent{Signal: domain.OpenLong, Name: "first",
+		Volume: 10, Close: 10}
+
+	// disabled allocation doesn't clip orders
+	checked, err := allocator.Check(event, user, allocationPairs)
+	assert.NoError(t, err)
+	assert.Equal(t, checked.Volume, int64(10))
+
+	user.Risk.Allocation = domain.Allocation{Budget: 90}
+	checked, err = allocator.Check(event, user, allocationPairs)
+	assert.NoError(t, err)
+	assert.Equal(t, checked.Volume, int64(4))
+	_, err = ledger.Fill(domain.OrderInfo{Username: user.Username, Name: "first",
+		Side: string(domain.Buy), Price: 10, Amount: 4})
+	assert.NoError(t, err)
+	_, err = allocator.Check(event, user, allocationPairs)
+	assert.ErrorIs(t, err, ErrAllocationExceeded)
+
+	// reducing orders pass even if position exceeds allocation
+	user.Risk.Allocation.Budget = 20
+	checked, err = allocator.Check(domain.StockMarketEvent{Signal: domain.CloseLong,
+		Name: "first", Volume: 4, Close: 10}, user, allocationPairs)
+	assert.NoError(t, err)
+	assert.Equal(t, checked.Volume, int64(4))
+
+	// reverse is clipped by allocation of the new position
+	user.Risk.Allocation.Budget = 90
+	event.Signal = domain.ReverseToShort
+	checked, err = allocator.Check(event, user, allocationPairs)
+	assert.NoError(t, err)
+	assert.Equal(t, checked.Volume, int64(8))
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+const auditBuffer = 1024
+
+// Auditor appends audit events of trading decisions to AuditRepository in background,
+// so trading isn't blocked by db writes. Events are dropped when buffer is full.
+// Nil Auditor skips events.
+type Auditor struct {
+	repository AuditRepository
+	events     chan domain.AuditEvent
+	stop       chan struct{}
+	done       chan struct{}
+	log        *logrus.Logger
+}
+
+// NewAuditor returns pointer to Auditor, which writes events to repository.
+func NewAuditor(repository AuditRepository, log *logrus.Logger) *Auditor {
+	return &Auditor{
+		repository: repository,
+		events:     make(chan domain.AuditEvent, auditBuffer),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		log:        log,
+	}
+}
+
+// Run writes events until Shutdown, the rest buffered events are written before stop.
+func (auditor *Auditor) Run() {
+	go func() {
+		for {
+			select {
+			case event := <-auditor.events:
+				auditor.add(event)
+			case <-auditor.stop:
+				for {
+					select {
+					case event := <-auditor.events:
+						auditor.add(event)
+					default:
+						close(auditor.done)
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Write appends event in order of writing without blocking, it's skipped after Shutdown.
+// Event is dropped and counted by metrics if buffer is full.
+func (auditor *Auditor) Write(event domain.AuditEvent) {
+	if auditor == nil {
+		return
+	}
+	select {
+	case <-auditor.stop:
+		return
+	default:
+	}
+	select {
+	case auditor.events <- event:
+	default:
+		metrics.AuditDropped.Inc()
+		auditor.log.Printf("AUDIT: <%s> event of <%s> is dropped, buffer is full",
+			event.Stage, event.TraceID)
+	}
+}
+
+// Events returns user's audit events by AuditFilter.
+func (auditor *Auditor) Events(filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("can't validate audit filter <%w>", err)
+	}
+	events, err := auditor.repository.GetEvents(filter)
+	if err != nil {
+		return nil, fmt.Errorf("can't get audit events <%w>", err)
+	}
+	return events, nil
+}
+
+// Shutdown stops running Auditor after buffered events are written.
+func (auditor *Auditor) Shutdown() {
+	close(auditor.stop)
+	<-auditor.done
+}
+
+// add writes event to repository.
+func (auditor *Auditor) add(event domain.AuditEvent) {
+	if err := auditor.repository.AddEvent(event); err != nil {
+		auditor.log.Printf("AUDIT: <%s> event of <%s> isn't stored <%s>",
+			event.Stage, event.TraceID, err)
+	}
+}
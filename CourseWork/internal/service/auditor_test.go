@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/metrics"
+	mock_service "github.com/agandreev/tfs-go-hw/CourseWork/internal/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditor(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	repository := mock_service.NewMockAuditRepository(c)
+	first := domain.AuditEvent{TraceID: "first", Stage: domain.SignalStage}
+	second := domain.AuditEvent{TraceID: "first", Stage: domain.DecisionStage}
+	gomock.InOrder(
+		repository.EXPECT().AddEvent(first).Return(errors.New("db error")),
+		repository.EXPECT().AddEvent(second).Return(nil),
+	)
+	filter := domain.AuditFilter{Username: "username", OrderID: "id",
+		Limit: domain.DefaultAuditLimit}
+	repository.EXPECT().GetEvents(filter).Return([]domain.AuditEvent{first, second}, nil)
+
+	var nilAuditor *Auditor
+	nilAuditor.Write(first)
+
+	// buffered events are written by shutdown
+	auditor := NewAuditor(repository, log)
+	auditor.Write(first)
+	auditor.Write(second)
+	auditor.Run()
+	auditor.Shutdown()
+	auditor.Write(first)
+
+	events, err := auditor.Events(domain.AuditFilter{Username: "username", OrderID: "id"})
+	assert.NoError(t, err)
+	assert.Equal(t, events, []domain.AuditEvent{first, second})
+	_, err = auditor.Events(domain.AuditFilter{})
+	assert.Error(t, err)
+}
+
+func TestAuditor_Dropped(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	repository := mock_service.NewMockAuditRepository(c)
+	first := domain.AuditEvent{TraceID: "first", Stage: domain.SignalStage}
+	repository.EXPECT().AddEvent(first).Return(nil)
+	dropped := testutil.ToFloat64(metrics.AuditDropped)
+
+	// full buffer doesn't block writing
+	auditor := NewAuditor(repository, log)
+	auditor.events = make(chan domain.AuditEvent, 1)
+	auditor.Write(first)
+	auditor.Write(domain.AuditEvent{TraceID: "second", Stage: domain.SignalStage})
+	assert.Equal(t, testutil.ToFloat64(metrics.AuditDropped), dropped+1)
+	auditor.Run()
+	auditor.Shutdown()
+}
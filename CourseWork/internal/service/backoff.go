@@ -0,0 +1,36 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff counts exponentially growing delays between reconnections.
+// Every delay is randomly changed by Jitter part, so pairs don't reconnect at once.
+type Backoff struct {
+	Min    time.Duration
+	Max    time.Duration
+	Factor float64
+	Jitter float64
+}
+
+// DefaultBackoff returns delays from a second to half a minute.
+func DefaultBackoff() Backoff {
+	return Backoff{
+		Min:    time.Second,
+		Max:    30 * time.Second,
+		Factor: 2,
+		Jitter: 0.2,
+	}
+}
+
+// Delay returns delay before attempt, the first attempt is zero.
+func (backoff Backoff) Delay(attempt int64) time.Duration {
+	delay := float64(backoff.Min) * math.Pow(backoff.Factor, float64(attempt))
+	if delay > float64(backoff.Max) {
+		delay = float64(backoff.Max)
+	}
+	delay += delay * backoff.Jitter * (2*rand.Float64() - 1)
+	return time.Duration(delay)
+}
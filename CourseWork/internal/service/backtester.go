@@ -0,0 +1,105 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+// Backtester replays historical candles through Indicator and simulates orders.
+type Backtester struct {
+	Name      string
+	Interval  domain.CandleInterval
+	Indicator Indicator
+	// Limit is the same fraction which is used by KrakenAPI for limit price.
+	Limit float64
+	// Sizer counts order sizes like for running pairs.
+	Sizer *domain.Sizer
+}
+
+// NewBacktester returns pointer to Backtester with Indicator and Sizer from config.
+// Positive size replaces config's Sizing by fixed size.
+func NewBacktester(config domain.Config, size int64) (*Backtester, error) {
+	if config.Limit < 0 || config.Limit > 1 {
+		return nil, fmt.Errorf("limit is out of bounds")
+	}
+	indicator, err := NewIndicator(config.IndicatorName, config.Params)
+	if err != nil {
+		return nil, fmt.Errorf("can't create backtester: <%w>", err)
+	}
+	if size > 0 {
+		config.Sizing = domain.Sizing{Mode: domain.FixedSizing, Contracts: size}
+	}
+	sizer, err := domain.NewSizer(config.Sizing)
+	if err != nil {
+		return nil, fmt.Errorf("can't create backtester: <%w>", err)
+	}
+	return &Backtester{
+		Name:      config.PairName,
+		Interval:  config.PairInterval,
+		Indicator: indicator,
+		Limit:     config.Limit,
+		Sizer:     sizer,
+	}, nil
+}
+
+// position describes opened backtest position.
+type position struct {
+	side   domain.Signal
+	candle domain.Candle
+	price  float64
+	size   int64
+}
+
+// Run sends candles to Indicator and returns report about closed trades.
+// Orders are filled by limit price like KrakenAPI immediate-or-cancel orders.
+// Reverse signal closes trade and opens the opposite one by the same price.
+// Signals, which can't be sized, don't open positions.
+func (backtester *Backtester) Run(candles []domain.Candle) (domain.BacktestReport, error) {
+	trades := make([]domain.Trade, 0)
+	var opened *position
+	var pnl float64
+	machine := domain.PositionMachine{Direction: domain.BothDirection, State: domain.FlatState}
+	for _, candle := range candles {
+		signal, err := backtester.Indicator.Add(candle)
+		if err != nil {
+			if err == domain.ErrSameTimestamp {
+				continue
+			}
+			return domain.BacktestReport{}, fmt.Errorf("backtest is broken: <%w>", err)
+		}
+		backtester.Sizer.Add(candle)
+		target, ok := signal.Target(machine.State)
+		if !ok {
+			continue
+		}
+		signal = machine.Move(target)
+		if !signal.IsOrder() {
+			continue
+		}
+		event := newStockMarketEvent(backtester.Name, backtester.Interval, signal, candle)
+		price := countLimitPrice(event.Signal.Side(), event.Close, backtester.Limit)
+		if opened != nil {
+			trade := domain.NewTrade(opened.side, opened.candle, candle,
+				opened.price, price, opened.size)
+			trades = append(trades, trade)
+			pnl += trade.PnL
+			opened = nil
+		}
+		if target == domain.FlatState {
+			continue
+		}
+		size, err := backtester.Sizer.Size(event.Close, pnl)
+		if err != nil {
+			machine.Reset()
+			continue
+		}
+		opened = &position{
+			side:   event.Signal.Side(),
+			candle: candle,
+			price:  price,
+			size:   size,
+		}
+	}
+	return domain.NewBacktestReport(trades, len(candles)), nil
+}
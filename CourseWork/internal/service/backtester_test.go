@@ -0,0 +1,84 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBacktester(t *testing.T) {
+	config := domain.Config{PairName: "name", PairInterval: domain.Candle1m,
+		IndicatorName: DonchianName, Limit: 0.1}
+	backtester, err := NewBacktester(config, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, backtester.Indicator)
+
+	config.Params = domain.IndicatorParams{Period: -1}
+	config.IndicatorName = RSIName
+	_, err = NewBacktester(config, 1)
+	assert.Error(t, err)
+	config.IndicatorName = ""
+	_, err = NewBacktester(config, 1)
+	assert.Error(t, err)
+	config.IndicatorName = DonchianName
+	config.Limit = 2
+	_, err = NewBacktester(config, 1)
+	assert.Error(t, err)
+	config.Limit = 0
+	config.Sizing = domain.Sizing{Mode: domain.EquitySizing}
+	_, err = NewBacktester(config, 0)
+	assert.Error(t, err)
+	// fixed size replaces broken sizing
+	_, err = NewBacktester(config, 1)
+	assert.NoError(t, err)
+}
+
+func TestBacktester_Run(t *testing.T) {
+	backtester, err := NewBacktester(domain.Config{PairName: "name",
+		PairInterval: domain.Candle1m, IndicatorName: DonchianName, Limit: 0.1}, 2)
+	assert.NoError(t, err)
+	candles := []domain.Candle{
+		{High: 1, Low: 1, Close: 1, Time: 1},
+		{High: 1, Low: 1, Close: 1, Time: 2},
+		// high breakout: buy by 10 * 1.1
+		{High: 10, Low: 1, Close: 10, Time: 3},
+		{High: 10, Low: 1, Close: 10, Time: 3},
+		// low breakout: sell by 20 * 0.9
+		{High: 20, Low: 0.5, Close: 20, Time: 4},
+	}
+	report, err := backtester.Run(candles)
+	assert.NoError(t, err)
+	assert.Equal(t, report.Candles, len(candles))
+	assert.Equal(t, len(report.Trades), 1)
+	assert.InDelta(t, report.Trades[0].EntryPrice, 11, 1e-9)
+	assert.InDelta(t, report.Trades[0].ExitPrice, 18, 1e-9)
+	assert.InDelta(t, report.TotalPnL, 14, 1e-9)
+	assert.Equal(t, report.WinRate, 1.)
+
+	_, err = backtester.Run([]domain.Candle{{High: -1, Time: 5}})
+	assert.Error(t, err)
+}
+
+func TestBacktester_RunReverse(t *testing.T) {
+	backtester, err := NewBacktester(domain.Config{PairName: "name",
+		PairInterval: domain.Candle1m, IndicatorName: DonchianName,
+		Params: domain.IndicatorParams{Direction: domain.BothDirection}}, 1)
+	assert.NoError(t, err)
+	candles := []domain.Candle{
+		{High: 1, Low: 1, Close: 1, Time: 1},
+		{High: 1, Low: 1, Close: 1, Time: 2},
+		{High: 2, Low: 1, Close: 2, Time: 3},
+		// low breakout closes long and opens short
+		{High: 2, Low: 0.5, Close: 1, Time: 4},
+		{High: 3, Low: 0.6, Close: 3, Time: 5},
+	}
+	report, err := backtester.Run(candles)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(report.Trades))
+	assert.Equal(t, domain.Buy, report.Trades[0].Side)
+	assert.Equal(t, domain.Sell, report.Trades[1].Side)
+	assert.InDelta(t, report.Trades[1].EntryPrice, 1, 1e-9)
+	assert.InDelta(t, report.Trades[1].PnL, -2, 1e-9)
+	assert.InDelta(t, report.TotalPnL, -3, 1e-9)
+}
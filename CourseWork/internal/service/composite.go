@@ -0,0 +1,192 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+// FilteredIndicator is implemented by indicators, which read candles of other intervals.
+type FilteredIndicator interface {
+	AddFilter(candle domain.Candle) error
+}
+
+// filter keeps Indicator of filter's interval, its trend and the last order signal,
+// which isn't seen by primary candle yet.
+type filter struct {
+	config    domain.FilterConfig
+	indicator Indicator
+	trend     domain.Signal
+	fresh     domain.Signal
+}
+
+// CompositeIndicator combines signals of primary Indicator with filters
+// of other intervals. Filters only gate entries, open position is always closed
+// by opposite signal and blocked reverse only closes position. Primary Indicator
+// is reset, if its entry is blocked or position is moved by filter,
+// so it doesn't signal orphan exits. Position direction is inherited from primary Indicator,
+// so filter's entry of not allowed direction only closes the opposite position.
+type CompositeIndicator struct {
+	primary Indicator
+	filters []*filter
+	machine domain.PositionMachine
+}
+
+// NewCompositeIndicator returns pointer to CompositeIndicator by Config's indicator and filters.
+func NewCompositeIndicator(config domain.Config) (*CompositeIndicator, error) {
+	primary, err := NewIndicator(config.IndicatorName, config.Params)
+	if err != nil {
+		return nil, err
+	}
+	machine, err := domain.NewPositionMachine(config.Params.Direction)
+	if err != nil {
+		return nil, err
+	}
+	composite := &CompositeIndicator{
+		primary: primary,
+		filters: make([]*filter, 0, len(config.Filters)),
+		machine: machine,
+	}
+	for _, filterConfig := range config.Filters {
+		indicator, err := NewIndicator(filterConfig.IndicatorName, filterConfig.Params)
+		if err != nil {
+			return nil, fmt.Errorf("can't create filter <%s>: <%w>", filterConfig.Interval, err)
+		}
+		composite.filters = append(composite.filters, &filter{
+			config:    filterConfig,
+			indicator: indicator,
+		})
+	}
+	return composite, nil
+}
+
+// NewUserIndicator returns CompositeIndicator if Config has filters and Indicator otherwise.
+func NewUserIndicator(config domain.Config) (Indicator, error) {
+	if len(config.Filters) == 0 {
+		return NewIndicator(config.IndicatorName, config.Params)
+	}
+	return NewCompositeIndicator(config)
+}
+
+// Add adds candle of primary interval and returns combined signal.
+// Primary signal has priority over fresh signals of or filters.
+func (composite *CompositeIndicator) Add(candle domain.Candle) (domain.Signal, error) {
+	signal, err := composite.primary.Add(candle)
+	if err != nil {
+		return signal, err
+	}
+	target, ok := signal.Target(composite.machine.State)
+	byPrimary := ok
+	for _, filter := range composite.filters {
+		if !ok && len(filter.fresh) != 0 {
+			target, ok = filter.fresh.Target(composite.machine.State)
+		}
+		filter.fresh = ""
+	}
+	if !ok {
+		return composite.machine.Wait(), nil
+	}
+	if !composite.machine.Allows(target) {
+		target = domain.FlatState
+	}
+	if target != domain.FlatState && !composite.allows(target) {
+		if byPrimary {
+			composite.resetPrimary()
+		}
+		target = domain.FlatState
+	}
+	if !byPrimary {
+		composite.resetPrimary()
+	}
+	return composite.machine.Move(target), nil
+}
+
+// AddFilter adds candle to filters of candle's interval and updates their trends.
+func (composite *CompositeIndicator) AddFilter(candle domain.Candle) error {
+	for _, filter := range composite.filters {
+		if filter.config.Interval != candle.Interval {
+			continue
+		}
+		signal, err := filter.indicator.Add(candle)
+		if err != nil {
+			if err == domain.ErrSameTimestamp {
+				continue
+			}
+			return err
+		}
+		if !signal.IsOrder() {
+			continue
+		}
+		filter.trend = signal.Side()
+		if filter.config.RuleOrDefault() == domain.OrRule {
+			filter.fresh = signal
+		}
+	}
+	return nil
+}
+
+// Reset resets primary Indicator and entered position, filters' trends are kept.
+func (composite *CompositeIndicator) Reset() {
+	composite.resetPrimary()
+	composite.machine.Reset()
+	for _, filter := range composite.filters {
+		filter.fresh = ""
+	}
+}
+
+// MarshalJSON returns state of primary Indicator, trends of filters and position state.
+func (composite *CompositeIndicator) MarshalJSON() ([]byte, error) {
+	type filterState struct {
+		Interval  domain.CandleInterval `json:"interval"`
+		Rule      domain.FilterRule     `json:"rule"`
+		Trend     domain.Signal         `json:"trend,omitempty"`
+		Indicator Indicator             `json:"indicator"`
+	}
+	filters := make([]filterState, 0, len(composite.filters))
+	for _, filter := range composite.filters {
+		filters = append(filters, filterState{
+			Interval:  filter.config.Interval,
+			Rule:      filter.config.RuleOrDefault(),
+			Trend:     filter.trend,
+			Indicator: filter.indicator,
+		})
+	}
+	return json.Marshal(struct {
+		Primary Indicator            `json:"primary"`
+		Filters []filterState        `json:"filters"`
+		State   domain.PositionState `json:"state"`
+	}{
+		Primary: composite.primary,
+		Filters: filters,
+		State:   composite.machine.State,
+	})
+}
+
+// allows checks entry of target position by and and veto filters.
+func (composite *CompositeIndicator) allows(target domain.PositionState) bool {
+	direction := domain.Buy
+	if target == domain.ShortState {
+		direction = domain.Sell
+	}
+	for _, filter := range composite.filters {
+		switch filter.config.RuleOrDefault() {
+		case domain.AndRule:
+			if filter.trend != direction {
+				return false
+			}
+		case domain.VetoRule:
+			if filter.trend == direction.Opposite() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// resetPrimary resets primary Indicator, if it remembers entered position.
+func (composite *CompositeIndicator) resetPrimary() {
+	if resetter, ok := composite.primary.(Resetter); ok {
+		resetter.Reset()
+	}
+}
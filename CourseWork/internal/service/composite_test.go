@@ -0,0 +1,179 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptedIndicator returns scripted signals and counts resets.
+type scriptedIndicator struct {
+	signals []domain.Signal
+	resets  int
+}
+
+func (indicator *scriptedIndicator) Add(domain.Candle) (domain.Signal, error) {
+	if len(indicator.signals) == 0 {
+		return domain.WaitToBuy, nil
+	}
+	signal := indicator.signals[0]
+	indicator.signals = indicator.signals[1:]
+	return signal, nil
+}
+
+func (indicator *scriptedIndicator) Reset() {
+	indicator.resets++
+}
+
+func newScriptedComposite(primary *scriptedIndicator, rule domain.FilterRule,
+	filterSignals ...domain.Signal) *CompositeIndicator {
+	return &CompositeIndicator{
+		primary: primary,
+		filters: []*filter{{
+			config:    domain.FilterConfig{Interval: domain.Candle10m, Rule: rule},
+			indicator: &scriptedIndicator{signals: filterSignals},
+		}},
+	}
+}
+
+func TestNewUserIndicator(t *testing.T) {
+	indicator, err := NewUserIndicator(domain.Config{IndicatorName: DonchianName})
+	assert.NoError(t, err)
+	assert.IsType(t, &domain.Donchian{}, indicator)
+
+	indicator, err = NewUserIndicator(domain.Config{IndicatorName: DonchianName,
+		Filters: []domain.FilterConfig{{Interval: domain.Candle10m, IndicatorName: RSIName}}})
+	assert.NoError(t, err)
+	assert.IsType(t, &CompositeIndicator{}, indicator)
+
+	_, err = NewUserIndicator(domain.Config{IndicatorName: DonchianName,
+		Filters: []domain.FilterConfig{{Interval: domain.Candle10m, IndicatorName: "unknown"}}})
+	assert.Error(t, err)
+}
+
+func TestCompositeIndicator_And(t *testing.T) {
+	primary := &scriptedIndicator{signals: []domain.Signal{domain.OpenLong, domain.OpenLong,
+		domain.CloseLong}}
+	composite := newScriptedComposite(primary, domain.AndRule, domain.Buy, domain.Sell)
+	filterCandle := domain.Candle{Interval: domain.Candle10m}
+
+	// unknown trend blocks entry and primary forgets it
+	signal, err := composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WaitToBuy, signal)
+	assert.Equal(t, 1, primary.resets)
+
+	// candles of other intervals are skipped
+	assert.NoError(t, composite.AddFilter(domain.Candle{Interval: domain.Candle1h}))
+	assert.NoError(t, composite.AddFilter(filterCandle))
+	signal, err = composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OpenLong, signal)
+
+	// exit isn't filtered
+	assert.NoError(t, composite.AddFilter(filterCandle))
+	signal, err = composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CloseLong, signal)
+	assert.Equal(t, 1, primary.resets)
+}
+
+func TestCompositeIndicator_Reverse(t *testing.T) {
+	primary := &scriptedIndicator{signals: []domain.Signal{domain.OpenLong, domain.ReverseToShort}}
+	composite := newScriptedComposite(primary, domain.AndRule, domain.Buy)
+	assert.NoError(t, composite.AddFilter(domain.Candle{Interval: domain.Candle10m}))
+
+	signal, err := composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OpenLong, signal)
+
+	// blocked reverse only closes position
+	signal, err = composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CloseLong, signal)
+	assert.Equal(t, 1, primary.resets)
+}
+
+func TestCompositeIndicator_Veto(t *testing.T) {
+	primary := &scriptedIndicator{signals: []domain.Signal{domain.OpenLong, domain.CloseLong,
+		domain.OpenLong}}
+	composite := newScriptedComposite(primary, domain.VetoRule, domain.Sell)
+
+	// unknown trend doesn't veto
+	signal, err := composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OpenLong, signal)
+	signal, err = composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CloseLong, signal)
+
+	assert.NoError(t, composite.AddFilter(domain.Candle{Interval: domain.Candle10m}))
+	signal, err = composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WaitToBuy, signal)
+	assert.Equal(t, 1, primary.resets)
+}
+
+func TestCompositeIndicator_Or(t *testing.T) {
+	primary := &scriptedIndicator{signals: []domain.Signal{domain.WaitToBuy,
+		domain.OpenLong, domain.WaitToSell}}
+	composite := newScriptedComposite(primary, domain.OrRule, domain.Buy, domain.Sell)
+	filterCandle := domain.Candle{Interval: domain.Candle10m}
+
+	// filter enters by itself on the next primary candle
+	assert.NoError(t, composite.AddFilter(filterCandle))
+	signal, err := composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OpenLong, signal)
+	assert.Equal(t, 1, primary.resets)
+
+	// primary entry in the same direction is skipped
+	signal, err = composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WaitToSell, signal)
+
+	// filter exits and resets primary
+	assert.NoError(t, composite.AddFilter(filterCandle))
+	signal, err = composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CloseLong, signal)
+	assert.Equal(t, 2, primary.resets)
+
+	composite.Reset()
+	assert.Equal(t, 3, primary.resets)
+	assert.Equal(t, domain.Sell, composite.filters[0].trend)
+}
+
+func TestCompositeIndicator_MarshalJSON(t *testing.T) {
+	indicator, err := NewUserIndicator(domain.Config{IndicatorName: DonchianName,
+		Filters: []domain.FilterConfig{{Interval: domain.Candle10m, IndicatorName: SMACrossName}}})
+	assert.NoError(t, err)
+	state := indicatorState(indicator)
+	assert.Contains(t, string(state), `"primary":{"High"`)
+	assert.Contains(t, string(state), `"filters":[{"interval":"candles_trade_10m","rule":"and",`+
+		`"indicator":{"FastPeriod"`)
+	assert.Contains(t, string(state), `"state":"flat"`)
+}
+
+func TestCompositeIndicator_Direction(t *testing.T) {
+	composite, err := NewCompositeIndicator(domain.Config{IndicatorName: DonchianName,
+		Filters: []domain.FilterConfig{{Interval: domain.Candle10m, IndicatorName: RSIName}}})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.LongDirection, composite.machine.Direction)
+	composite, err = NewCompositeIndicator(domain.Config{IndicatorName: DonchianName,
+		Params:  domain.IndicatorParams{Direction: domain.BothDirection},
+		Filters: []domain.FilterConfig{{Interval: domain.Candle10m, IndicatorName: RSIName}}})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BothDirection, composite.machine.Direction)
+
+	// short entry of or filter isn't allowed for long primary
+	primary := &scriptedIndicator{signals: []domain.Signal{domain.WaitToBuy, domain.WaitToBuy}}
+	composite = newScriptedComposite(primary, domain.OrRule, domain.OpenShort)
+	composite.machine.Direction = domain.LongDirection
+	assert.NoError(t, composite.AddFilter(domain.Candle{Interval: domain.Candle10m}))
+	signal, err := composite.Add(domain.Candle{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WaitToBuy, signal)
+	assert.NotEqual(t, domain.ShortState, composite.machine.State)
+}
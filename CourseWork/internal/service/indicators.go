@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+const (
+	DonchianName  = "Donchian"
+	RSIName       = "RSI"
+	MACDName      = "MACD"
+	BollingerName = "Bollinger"
+	SMACrossName  = "SMACross"
+)
+
+// IndicatorFactory creates Indicator with parameters from Config.
+type IndicatorFactory func(params domain.IndicatorParams) (Indicator, error)
+
+var (
+	indicators   = make(map[string]IndicatorFactory)
+	muIndicators = &sync.RWMutex{}
+)
+
+func init() {
+	RegisterIndicator(DonchianName, func(params domain.IndicatorParams) (Indicator, error) {
+		return domain.NewDonchian(params)
+	})
+	RegisterIndicator(RSIName, func(params domain.IndicatorParams) (Indicator, error) {
+		return domain.NewRSI(params)
+	})
+	RegisterIndicator(MACDName, func(params domain.IndicatorParams) (Indicator, error) {
+		return domain.NewMACD(params)
+	})
+	RegisterIndicator(BollingerName, func(params domain.IndicatorParams) (Indicator, error) {
+		return domain.NewBollinger(params)
+	})
+	RegisterIndicator(SMACrossName, func(params domain.IndicatorParams) (Indicator, error) {
+		return domain.NewSMACross(params)
+	})
+}
+
+// RegisterIndicator adds IndicatorFactory by indicator's name.
+// Factory with the same name is replaced.
+func RegisterIndicator(name string, factory IndicatorFactory) {
+	muIndicators.Lock()
+	defer muIndicators.Unlock()
+	indicators[name] = factory
+}
+
+// NewIndicator returns Indicator by its name and parameters.
+func NewIndicator(name string, params domain.IndicatorParams) (Indicator, error) {
+	muIndicators.RLock()
+	factory, ok := indicators[name]
+	muIndicators.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("such indicator doesn't exist")
+	}
+	indicator, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("can't create indicator <%s>: <%w>", name, err)
+	}
+	return indicator, nil
+}
+
+// IndicatorNames returns sorted names of registered indicators.
+func IndicatorNames() []string {
+	muIndicators.RLock()
+	defer muIndicators.RUnlock()
+	names := make([]string, 0, len(indicators))
+	for name := range indicators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIndicator(t *testing.T) {
+	for _, name := range []string{DonchianName, RSIName, MACDName, BollingerName, SMACrossName} {
+		indicator, err := NewIndicator(name, domain.IndicatorParams{})
+		assert.NoError(t, err)
+		assert.NotNil(t, indicator)
+	}
+	_, err := NewIndicator("", domain.IndicatorParams{})
+	assert.Error(t, err)
+	_, err = NewIndicator(RSIName, domain.IndicatorParams{Period: -1})
+	assert.Error(t, err)
+}
+
+func TestRegisterIndicator(t *testing.T) {
+	RegisterIndicator("test", func(domain.IndicatorParams) (Indicator, error) {
+		return &MockIndicator{}, nil
+	})
+	defer func() {
+		muIndicators.Lock()
+		delete(indicators, "test")
+		muIndicators.Unlock()
+	}()
+	indicator, err := NewIndicator("test", domain.IndicatorParams{})
+	assert.NoError(t, err)
+	assert.NotNil(t, indicator)
+	assert.Contains(t, IndicatorNames(), "test")
+}
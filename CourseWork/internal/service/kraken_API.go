@@ -10,47 +10,68 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/secret"
 )
 
 const (
-	timeout        = 10 * time.Second
-	orderURL       = "https://demo-futures.kraken.com/derivatives/api/v3/sendorder"
-	derivativePath = "/derivatives"
-	ioc            = "ioc"
-	success        = "success"
-	placed         = "placed"
+	timeout         = 10 * time.Second
+	DefaultOrderURL = "https://demo-futures.kraken.com/derivatives/api/v3/sendorder"
+	derivativePath  = "/derivatives"
+	cancelOrderPath = "cancelorder"
+	openOrdersPath  = "openorders"
+	fillsPath       = "fills"
+	positionsPath   = "openpositions"
+	accountsPath    = "accounts"
+	success         = "success"
+	placed          = "placed"
+	cancelled       = "cancelled"
 )
 
 // KrakenAPI implements Rest API communication with stock market.
 type KrakenAPI struct {
-	client *http.Client
+	client   *http.Client
+	orderURL string
+	keeper   *secret.Keeper
 }
 
-// NewKrakenAPI returns pointer to KrakenAPI.
-func NewKrakenAPI() *KrakenAPI {
+// NewKrakenAPI returns pointer to KrakenAPI, which sends orders to orderURL
+// and opens users' sealed private keys by keeper.
+// Cancel and open orders urls are siblings of orderURL.
+func NewKrakenAPI(orderURL string, keeper *secret.Keeper) *KrakenAPI {
 	return &KrakenAPI{
-		client: &http.Client{Timeout: timeout},
+		client:   &http.Client{Timeout: timeout},
+		orderURL: orderURL,
+		keeper:   keeper,
 	}
 }
 
-// getSign creates sign for stock market's private methods.
+// getSign creates sign for stock market's private methods,
+// sealed private key is opened only while signing.
 func (api *KrakenAPI) getSign(apiPath string, urlValues url.Values,
-	privateKey string) (string, error) {
+	sealedKey string) (string, error) {
 	sha := sha256.New()
 
 	if _, err := sha.Write([]byte(urlValues.Encode() + apiPath)); err != nil {
 		return "", fmt.Errorf("sha encoding error: <%w>", err)
 	}
-	decodedSecretKey, err := base64.StdEncoding.DecodeString(privateKey)
+	privateKey, err := api.keeper.Open(sealedKey)
+	if err != nil {
+		return "", fmt.Errorf("can't open private key: <%w>", err)
+	}
+	defer secret.Wipe(privateKey)
+	decodedSecretKey := make([]byte, base64.StdEncoding.DecodedLen(len(privateKey)))
+	defer secret.Wipe(decodedSecretKey)
+	n, err := base64.StdEncoding.Decode(decodedSecretKey, privateKey)
 	if err != nil {
 		return "", fmt.Errorf("base64 decoding error: <%w>", err)
 	}
-	mac := hmac.New(sha512.New, decodedSecretKey)
+	mac := hmac.New(sha512.New, decodedSecretKey[:n])
 
 	if _, err = mac.Write(sha.Sum(nil)); err != nil {
 		return "", fmt.Errorf("mac encoding error: <%w>", err)
@@ -58,33 +79,165 @@ func (api *KrakenAPI) getSign(apiPath string, urlValues url.Values,
 	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
 }
 
-// AddOrder adds order by StockMarketEvent.
+// AddOrder adds order by StockMarketEvent with its OrderOptions.
+// Order side is the side of event's signal and closing signals are reduce-only.
+// Stop and take-profit orders are limited by trigger price instead of close.
+// Post-only order is priced on the passive side of close, so it doesn't cross the market.
 func (api *KrakenAPI) AddOrder(event domain.StockMarketEvent, user *domain.User) (domain.OrderInfo, error) {
-	if event.Volume <= 0 {
-		event.Volume = 1
+	side := event.Signal.Side()
+	if len(side) == 0 {
+		return domain.OrderInfo{}, fmt.Errorf("unsupported order signal <%s>", event.Signal)
 	}
-	limitPrice := countLimitPrice(event.Signal, event.Close, user.GetLimit(event.Name))
+	event.Volume = orderVolume(event.Volume)
+	orderType := event.Order.OrderType()
 	urlValues := url.Values{
-		"symbol":     {strings.ToLower(event.Name)},
-		"side":       {string(event.Signal)},
-		"orderType":  {ioc},
-		"size":       {strconv.FormatInt(event.Volume, 10)},
-		"limitPrice": {fmt.Sprintf("%.1f", limitPrice)},
+		"symbol":    {strings.ToLower(event.Name)},
+		"side":      {string(side)},
+		"orderType": {string(orderType)},
+		"size":      {strconv.FormatInt(event.Volume, 10)},
+	}
+	price := event.Close
+	if orderType == domain.StopOrder || orderType == domain.TakeProfitOrder {
+		price = event.Order.TriggerPrice(side, event.Close)
+		urlValues.Set("stopPrice", fmt.Sprintf("%.1f", price))
+	}
+	limitPrice := countLimitPrice(side, price, user.GetLimit(event.Name))
+	if orderType == domain.PostOnlyOrder {
+		limitPrice = countLimitPrice(side.Opposite(), price, user.GetLimit(event.Name))
+	}
+	urlValues.Set("limitPrice", fmt.Sprintf("%.1f", limitPrice))
+	if event.Order.ReduceOnly || event.Signal.IsReduceOnly() {
+		urlValues.Set("reduceOnly", "true")
+	}
+
+	data, err := api.send(http.MethodPost, api.orderURL, urlValues, user)
+	if err != nil {
+		return domain.OrderInfo{}, err
+	}
+	var orderResponse domain.OrderResponse
+	err = json.Unmarshal(data, &orderResponse)
+	if err != nil {
+		return domain.OrderInfo{}, fmt.Errorf("can't unmarshal order response: <%w>", err)
+	}
+	if err = checkOrderResponse(orderResponse); err != nil {
+		return domain.OrderInfo{}, err
+	}
+
+	message, err := orderResponse.Message(event.Name)
+	if err != nil {
+		return domain.OrderInfo{}, fmt.Errorf(
+			"can't structurized order response: <%w>", err)
+	}
+	message.Size = event.Volume
+	if len(message.Type) == 0 {
+		message.Type = orderType
+	}
+	return message, nil
+}
+
+// CancelOrder cancels user's resting order by its id.
+func (api *KrakenAPI) CancelOrder(orderID string, user *domain.User) error {
+	cancelURL, err := siblingURL(api.orderURL, cancelOrderPath)
+	if err != nil {
+		return err
+	}
+	data, err := api.send(http.MethodPost, cancelURL, url.Values{"order_id": {orderID}}, user)
+	if err != nil {
+		return err
+	}
+	var cancelResponse domain.CancelResponse
+	if err = json.Unmarshal(data, &cancelResponse); err != nil {
+		return fmt.Errorf("can't unmarshal cancel response: <%w>", err)
+	}
+	if cancelResponse.Result != success || cancelResponse.CancelStatus == nil {
+		return fmt.Errorf("can't cancel order cause of stock market side problem")
+	}
+	if cancelResponse.CancelStatus.Status != cancelled {
+		return fmt.Errorf("can't cancel order because of <%s>",
+			cancelResponse.CancelStatus.Status)
+	}
+	return nil
+}
+
+// OpenOrders returns user's resting orders.
+func (api *KrakenAPI) OpenOrders(user *domain.User) ([]domain.OpenOrder, error) {
+	var openResponse domain.OpenOrdersResponse
+	if err := api.get(openOrdersPath, user, &openResponse); err != nil {
+		return nil, err
+	}
+	if openResponse.Result != success {
+		return nil, fmt.Errorf("can't get open orders cause of stock market side problem")
+	}
+	if openResponse.OpenOrders == nil {
+		return make([]domain.OpenOrder, 0), nil
+	}
+	return openResponse.OpenOrders, nil
+}
+
+// Fills returns user's recent fills.
+func (api *KrakenAPI) Fills(user *domain.User) ([]domain.Fill, error) {
+	var response domain.FillsResponse
+	if err := api.get(fillsPath, user, &response); err != nil {
+		return nil, err
+	}
+	if response.Result != success {
+		return nil, fmt.Errorf("can't get fills cause of stock market side problem")
 	}
+	return response.Fills, nil
+}
+
+// OpenPositions returns user's open positions.
+func (api *KrakenAPI) OpenPositions(user *domain.User) ([]domain.OpenPosition, error) {
+	var response domain.OpenPositionsResponse
+	if err := api.get(positionsPath, user, &response); err != nil {
+		return nil, err
+	}
+	if response.Result != success {
+		return nil, fmt.Errorf("can't get open positions cause of stock market side problem")
+	}
+	return response.OpenPositions, nil
+}
 
-	req, err := http.NewRequest("POST", orderURL, nil)
+// Accounts returns user's cash and margin accounts by their names.
+func (api *KrakenAPI) Accounts(user *domain.User) (map[string]domain.Account, error) {
+	var response domain.AccountsResponse
+	if err := api.get(accountsPath, user, &response); err != nil {
+		return nil, err
+	}
+	if response.Result != success {
+		return nil, fmt.Errorf("can't get accounts cause of stock market side problem")
+	}
+	return response.Accounts, nil
+}
+
+// get sends signed request without parameters to endpoint, which is sibling of
+// order url, and unmarshals response into v.
+func (api *KrakenAPI) get(name string, user *domain.User, v interface{}) error {
+	endpoint, err := siblingURL(api.orderURL, name)
 	if err != nil {
-		return domain.OrderInfo{}, fmt.Errorf("can't send order request: <%w>", err)
+		return err
 	}
-	parse, err := url.Parse(orderURL)
+	data, err := api.send(http.MethodGet, endpoint, url.Values{}, user)
 	if err != nil {
-		return domain.OrderInfo{}, fmt.Errorf("can't parse order url: <%w>", err)
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("can't unmarshal %s response: <%w>", name, err)
 	}
-	apiPath := parse.Path
-	apiPath = strings.TrimPrefix(apiPath, derivativePath)
+	return nil
+}
+
+// send signs request to private endpoint and returns response body.
+func (api *KrakenAPI) send(method, endpoint string, urlValues url.Values,
+	user *domain.User) ([]byte, error) {
+	req, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't send request: <%w>", err)
+	}
+	apiPath := strings.TrimPrefix(req.URL.Path, derivativePath)
 	signature, err := api.getSign(apiPath, urlValues, user.PrivateKey)
 	if err != nil {
-		return domain.OrderInfo{}, err
+		return nil, err
 	}
 	req.Header.Add("APIKey", user.PublicKey)
 	req.Header.Add("Authent", signature)
@@ -92,40 +245,25 @@ func (api *KrakenAPI) AddOrder(event domain.StockMarketEvent, user *domain.User)
 
 	resp, err := api.client.Do(req)
 	if err != nil {
-		return domain.OrderInfo{}, fmt.Errorf("can't send order request: <%w>", err)
+		return nil, fmt.Errorf("can't send request: <%w>", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK &&
 		resp.StatusCode != http.StatusCreated &&
 		resp.StatusCode != http.StatusAccepted {
-		return domain.OrderInfo{}, fmt.Errorf(
-			"order request is sended, but status code: <%d>", resp.StatusCode)
+		return nil, fmt.Errorf(
+			"request is sended, but status code: <%d>", resp.StatusCode)
 	}
-
 	data, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
 	if err != nil {
-		return domain.OrderInfo{}, fmt.Errorf("can't read order response: <%w>", err)
+		return nil, fmt.Errorf("can't read response: <%w>", err)
 	}
-	var orderResponse domain.OrderResponse
-	err = json.Unmarshal(data, &orderResponse)
-	if err != nil {
-		return domain.OrderInfo{}, fmt.Errorf("can't unmarshal order response: <%w>", err)
-	}
-	if err = checkOrderResponse(orderResponse); err != nil {
-		return domain.OrderInfo{}, err
-	}
-
-	message, err := orderResponse.Message(event.Name)
-	if err != nil {
-		return domain.OrderInfo{}, fmt.Errorf(
-			"can't structurized order response: <%w>", err)
-	}
-	return message, nil
+	return data, nil
 }
 
 // checkOrderResponse checks order status.
 func checkOrderResponse(response domain.OrderResponse) error {
-	if response.Result != success {
+	if response.Result != success || response.SendStatus == nil {
 		return fmt.Errorf("can't process order cause of stock market side problem")
 	}
 	if response.SendStatus.Status != placed {
@@ -134,6 +272,25 @@ func checkOrderResponse(response domain.OrderResponse) error {
 	return nil
 }
 
+// siblingURL replaces the last path element of url by name.
+func siblingURL(rawURL, name string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("can't parse order url: <%w>", err)
+	}
+	parsed.Path = path.Join(path.Dir(parsed.Path), name)
+	return parsed.String(), nil
+}
+
+// orderVolume returns minimal order size if volume isn't positive.
+func orderVolume(volume int64) int64 {
+	if volume <= 0 {
+		return 1
+	}
+	return volume
+}
+
+// countLimitPrice counts the worst acceptable price by order side and limit.
 func countLimitPrice(side domain.Signal, price float64, limit float64) float64 {
 	var limitPrice float64
 	switch side {
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/fakekraken"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKrakenAPI_AddOrder(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	privateKey := base64.StdEncoding.EncodeToString([]byte("private"))
+	server.AddKey("public", privateKey)
+	keeper := newKeeper()
+	api := NewKrakenAPI(server.OrderURL(), keeper)
+	event := domain.StockMarketEvent{Signal: domain.Buy, Name: "PI_XBTUSD", Close: 10}
+
+	user := domain.NewUser("username")
+	user.PublicKey = "public"
+	user.PrivateKey = privateKey
+	_, err := api.AddOrder(event, user)
+	assert.ErrorIs(t, err, secret.ErrMalformedSecret)
+	user.PrivateKey, err = keeper.Seal([]byte(privateKey))
+	assert.NoError(t, err)
+	info, err := api.AddOrder(event, user)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Amount, int64(1))
+	assert.Equal(t, info.Price, 10.)
+	assert.Equal(t, info.Side, string(domain.Buy))
+
+	user.PrivateKey, err = keeper.Seal([]byte(base64.StdEncoding.EncodeToString([]byte("wrong"))))
+	assert.NoError(t, err)
+	_, err = api.AddOrder(event, user)
+	assert.Error(t, err)
+	user.PublicKey = "wrong"
+	_, err = api.AddOrder(event, user)
+	assert.Error(t, err)
+}
+
+func TestKrakenAPI_OrderTypes(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	server.FillLimit = 2
+	privateKey := base64.StdEncoding.EncodeToString([]byte("private"))
+	server.AddKey("public", privateKey)
+	keeper := newKeeper()
+	api := NewKrakenAPI(server.OrderURL(), keeper)
+	user := domain.NewUser("username")
+	user.PublicKey = "public"
+	sealedKey, err := keeper.Seal([]byte(privateKey))
+	assert.NoError(t, err)
+	user.PrivateKey = sealedKey
+	assert.NoError(t, user.AddLimit("PI_XBTUSD", 0.1))
+
+	// partially filled limit order rests the rest
+	info, err := api.AddOrder(domain.StockMarketEvent{Signal: domain.Buy, Name: "PI_XBTUSD",
+		Volume: 5, Close: 10, Order: domain.OrderOptions{Type: domain.LimitOrder}}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Amount, int64(2))
+	assert.Equal(t, info.Size, int64(5))
+	assert.Equal(t, info.Type, domain.LimitOrder)
+
+	// partially filled ioc order cancels the rest
+	info, err = api.AddOrder(domain.StockMarketEvent{Signal: domain.Buy, Name: "PI_XBTUSD",
+		Volume: 3, Close: 10}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Amount, int64(2))
+	assert.Equal(t, info.Type, domain.IOCOrder)
+
+	// stop order is rested by trigger price
+	info, err = api.AddOrder(domain.StockMarketEvent{Signal: domain.Sell, Name: "PI_XBTUSD",
+		Volume: 1, Close: 10, Order: domain.OrderOptions{Type: domain.StopOrder,
+			Trigger: 0.1, ReduceOnly: true}}, user)
+	assert.NoError(t, err)
+	assert.Zero(t, info.Amount)
+	assert.Equal(t, info.Type, domain.StopOrder)
+	received := server.Orders()
+	assert.Equal(t, received[2].Get("stopPrice"), "9.0")
+	assert.Equal(t, received[2].Get("limitPrice"), "8.1")
+	assert.Equal(t, received[2].Get("reduceOnly"), "true")
+
+	orders, err := api.OpenOrders(user)
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), 2)
+	assert.Equal(t, orders[0].UnfilledSize, 3.)
+	assert.Equal(t, orders[1].OrderID, info.OrderID)
+	assert.True(t, orders[1].ReduceOnly)
+
+	assert.NoError(t, api.CancelOrder(info.OrderID, user))
+	assert.Error(t, api.CancelOrder(info.OrderID, user))
+	orders, err = api.OpenOrders(user)
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), 1)
+
+	// closing signal is reduce-only order of the opposite side
+	_, err = api.AddOrder(domain.StockMarketEvent{Signal: domain.CloseLong, Name: "PI_XBTUSD",
+		Volume: 1, Close: 10}, user)
+	assert.NoError(t, err)
+	_, err = api.AddOrder(domain.StockMarketEvent{Signal: domain.ReverseToShort, Name: "PI_XBTUSD",
+		Volume: 1, Close: 10}, user)
+	assert.NoError(t, err)
+	received = server.Orders()
+	assert.Equal(t, received[3].Get("side"), string(domain.Sell))
+	assert.Equal(t, received[3].Get("reduceOnly"), "true")
+	assert.Equal(t, received[4].Get("side"), string(domain.Sell))
+	assert.Empty(t, received[4].Get("reduceOnly"))
+
+	// post-only order is priced on the passive side of close
+	for _, signal := range []domain.Signal{domain.Buy, domain.Sell} {
+		_, err = api.AddOrder(domain.StockMarketEvent{Signal: signal, Name: "PI_XBTUSD",
+			Volume: 1, Close: 10, Order: domain.OrderOptions{Type: domain.PostOnlyOrder}}, user)
+		assert.NoError(t, err)
+	}
+	received = server.Orders()
+	assert.Equal(t, received[5].Get("limitPrice"), "9.0")
+	assert.Equal(t, received[6].Get("limitPrice"), "11.0")
+	_, err = api.AddOrder(domain.StockMarketEvent{Signal: domain.WaitToSell, Name: "PI_XBTUSD",
+		Volume: 1, Close: 10}, user)
+	assert.Error(t, err)
+}
+
+func TestSiblingURL(t *testing.T) {
+	sibling, err := siblingURL("https://host/derivatives/api/v3/sendorder", cancelOrderPath)
+	assert.NoError(t, err)
+	assert.Equal(t, sibling, "https://host/derivatives/api/v3/cancelorder")
+}
+
+func TestCountLimitPrice(t *testing.T) {
+	assert.InDelta(t, countLimitPrice(domain.Buy, 10, 0.1), 11, 1e-9)
+	assert.InDelta(t, countLimitPrice(domain.Sell, 10, 0.1), 9, 1e-9)
+	assert.Equal(t, countLimitPrice(domain.WaitToBuy, 10, 0.1), 10.)
+}
+
+func TestKrakenAPI_Account(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	privateKey := base64.StdEncoding.EncodeToString([]byte("private"))
+	server.AddKey("public", privateKey)
+	keeper := newKeeper()
+	api := NewKrakenAPI(server.OrderURL(), keeper)
+	user := domain.NewUser("username")
+	user.PublicKey = "public"
+	sealedKey, err := keeper.Seal([]byte(privateKey))
+	assert.NoError(t, err)
+	user.PrivateKey = sealedKey
+
+	_, err = api.AddOrder(domain.StockMarketEvent{Signal: domain.Sell, Name: "PI_XBTUSD",
+		Close: 10, Volume: 3}, user)
+	assert.NoError(t, err)
+	fills, err := api.Fills(user)
+	assert.NoError(t, err)
+	assert.Len(t, fills, 1)
+	assert.Equal(t, 3., fills[0].Size)
+	assert.Equal(t, string(domain.Sell), fills[0].Side)
+	positions, err := api.OpenPositions(user)
+	assert.NoError(t, err)
+	assert.Len(t, positions, 1)
+	assert.Equal(t, int64(-3), positions[0].SignedSize())
+	server.SetAccounts("public", map[string]domain.Account{
+		"cash": {Type: "cashAccount", Balances: map[string]float64{"xbt": 1}},
+	})
+	accounts, err := api.Accounts(user)
+	assert.NoError(t, err)
+	assert.Equal(t, 1., accounts["cash"].Balances["xbt"])
+
+	user.PublicKey = "wrong"
+	_, err = api.Fills(user)
+	assert.Error(t, err)
+}
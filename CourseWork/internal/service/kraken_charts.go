@@ -0,0 +1,116 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+const (
+	DefaultChartsURL = "https://futures.kraken.com/api/charts/v1/trade"
+)
+
+var (
+	ErrUnsupportedResolution = errors.New("interval isn't supported by charts")
+)
+
+// chartsResolutions consists of intervals, which charts endpoint returns.
+// Candles of other intervals are aggregated from 1m candles.
+var chartsResolutions = map[domain.CandleInterval]struct{}{
+	domain.Candle1m:  {},
+	domain.Candle5m:  {},
+	domain.Candle15m: {},
+	domain.Candle1h:  {},
+	domain.Candle4h:  {},
+	domain.Candle1d:  {},
+}
+
+// CandlesResponse describes JSON response of charts endpoint.
+type CandlesResponse struct {
+	Candles     []domain.Candle `json:"candles"`
+	MoreCandles bool            `json:"more_candles"`
+}
+
+// KrakenCharts implements public REST charts communication with stock market.
+type KrakenCharts struct {
+	client    *http.Client
+	chartsURL string
+}
+
+// NewKrakenCharts returns pointer to KrakenCharts, which requests chartsURL.
+func NewKrakenCharts(chartsURL string) *KrakenCharts {
+	return &KrakenCharts{
+		client:    &http.Client{Timeout: timeout},
+		chartsURL: chartsURL,
+	}
+}
+
+// Candles returns pair's candles of interval, which started at from or later.
+// from is in milliseconds like candle's time.
+func (charts *KrakenCharts) Candles(pairName string, interval domain.CandleInterval,
+	from int64) ([]domain.Candle, error) {
+	if _, ok := chartsResolutions[interval]; !ok {
+		return charts.aggregatedCandles(pairName, interval, from)
+	}
+	endpoint := charts.chartsURL + "/" + url.PathEscape(pairName) + "/" + interval.Resolution()
+	query := url.Values{}
+	query.Add("from", strconv.FormatInt(from/1000, 10))
+	resp, err := charts.client.Get(endpoint + "?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("can't get candles: <%w>", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read candles: <%w>", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can't get candles because of status <%d>", resp.StatusCode)
+	}
+	var response CandlesResponse
+	if err = json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("can't unmarshal candles: <%w>", err)
+	}
+	candles := make([]domain.Candle, 0, len(response.Candles))
+	for _, candle := range response.Candles {
+		if candle.Time < from {
+			continue
+		}
+		candle.Interval = interval
+		candles = append(candles, candle)
+	}
+	return candles, nil
+}
+
+// aggregatedCandles returns candles of interval, which isn't returned by charts,
+// aggregated from 1m candles of the same periods.
+func (charts *KrakenCharts) aggregatedCandles(pairName string, interval domain.CandleInterval,
+	from int64) ([]domain.Candle, error) {
+	duration, err := interval.Duration()
+	if err != nil {
+		return nil, fmt.Errorf("can't get candles <%s> <%w>", interval, ErrUnsupportedResolution)
+	}
+	step := duration.Milliseconds()
+	start := from - from%step
+	minutes, err := charts.Candles(pairName, domain.Candle1m, start)
+	if err != nil {
+		return nil, err
+	}
+	aggregated, err := domain.AggregateCandles(minutes, interval)
+	if err != nil {
+		return nil, fmt.Errorf("can't aggregate candles <%w>", err)
+	}
+	candles := make([]domain.Candle, 0, len(aggregated))
+	for _, candle := range aggregated {
+		if candle.Time >= from {
+			candles = append(candles, candle)
+		}
+	}
+	return candles, nil
+}
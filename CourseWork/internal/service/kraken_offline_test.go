@@ -0,0 +1,205 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/fakekraken"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/repository/users"
+	mock_service "github.com/agandreev/tfs-go-hw/CourseWork/internal/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlgoTrader_Offline(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	privateKey := base64.StdEncoding.EncodeToString([]byte("private"))
+	server.AddKey("public", privateKey)
+	server.AddCandles("PI_XBTUSD", domain.Candle1m,
+		domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 1},
+		domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 2},
+		domain.Candle{Open: 1, High: 10, Low: 1, Close: 10, Time: 3},
+		domain.Candle{Open: 10, High: 10, Low: 1, Close: 10, Time: 3},
+		domain.Candle{Open: 10, High: 20, Low: 0.5, Close: 20, Time: 4},
+	)
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	var stored int32
+	orders := mock_service.NewMockOrderRepository(c)
+	orders.EXPECT().Connect().Return(nil)
+	orders.EXPECT().AddOrder(gomock.Any()).DoAndReturn(func(info domain.OrderInfo) error {
+		assert.Equal(t, info.Username, "username")
+		assert.Equal(t, info.Interval, domain.Candle1m)
+		assert.Equal(t, info.Indicator, DonchianName)
+		assert.False(t, info.CreatedAt.IsZero())
+		atomic.AddInt32(&stored, 1)
+		return nil
+	}).Times(2)
+	orders.EXPECT().Shutdown()
+	var closed int32
+	candles := mock_service.NewMockCandleRepository(c)
+	candles.EXPECT().Connect().Return(nil)
+	candles.EXPECT().GetCandles(gomock.Any()).Return(nil, errors.New("no candles")).AnyTimes()
+	candles.EXPECT().AddCandle("PI_XBTUSD", gomock.Any()).DoAndReturn(
+		func(pairName string, candle domain.Candle) error {
+			assert.Equal(t, candle.Interval, domain.Candle1m)
+			atomic.AddInt32(&closed, 1)
+			return nil
+		}).AnyTimes()
+	candles.EXPECT().Shutdown()
+	muAudit := &sync.Mutex{}
+	auditEvents := make([]domain.AuditEvent, 0)
+	audit := mock_service.NewMockAuditRepository(c)
+	audit.EXPECT().Connect().Return(nil)
+	audit.EXPECT().AddEvent(gomock.Any()).DoAndReturn(func(event domain.AuditEvent) error {
+		muAudit.Lock()
+		auditEvents = append(auditEvents, event)
+		muAudit.Unlock()
+		return nil
+	}).AnyTimes()
+	audit.EXPECT().Shutdown()
+	userStorage, err := users.NewUserStorage("key", 1)
+	assert.NoError(t, err)
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	trader := NewAlgoTrader(userStorage, orders, candles, audit, Endpoints{
+		SocketURL: server.SocketURL(),
+		OrderURL:  server.OrderURL(),
+	}, newKeeper(), log, 1)
+	assert.NoError(t, trader.Run())
+
+	user := domain.NewUser("username")
+	user.Password = "password"
+	user.PublicKey = "public"
+	user.PrivateKey = privateKey
+	assert.NoError(t, trader.AddUser(*user))
+	assert.NoError(t, trader.AddPair(user.Username, domain.Config{
+		PairName:      "PI_XBTUSD",
+		PairInterval:  domain.Candle1m,
+		IndicatorName: DonchianName,
+		Limit:         0.1,
+	}))
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&stored) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	received := server.Orders()
+	assert.Equal(t, len(received), 2)
+	assert.Equal(t, received[0].Get("side"), string(domain.Buy))
+	assert.Equal(t, received[0].Get("limitPrice"), "11.0")
+	assert.Equal(t, received[1].Get("side"), string(domain.Sell))
+	assert.Equal(t, received[1].Get("symbol"), "pi_xbtusd")
+	portfolio := trader.Portfolio(user.Username)
+	assert.Equal(t, len(portfolio.Positions), 1)
+	assert.Equal(t, portfolio.Positions[0].Size, int64(0))
+	assert.Positive(t, atomic.LoadInt32(&closed))
+
+	trader.ShutDown()
+	// the whole chain of the first order shares trace id
+	var orderID string
+	for _, event := range auditEvents {
+		if event.Stage == domain.ResponseStage {
+			orderID = event.OrderID
+			break
+		}
+	}
+	assert.NotEmpty(t, orderID)
+	stages := make([]domain.AuditStage, 0)
+	var traceID string
+	for _, event := range auditEvents {
+		if event.OrderID == orderID {
+			traceID = event.TraceID
+		}
+	}
+	for _, event := range auditEvents {
+		if event.TraceID == traceID {
+			stages = append(stages, event.Stage)
+		}
+	}
+	assert.Equal(t, stages, []domain.AuditStage{domain.SignalStage, domain.DecisionStage,
+		domain.OrderStage, domain.ResponseStage, domain.StorageStage})
+}
+
+func TestAlgoTrader_RestorePairs(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	orders := mock_service.NewMockOrderRepository(c)
+	orders.EXPECT().Connect().Return(nil)
+	orders.EXPECT().GetOrders(domain.OrderFilter{Username: "username",
+		Limit: domain.MaxOrdersLimit}).Return(domain.OrderPage{
+		Orders: []domain.OrderInfo{{ID: 2, Username: "username", Name: "PI_XBTUSD",
+			Side: string(domain.Buy), Price: 10, Amount: 1}},
+		NextCursor: 2,
+	}, nil)
+	orders.EXPECT().GetOrders(domain.OrderFilter{Username: "username",
+		Limit: domain.MaxOrdersLimit, Cursor: 2}).Return(domain.OrderPage{
+		Orders: []domain.OrderInfo{{ID: 1, Username: "username", Name: "PI_XBTUSD",
+			Side: string(domain.Buy), Price: 20, Amount: 1}},
+	}, nil)
+	orders.EXPECT().Shutdown()
+	candles := mock_service.NewMockCandleRepository(c)
+	candles.EXPECT().Connect().Return(nil)
+	candles.EXPECT().GetCandles(gomock.Any()).Return(nil, errors.New("no candles")).AnyTimes()
+	candles.EXPECT().AddCandle(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	candles.EXPECT().Shutdown()
+	audit := mock_service.NewMockAuditRepository(c)
+	audit.EXPECT().Connect().Return(nil)
+	audit.EXPECT().AddEvent(gomock.Any()).Return(nil).AnyTimes()
+	audit.EXPECT().Shutdown()
+	userStorage, err := users.NewUserStorage("key", 1)
+	assert.NoError(t, err)
+	user := domain.NewUser("username")
+	user.PublicKey = "public"
+	user.PrivateKey = "private"
+	assert.NoError(t, userStorage.AddUser(user))
+	config := domain.Config{
+		PairName:      "PI_XBTUSD",
+		PairInterval:  domain.Candle1m,
+		IndicatorName: DonchianName,
+		Limit:         0.1,
+		Mode:          domain.PaperMode,
+	}
+	assert.NoError(t, userStorage.AddSubscription(user.Username, config))
+	// broken subscription shouldn't stop restoring
+	assert.NoError(t, userStorage.AddSubscription(user.Username, domain.Config{
+		PairName:     "PI_ETHUSD",
+		PairInterval: domain.Candle1m,
+	}))
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	trader := NewAlgoTrader(userStorage, orders, candles, audit, Endpoints{
+		SocketURL: server.SocketURL(),
+		OrderURL:  server.OrderURL(),
+	}, newKeeper(), log, 0)
+	assert.NoError(t, trader.Run())
+
+	assert.True(t, trader.Pairs.IsExist(config.PairName, config.PairInterval))
+	assert.False(t, trader.Pairs.IsExist("PI_ETHUSD", domain.Candle1m))
+	assert.Equal(t, trader.Modes.Mode(user.Username, config.PairName), domain.PaperMode)
+	assert.Equal(t, user.GetLimit(config.PairName), config.Limit)
+	portfolio := trader.Portfolio(user.Username)
+	assert.Equal(t, len(portfolio.Positions), 1)
+	assert.Equal(t, portfolio.Positions[0].Size, int64(2))
+	assert.InDelta(t, portfolio.Positions[0].AvgPrice, 15, 1e-9)
+
+	assert.NoError(t, trader.DeletePair(user.Username, config))
+	subscriptions, err := userStorage.GetSubscriptions()
+	assert.NoError(t, err)
+	assert.Equal(t, len(subscriptions), 1)
+
+	trader.ShutDown()
+}
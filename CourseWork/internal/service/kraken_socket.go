@@ -2,22 +2,133 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/metrics"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	wsLink = "wss://futures.kraken.com/ws/v1?chart"
+	DefaultSocketURL        = "wss://futures.kraken.com/ws/v1?chart"
+	DefaultHeartbeatTimeout = 15 * time.Second
+	DefaultPingInterval     = 5 * time.Second
+	heartbeatFeed           = "heartbeat"
+	subscribeEvent          = "subscribe"
+	unsubscribeEvent        = "unsubscribe"
+	subscribedEvent         = "subscribed"
+	subscribedFailedEvent   = "subscribed_failed"
+	infoEvent               = "info"
+	errorEvent              = "error"
+	// candleBacklogSize limits candles queued for slow pair, the oldest ones are dropped
+	candleBacklogSize = 1024
 )
 
-// KrakenSocket implements socket connections to the stock market.
+var (
+	ErrConnectionIsLost      = errors.New("connection is lost")
+	ErrSubscriptionIsFailed  = errors.New("subscription is failed")
+	ErrSubscriptionIsTimeout = errors.New("subscription isn't confirmed")
+)
+
+// KrakenSocket multiplexes candle subscriptions of all pairs over one connection.
+// Connection is dialed by the first subscription and is closed after the last one.
+// Candles are routed to subscriptions by product id and feed.
+// Derived intervals share product's 1m feed and their candles are built by CandleGenerator.
+// Lost connection is restored with Backoff delays, all pairs are resubscribed
+// and their missed candles are backfilled from History, which is charts by default.
+// Connection is lost if neither message nor pong is read during HeartbeatTimeout.
+// Connection is dialed without muSubs and muWS, dialing is serialized by muDial.
 type KrakenSocket struct {
-	ws  *websocket.Conn
-	ctx context.Context
-	log *logrus.Logger
+	Backoff          Backoff
+	Reconnections    int64
+	HeartbeatTimeout time.Duration
+	PingInterval     time.Duration
+	History          CandleHistory
+	url              string
+	muSubs           *sync.Mutex
+	subs             map[string]*subscription
+	lastID           int64
+	muDial           *sync.Mutex
+	muWS             *sync.Mutex
+	ws               *websocket.Conn
+	reconnecting     bool
+	log              *logrus.Logger
+}
+
+// subscription describes pair's candle feed. Candles are queued by the reader without
+// blocking and are forwarded to pair until ctx is done, so slow pair doesn't stop others.
+// Queued update of candle is replaced by the next update of the same candle.
+// Connection's failure is forwarded to errors too, so it isn't sent after pair is stopped.
+// lastTime is time of the last feed candle.
+type subscription struct {
+	key        string
+	name       string
+	interval   domain.CandleInterval
+	feed       domain.CandleInterval
+	generator  *domain.CandleGenerator
+	muQueue    *sync.Mutex
+	queue      []domain.Candle
+	ready      chan struct{}
+	failed     chan error
+	errors     chan PairError
+	subscribed chan error
+	lastTime   int64
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// push queues candle and returns false, if the oldest queued candle is dropped.
+func (sub *subscription) push(candle domain.Candle) bool {
+	sub.muQueue.Lock()
+	kept := true
+	if last := len(sub.queue) - 1; last >= 0 && sub.queue[last].Time == candle.Time &&
+		sub.queue[last].Backfilled == candle.Backfilled {
+		sub.queue[last] = candle
+	} else {
+		sub.queue = append(sub.queue, candle)
+	}
+	if len(sub.queue) > candleBacklogSize {
+		sub.queue = sub.queue[1:]
+		kept = false
+	}
+	sub.muQueue.Unlock()
+	select {
+	case sub.ready <- struct{}{}:
+	default:
+	}
+	return kept
+}
+
+// pop returns all queued candles.
+func (sub *subscription) pop() []domain.Candle {
+	sub.muQueue.Lock()
+	defer sub.muQueue.Unlock()
+	candles := sub.queue
+	sub.queue = nil
+	return candles
+}
+
+// NewKrakenSocket returns pointer to KrakenSocket, which dials socket url
+// and backfills candles from charts url.
+func NewKrakenSocket(endpoints Endpoints, reconnections int64, log *logrus.Logger) *KrakenSocket {
+	return &KrakenSocket{
+		Backoff:          DefaultBackoff(),
+		Reconnections:    reconnections,
+		HeartbeatTimeout: DefaultHeartbeatTimeout,
+		PingInterval:     DefaultPingInterval,
+		History:          NewKrakenCharts(endpoints.ChartsURL),
+		url:              endpoints.SocketURL,
+		muSubs:           &sync.Mutex{},
+		subs:             make(map[string]*subscription),
+		muDial:           &sync.Mutex{},
+		muWS:             &sync.Mutex{},
+		log:              log,
+	}
 }
 
 // SubscriptionMessage describes JSON subscription response.
@@ -36,111 +147,517 @@ type CandleMessage struct {
 	Time      int64         `json:"time"`
 }
 
-// Connect dials to the socket.
-func (socket *KrakenSocket) Connect(ctx context.Context) error {
-	connection, response, err := websocket.DefaultDialer.Dial(wsLink, nil)
-	if err != nil {
-		socket.log.Printf("ERROR creating connection to url, %v", err)
-		return fmt.Errorf("error in socket connection: <%w>", err)
-	} else {
-		socket.log.Printf("SUCCESS: Connection established with %s  \n", wsLink)
-		socket.log.Printf("RESPONSE: %+v", *response)
-	}
-	socket.ws = connection
-	socket.ctx = ctx
-	return nil
+// socketMessage describes any message of the connection.
+type socketMessage struct {
+	Event      string        `json:"event"`
+	Feed       string        `json:"feed"`
+	ProductIDs []string      `json:"product_ids"`
+	ProductID  string        `json:"product_id"`
+	Candle     domain.Candle `json:"candle"`
+	Message    string        `json:"message"`
 }
 
-// SubscribeCandle subscribes candle's socket.
-func (socket KrakenSocket) SubscribeCandle(pair Pair, candles chan domain.Candle,
-	errors chan PairError) error {
-	subscriptionCandleRequest := SubscriptionMessage{
-		Event:      "subscribe",
-		Feed:       string(pair.Interval),
-		ProductIDs: []string{pair.Name},
-	}
+// Subscriptions returns quantity of subscribed pairs.
+func (socket *KrakenSocket) Subscriptions() int {
+	socket.muSubs.Lock()
+	defer socket.muSubs.Unlock()
+	return len(socket.subs)
+}
 
-	subscriptionHeartbeatRequest := SubscriptionMessage{
-		Event: "subscribe",
-		Feed:  "heartbeat",
+// Status returns state of the shared connection.
+func (socket *KrakenSocket) Status() domain.SocketStatus {
+	socket.muWS.Lock()
+	defer socket.muWS.Unlock()
+	switch {
+	case socket.reconnecting:
+		return domain.ReconnectingSocket
+	case socket.ws != nil:
+		return domain.ConnectedSocket
 	}
+	return domain.DisconnectedSocket
+}
 
-	err := socket.ws.WriteJSON(subscriptionHeartbeatRequest)
-	if err != nil {
-		return fmt.Errorf("error in heartbeat subscription: <%w>", err)
+// SubscribeCandle subscribes pair's candles, which are sent until ctx is done.
+// Then pair is unsubscribed and candles are closed.
+func (socket *KrakenSocket) SubscribeCandle(ctx context.Context, pair Pair,
+	candles chan domain.Candle, errors chan PairError) error {
+	subCtx, cancel := context.WithCancel(ctx)
+	sub := &subscription{
+		name:       pair.Name,
+		interval:   pair.Interval,
+		feed:       pair.Interval.Feed(),
+		muQueue:    &sync.Mutex{},
+		ready:      make(chan struct{}, 1),
+		failed:     make(chan error, 1),
+		errors:     errors,
+		subscribed: make(chan error, 1),
+		ctx:        subCtx,
+		cancel:     cancel,
+	}
+	if pair.Interval.IsDerived() {
+		generator, err := domain.NewCandleGenerator(pair.Interval)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("error in candle subscription: <%w>", err)
+		}
+		sub.generator = generator
+		socket.seed(sub)
+	}
+	confirmed, err := socket.subscribe(sub)
+	if err == nil && !confirmed {
+		select {
+		case err = <-sub.subscribed:
+		case <-time.After(socket.HeartbeatTimeout):
+			err = ErrSubscriptionIsTimeout
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
 	}
-
-	err = socket.ws.WriteJSON(subscriptionCandleRequest)
 	if err != nil {
+		socket.remove(sub)
+		cancel()
 		return fmt.Errorf("error in candle subscription: <%w>", err)
 	}
+	go socket.forward(sub, candles)
+	return nil
+}
 
-	err = socket.readInfoMessages()
-	if err != nil {
-		return fmt.Errorf("error in subscription reading: <%w>", err)
+// subscribe adds subscription and sends it by connection, which is dialed if it's absent.
+// Subscription is confirmed at once while connection is being restored,
+// it's resubscribed by reconnection. It's also confirmed at once,
+// if product's feed is already subscribed by another subscription.
+func (socket *KrakenSocket) subscribe(sub *subscription) (bool, error) {
+	socket.muSubs.Lock()
+	// the same interval could be subscribed by pair and by filter of another pair
+	socket.lastID++
+	sub.key = fmt.Sprintf("%s#%d", subscriptionKey(sub.name, sub.interval), socket.lastID)
+	socket.subs[sub.key] = sub
+	socket.muSubs.Unlock()
+	if err := socket.connect(); err != nil {
+		return false, err
 	}
+	socket.muSubs.Lock()
+	defer socket.muSubs.Unlock()
+	socket.muWS.Lock()
+	defer socket.muWS.Unlock()
+	if socket.reconnecting {
+		return true, nil
+	}
+	if socket.ws == nil {
+		return false, ErrConnectionIsLost
+	}
+	if len(socket.feedSubs(sub.name, sub.feed)) > 1 {
+		return true, nil
+	}
+	return false, socket.ws.WriteJSON(SubscriptionMessage{
+		Event:      subscribeEvent,
+		Feed:       string(sub.feed),
+		ProductIDs: []string{sub.name},
+	})
+}
 
-	socket.runPipeLine(pair, candles, errors)
-
+// connect dials connection, if it's absent and isn't being restored.
+func (socket *KrakenSocket) connect() error {
+	socket.muDial.Lock()
+	defer socket.muDial.Unlock()
+	socket.muWS.Lock()
+	connected := socket.ws != nil || socket.reconnecting
+	socket.muWS.Unlock()
+	if connected {
+		return nil
+	}
+	ws, err := socket.dial()
+	if err != nil {
+		return err
+	}
+	socket.muWS.Lock()
+	socket.ws = ws
+	socket.muWS.Unlock()
+	go socket.run(ws)
 	return nil
 }
 
-// runPipeLine runs goroutine for candle reading.
-func (socket KrakenSocket) runPipeLine(pair Pair, candles chan domain.Candle,
-	errors chan PairError) {
-	go func() {
-		defer close(candles)
-		candleMessage := CandleMessage{}
-		for {
+// remove deletes subscription and unsubscribes its feed, if feed isn't used by
+// other subscriptions. Connection is closed after the last subscription.
+func (socket *KrakenSocket) remove(sub *subscription) {
+	socket.muSubs.Lock()
+	defer socket.muSubs.Unlock()
+	if _, ok := socket.subs[sub.key]; !ok {
+		return
+	}
+	delete(socket.subs, sub.key)
+	socket.muWS.Lock()
+	defer socket.muWS.Unlock()
+	if socket.ws == nil || socket.reconnecting {
+		return
+	}
+	if len(socket.subs) == 0 {
+		_ = socket.ws.Close()
+		socket.ws = nil
+		socket.log.Printf("STOP: socket <%s> was closed without subscriptions", socket.url)
+		return
+	}
+	if len(socket.feedSubs(sub.name, sub.feed)) != 0 {
+		return
+	}
+	// error is found by reading
+	_ = socket.ws.WriteJSON(SubscriptionMessage{
+		Event:      unsubscribeEvent,
+		Feed:       string(sub.feed),
+		ProductIDs: []string{sub.name},
+	})
+}
+
+// forward sends queued candles to pair until subscription is done or failed.
+// Pair is stopped after its candles are closed, so failure is sent while it's running.
+func (socket *KrakenSocket) forward(sub *subscription, candles chan domain.Candle) {
+	defer close(candles)
+	defer sub.cancel()
+	defer socket.remove(sub)
+	for {
+		select {
+		case <-sub.ctx.Done():
+			return
+		case err := <-sub.failed:
 			select {
-			case <-socket.ctx.Done():
-				return
-			default:
-				if err := socket.ws.ReadJSON(&candleMessage); err != nil {
-					socket.log.Printf("ERROR: <%s> has lost connection <%s>", pair.Name, err)
-					errors <- PairError{
-						Name:     pair.Name,
-						Interval: pair.Interval,
-						Message:  err.Error(),
-					}
+			case <-sub.ctx.Done():
+			case sub.errors <- PairError{
+				Name:     sub.name,
+				Interval: sub.interval,
+				Message:  err.Error(),
+			}:
+			}
+			return
+		case <-sub.ready:
+			for _, candle := range sub.pop() {
+				select {
+				case <-sub.ctx.Done():
 					return
-				}
-				if !(candleMessage.Feed == "heartbeat") {
-					candles <- candleMessage.Candle
+				case candles <- candle:
 				}
 			}
 		}
-	}()
+	}
 }
 
-// readInfoMessages skips first info messages from socket.
-func (socket KrakenSocket) readInfoMessages() error {
-	subscriptionResponse := SubscriptionMessage{}
-	// wait for {event: "info", version: 1}
-	err := socket.ws.ReadJSON(&subscriptionResponse)
+// dial creates connection, checks info message and subscribes heartbeat.
+func (socket *KrakenSocket) dial() (*websocket.Conn, error) {
+	ws, response, err := websocket.DefaultDialer.Dial(socket.url, nil)
 	if err != nil {
+		socket.log.Printf("ERROR creating connection to url, %v", err)
+		return nil, fmt.Errorf("error in socket connection: <%w>", err)
+	}
+	socket.log.Printf("SUCCESS: Connection established with %s  \n", socket.url)
+	socket.log.Printf("RESPONSE: %+v", *response)
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(socket.HeartbeatTimeout))
+	})
+	if err = socket.handshake(ws); err != nil {
+		_ = ws.Close()
+		return nil, fmt.Errorf("error in socket connection: <%w>", err)
+	}
+	return ws, nil
+}
+
+// handshake waits for {event: "info", version: 1} and subscribes heartbeat.
+func (socket *KrakenSocket) handshake(ws *websocket.Conn) error {
+	if err := ws.SetReadDeadline(time.Now().Add(socket.HeartbeatTimeout)); err != nil {
+		return err
+	}
+	info := SubscriptionMessage{}
+	if err := ws.ReadJSON(&info); err != nil {
 		return err
 	}
-	if subscriptionResponse.Event != "info" || subscriptionResponse.Version != 1 {
+	if info.Event != infoEvent || info.Version != 1 {
 		return fmt.Errorf("incorrect info response")
 	}
-	// wait for {event: "subscribed"...}
-	err = socket.ws.ReadJSON(&subscriptionResponse)
-	if err != nil {
+	if err := ws.WriteJSON(SubscriptionMessage{Event: subscribeEvent,
+		Feed: heartbeatFeed}); err != nil {
+		return fmt.Errorf("error in heartbeat subscription: <%w>", err)
+	}
+	return nil
+}
+
+// run reads connection's messages until connection is closed without subscriptions.
+// Reconnections are counted until any feed message is read after them,
+// so subscriptions get PairError if all of them are failed.
+func (socket *KrakenSocket) run(ws *websocket.Conn) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go socket.ping(stop)
+	var attempts int64
+	for {
+		message := socketMessage{}
+		err := socket.read(ws, &message)
+		if err == nil {
+			if len(message.Event) == 0 {
+				attempts = 0
+			}
+			socket.route(message)
+			continue
+		}
+		socket.muWS.Lock()
+		closed := socket.ws != ws
+		socket.muWS.Unlock()
+		if closed {
+			return
+		}
+		socket.log.Printf("ERROR: socket <%s> has lost connection <%s>", socket.url, err)
+		if ws, attempts, err = socket.reconnect(ws, attempts, err); err != nil {
+			socket.fail(err)
+			return
+		}
+		if ws == nil {
+			return
+		}
+	}
+}
+
+// read reads the next message and prolongs heartbeat timeout.
+func (socket *KrakenSocket) read(ws *websocket.Conn, v interface{}) error {
+	if err := ws.ReadJSON(v); err != nil {
 		return err
 	}
-	if subscriptionResponse.Event != "subscribed" {
-		fmt.Println(subscriptionResponse)
-		return fmt.Errorf("subscription is failed")
+	return ws.SetReadDeadline(time.Now().Add(socket.HeartbeatTimeout))
+}
+
+// route sends candle to subscriptions of its feed and confirms subscriptions.
+func (socket *KrakenSocket) route(message socketMessage) {
+	switch message.Event {
+	case "":
+		if message.Feed == heartbeatFeed {
+			return
+		}
+		for _, sub := range socket.subscriptions(message.ProductID,
+			domain.CandleInterval(message.Feed)) {
+			if message.Candle.Time > sub.lastTime {
+				sub.lastTime = message.Candle.Time
+			}
+			socket.deliver(sub, message.Candle)
+		}
+	case subscribedEvent, subscribedFailedEvent:
+		var err error
+		if message.Event == subscribedFailedEvent {
+			err = ErrSubscriptionIsFailed
+		}
+		for _, productID := range message.ProductIDs {
+			for _, sub := range socket.subscriptions(productID,
+				domain.CandleInterval(message.Feed)) {
+				select {
+				case sub.subscribed <- err:
+				default:
+				}
+			}
+		}
+	case errorEvent:
+		socket.log.Printf("ERROR: socket <%s> <%s>", socket.url, message.Message)
 	}
-	// wait for {event: "subscribed"...}
-	err = socket.ws.ReadJSON(&subscriptionResponse)
+}
+
+// subscriptions returns subscriptions of product's feed.
+func (socket *KrakenSocket) subscriptions(productID string,
+	feed domain.CandleInterval) []*subscription {
+	socket.muSubs.Lock()
+	defer socket.muSubs.Unlock()
+	return socket.feedSubs(productID, feed)
+}
+
+// feedSubs returns subscriptions of product's feed, muSubs should be locked.
+func (socket *KrakenSocket) feedSubs(productID string,
+	feed domain.CandleInterval) []*subscription {
+	key := subscriptionKey(productID, feed)
+	subs := make([]*subscription, 0, 1)
+	for _, sub := range socket.subs {
+		if subscriptionKey(sub.name, sub.feed) == key {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// deliver queues candle without blocking the reader.
+// Feed candle of derived interval is merged by generator and derived candle
+// is queued only when its period is closed.
+func (socket *KrakenSocket) deliver(sub *subscription, candle domain.Candle) {
+	if sub.generator != nil {
+		closed, isClosed, err := sub.generator.Add(candle)
+		if err != nil {
+			socket.log.Printf("ERROR: candle of <%s> <%s> is skipped <%s>",
+				sub.name, sub.interval, err)
+			return
+		}
+		if !isClosed {
+			return
+		}
+		candle = closed
+	}
+	if !sub.push(candle) {
+		socket.log.Printf("ERROR: the oldest queued candle of <%s> <%s> is dropped",
+			sub.name, sub.interval)
+	}
+}
+
+// seed merges feed candles of the current period of derived interval from History,
+// so the first derived candle isn't partial. Subscription isn't seeded, if History fails.
+func (socket *KrakenSocket) seed(sub *subscription) {
+	from := sub.generator.Start(time.Now().UnixMilli())
+	candles, err := socket.History.Candles(sub.name, sub.feed, from)
+	if err != nil {
+		socket.log.Printf("ERROR: <%s> <%s> isn't seeded <%s>", sub.name, sub.interval, err)
+		return
+	}
+	for _, candle := range candles {
+		if _, _, err = sub.generator.Add(candle); err != nil {
+			socket.log.Printf("ERROR: <%s> <%s> isn't seeded <%s>", sub.name, sub.interval, err)
+			return
+		}
+		sub.lastTime = candle.Time
+	}
+}
+
+// ping sends ping messages to current connection every PingInterval until stop.
+func (socket *KrakenSocket) ping(stop chan struct{}) {
+	ticker := time.NewTicker(socket.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			socket.muWS.Lock()
+			if socket.ws != nil {
+				// error is found by reading
+				_ = socket.ws.WriteControl(websocket.PingMessage, nil,
+					time.Now().Add(socket.PingInterval))
+			}
+			socket.muWS.Unlock()
+		}
+	}
+}
+
+// reconnect redials with Backoff delays starting from attempt, resubscribes all
+// subscriptions and backfills their candles. It returns the new connection and
+// quantity of used attempts. Connection is nil if there are no subscriptions.
+func (socket *KrakenSocket) reconnect(lost *websocket.Conn, attempt int64,
+	err error) (*websocket.Conn, int64, error) {
+	socket.muWS.Lock()
+	socket.reconnecting = true
+	_ = lost.Close()
+	socket.muWS.Unlock()
+	for ; attempt < socket.Reconnections; attempt++ {
+		time.Sleep(socket.Backoff.Delay(attempt))
+		var ws *websocket.Conn
+		if ws, err = socket.dial(); err != nil {
+			metrics.Reconnects.WithLabelValues(metrics.Failure).Inc()
+			continue
+		}
+		var subs []*subscription
+		if subs, err = socket.resubscribe(ws); err != nil {
+			socket.log.Printf("ERROR: socket <%s> isn't resubscribed <%s>", socket.url, err)
+			metrics.Reconnects.WithLabelValues(metrics.Failure).Inc()
+			_ = ws.Close()
+			continue
+		}
+		metrics.Reconnects.WithLabelValues(metrics.Success).Inc()
+		if len(subs) == 0 {
+			_ = ws.Close()
+			return nil, 0, nil
+		}
+		socket.log.Printf("RECONNECT: <%d> subscriptions were resubscribed", len(subs))
+		for _, sub := range subs {
+			if err = socket.backfill(sub); err != nil {
+				socket.log.Printf("BACKFILL: candles of <%s> <%s> are skipped <%s>",
+					sub.name, sub.interval, err)
+			}
+		}
+		return ws, attempt + 1, nil
+	}
+	return nil, attempt, fmt.Errorf("%w after %d reconnections <%s>", ErrConnectionIsLost,
+		socket.Reconnections, err)
+}
+
+// resubscribe sends feeds of subscriptions by connection grouped by interval
+// and makes it current. It returns resubscribed subscriptions.
+func (socket *KrakenSocket) resubscribe(ws *websocket.Conn) ([]*subscription, error) {
+	socket.muSubs.Lock()
+	defer socket.muSubs.Unlock()
+	socket.muWS.Lock()
+	defer socket.muWS.Unlock()
+	subs := make([]*subscription, 0, len(socket.subs))
+	productIDs := make(map[domain.CandleInterval][]string)
+	feeds := make(map[string]struct{})
+	for _, sub := range socket.subs {
+		subs = append(subs, sub)
+		key := subscriptionKey(sub.name, sub.feed)
+		if _, ok := feeds[key]; ok {
+			continue
+		}
+		feeds[key] = struct{}{}
+		productIDs[sub.feed] = append(productIDs[sub.feed], sub.name)
+	}
+	for feed, ids := range productIDs {
+		if err := ws.WriteJSON(SubscriptionMessage{
+			Event:      subscribeEvent,
+			Feed:       string(feed),
+			ProductIDs: ids,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if len(subs) == 0 {
+		socket.ws = nil
+	} else {
+		socket.ws = ws
+	}
+	socket.reconnecting = false
+	return subs, nil
+}
+
+// fail passes error to all subscriptions, which send it to pairs and stop.
+func (socket *KrakenSocket) fail(err error) {
+	socket.muSubs.Lock()
+	subs := make([]*subscription, 0, len(socket.subs))
+	for _, sub := range socket.subs {
+		subs = append(subs, sub)
+	}
+	socket.muWS.Lock()
+	socket.ws = nil
+	socket.reconnecting = false
+	socket.muWS.Unlock()
+	socket.muSubs.Unlock()
+	for _, sub := range subs {
+		select {
+		case sub.failed <- err:
+		default:
+		}
+	}
+}
+
+// backfill queues candles, which were missed after the last one, from History.
+// Backfilled candles only update indicators, so trading is resumed by the next candle.
+// Missed feed candles of derived interval are merged by its generator.
+func (socket *KrakenSocket) backfill(sub *subscription) error {
+	if sub.lastTime == 0 {
+		return nil
+	}
+	missed, err := socket.History.Candles(sub.name, sub.feed, sub.lastTime)
 	if err != nil {
 		return err
 	}
-	if subscriptionResponse.Event != "subscribed" {
-		fmt.Println(subscriptionResponse)
-		return fmt.Errorf("subscription is failed")
+	for _, candle := range missed {
+		if candle.Time <= sub.lastTime {
+			continue
+		}
+		candle.Backfilled = true
+		sub.lastTime = candle.Time
+		socket.deliver(sub, candle)
 	}
+	socket.log.Printf("BACKFILL: <%d> candles of <%s> <%s> were received",
+		len(missed), sub.name, sub.interval)
 	return nil
 }
+
+// subscriptionKey returns case insensitive key of product's feed.
+func subscriptionKey(productID string, interval domain.CandleInterval) string {
+	return strings.ToUpper(productID) + "/" + string(interval)
+}
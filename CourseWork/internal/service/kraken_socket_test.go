@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/fakekraken"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSocket(server *fakekraken.Server) *KrakenSocket {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	socket := NewKrakenSocket(Endpoints{
+		SocketURL: server.SocketURL(),
+		ChartsURL: server.ChartsURL(),
+	}, 3, log)
+	socket.Backoff = Backoff{Min: 10 * time.Millisecond, Max: 50 * time.Millisecond, Factor: 2}
+	socket.HeartbeatTimeout = 300 * time.Millisecond
+	socket.PingInterval = 50 * time.Millisecond
+	return socket
+}
+
+func readCandle(t *testing.T, candles chan domain.Candle) domain.Candle {
+	select {
+	case candle := <-candles:
+		return candle
+	case <-time.After(5 * time.Second):
+		t.Fatal("candle isn't received")
+	}
+	return domain.Candle{}
+}
+
+func TestKrakenSocket_Multiplex(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	server.AddCandles("PI_XBTUSD", domain.Candle1m,
+		domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 60000})
+	server.AddCandles("PI_ETHUSD", domain.Candle5m,
+		domain.Candle{Open: 2, High: 2, Low: 2, Close: 2, Time: 300000})
+	socket := newTestSocket(server)
+	assert.Equal(t, domain.DisconnectedSocket, socket.Status())
+	xbtCtx, xbtCancel := context.WithCancel(context.Background())
+	ethCtx, ethCancel := context.WithCancel(context.Background())
+	xbtCandles := make(chan domain.Candle)
+	ethCandles := make(chan domain.Candle)
+	errors := make(chan PairError, 2)
+	assert.NoError(t, socket.SubscribeCandle(xbtCtx, Pair{Name: "PI_XBTUSD",
+		Interval: domain.Candle1m}, xbtCandles, errors))
+	assert.NoError(t, socket.SubscribeCandle(ethCtx, Pair{Name: "PI_ETHUSD",
+		Interval: domain.Candle5m}, ethCandles, errors))
+	assert.Equal(t, 2., readCandle(t, ethCandles).Close)
+	assert.Equal(t, 1., readCandle(t, xbtCandles).Close)
+	assert.Equal(t, 1, server.Connections())
+	assert.Equal(t, 2, socket.Subscriptions())
+	assert.Equal(t, domain.ConnectedSocket, socket.Status())
+
+	// the deleted pair is unsubscribed, the connection is kept for the rest
+	xbtCancel()
+	for range xbtCandles {
+	}
+	assert.Eventually(t, func() bool {
+		return server.Subscribed("PI_XBTUSD", domain.Candle1m) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, server.Subscribed("PI_ETHUSD", domain.Candle5m))
+	assert.Equal(t, 1, socket.Subscriptions())
+
+	// the last pair closes the connection and the next one dials again
+	ethCancel()
+	for range ethCandles {
+	}
+	assert.Eventually(t, func() bool {
+		return server.Subscribed("PI_ETHUSD", domain.Candle5m) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return socket.Status() == domain.DisconnectedSocket
+	}, time.Second, 10*time.Millisecond)
+	xbtCtx, xbtCancel = context.WithCancel(context.Background())
+	defer xbtCancel()
+	xbtCandles = make(chan domain.Candle)
+	assert.NoError(t, socket.SubscribeCandle(xbtCtx, Pair{Name: "PI_XBTUSD",
+		Interval: domain.Candle1m}, xbtCandles, errors))
+	assert.Equal(t, 1., readCandle(t, xbtCandles).Close)
+	assert.Equal(t, 2, server.Connections())
+	assert.Len(t, errors, 0)
+}
+
+func TestKrakenSocket_Derived(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	server.AddCandles("PI_XBTUSD", domain.Candle1m,
+		domain.Candle{Open: 1, High: 2, Low: 1, Close: 2, Volume: 1, Time: 960000},
+		domain.Candle{Open: 2, High: 3, Low: 0.5, Close: 1, Volume: 2, Time: 1020000},
+		domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Volume: 1, Time: 1800000})
+	socket := newTestSocket(server)
+	derivedCtx, derivedCancel := context.WithCancel(context.Background())
+	feedCtx, feedCancel := context.WithCancel(context.Background())
+	derivedCandles := make(chan domain.Candle)
+	feedCandles := make(chan domain.Candle)
+	errors := make(chan PairError, 2)
+	assert.NoError(t, socket.SubscribeCandle(derivedCtx, Pair{Name: "PI_XBTUSD",
+		Interval: domain.Candle15m}, derivedCandles, errors))
+	// derived candle is sent, when it's closed by the next period
+	assert.Equal(t, domain.Candle{Interval: domain.Candle15m, Open: 1, High: 3, Low: 0.5,
+		Close: 1, Volume: 3, Time: 900000}, readCandle(t, derivedCandles))
+
+	// 1m pair shares the feed of derived pair
+	assert.NoError(t, socket.SubscribeCandle(feedCtx, Pair{Name: "PI_XBTUSD",
+		Interval: domain.Candle1m}, feedCandles, errors))
+	assert.Equal(t, 1, server.Subscribed("PI_XBTUSD", domain.Candle1m))
+	assert.Equal(t, 2, socket.Subscriptions())
+
+	derivedCancel()
+	for range derivedCandles {
+	}
+	assert.Equal(t, 1, socket.Subscriptions())
+	assert.Equal(t, 1, server.Subscribed("PI_XBTUSD", domain.Candle1m))
+	feedCancel()
+	for range feedCandles {
+	}
+	assert.Eventually(t, func() bool {
+		return server.Subscribed("PI_XBTUSD", domain.Candle1m) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Len(t, errors, 0)
+}
+
+func TestKrakenSocket_Reconnect(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	server.Heartbeat = time.Hour
+	server.AddCandles("PI_XBTUSD", domain.Candle1m,
+		domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 60000},
+		domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 120000},
+	)
+	socket := newTestSocket(server)
+	pair := Pair{Name: "PI_XBTUSD", Interval: domain.Candle1m}
+	ctx, cancel := context.WithCancel(context.Background())
+	candles := make(chan domain.Candle)
+	errors := make(chan PairError, 1)
+	assert.NoError(t, socket.SubscribeCandle(ctx, pair, candles, errors))
+	assert.Equal(t, int64(60000), readCandle(t, candles).Time)
+	assert.Equal(t, int64(120000), readCandle(t, candles).Time)
+
+	// pongs keep connection without heartbeats
+	time.Sleep(2 * socket.HeartbeatTimeout)
+	assert.Equal(t, 1, server.Connections())
+
+	// the missed candle is backfilled before the stream
+	server.AddCandles("PI_XBTUSD", domain.Candle1m,
+		domain.Candle{Open: 1, High: 2, Low: 1, Close: 2, Time: 180000})
+	server.DropConnections()
+	candle := readCandle(t, candles)
+	assert.Equal(t, int64(180000), candle.Time)
+	assert.True(t, candle.Backfilled)
+	assert.Equal(t, 2., candle.Close)
+	candle = readCandle(t, candles)
+	assert.Equal(t, int64(60000), candle.Time)
+	assert.False(t, candle.Backfilled)
+	assert.Equal(t, 2, server.Connections())
+
+	cancel()
+	for range candles {
+	}
+	assert.Len(t, errors, 0)
+}
+
+func TestKrakenSocket_SlowPair(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	// more candles than the old queue of the slow pair
+	const count = 40
+	for i := int64(1); i <= count; i++ {
+		server.AddCandles("PI_XBTUSD", domain.Candle1m,
+			domain.Candle{Open: 1, High: 1, Low: 1, Close: float64(i), Time: i * 60000})
+	}
+	socket := newTestSocket(server)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	slowCandles := make(chan domain.Candle)
+	candles := make(chan domain.Candle)
+	errors := make(chan PairError, 2)
+	assert.NoError(t, socket.SubscribeCandle(ctx, Pair{Name: "PI_XBTUSD",
+		Interval: domain.Candle1m}, slowCandles, errors))
+	assert.NoError(t, socket.SubscribeCandle(ctx, Pair{Name: "PI_XBTUSD",
+		Interval: domain.Candle1m}, candles, errors))
+
+	// pair, which doesn't read candles, doesn't block another pair of the feed
+	var candle domain.Candle
+	for candle.Time != count*60000 {
+		candle = readCandle(t, candles)
+	}
+	assert.Equal(t, float64(count), candle.Close)
+	for candle = readCandle(t, slowCandles); candle.Time != count*60000; {
+		candle = readCandle(t, slowCandles)
+	}
+	assert.Len(t, errors, 0)
+}
+
+func TestKrakenSocket_HeartbeatTimeout(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	server.Heartbeat = time.Hour
+	server.MutePings = true
+	socket := newTestSocket(server)
+	socket.Reconnections = 1
+	pair := Pair{Name: "PI_XBTUSD", Interval: domain.Candle1m}
+	candles := make(chan domain.Candle)
+	errors := make(chan PairError, 1)
+	assert.NoError(t, socket.SubscribeCandle(context.Background(), pair, candles, errors))
+
+	// the only reconnection is dead too
+	select {
+	case pairErr := <-errors:
+		assert.Contains(t, pairErr.Message, ErrConnectionIsLost.Error())
+		assert.Equal(t, pair.Name, pairErr.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection isn't lost")
+	}
+	_, ok := <-candles
+	assert.False(t, ok)
+	assert.Equal(t, 2, server.Connections())
+	assert.Equal(t, 0, socket.Subscriptions())
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := Backoff{Min: time.Second, Max: 10 * time.Second, Factor: 2}
+	assert.Equal(t, time.Second, backoff.Delay(0))
+	assert.Equal(t, 4*time.Second, backoff.Delay(2))
+	assert.Equal(t, 10*time.Second, backoff.Delay(10))
+	backoff.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := backoff.Delay(1)
+		assert.GreaterOrEqual(t, delay, time.Second)
+		assert.LessOrEqual(t, delay, 3*time.Second)
+	}
+}
+
+func TestKrakenCharts_Candles(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	server.AddCandles("PI_XBTUSD", domain.Candle5m,
+		domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 300000},
+		domain.Candle{Open: 1, High: 3, Low: 1, Close: 2, Time: 600000},
+	)
+	charts := NewKrakenCharts(server.ChartsURL())
+	candles, err := charts.Candles("PI_XBTUSD", domain.Candle5m, 600000)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Candle{{Interval: domain.Candle5m, Open: 1, High: 3, Low: 1,
+		Close: 2, Time: 600000}}, candles)
+
+	// 2m candles aren't returned by charts, they are aggregated from 1m candles
+	server.AddCandles("PI_XBTUSD", domain.Candle1m,
+		domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Volume: 1, Time: 60000},
+		domain.Candle{Open: 1, High: 2, Low: 1, Close: 2, Volume: 1, Time: 120000},
+		domain.Candle{Open: 2, High: 2, Low: 0.5, Close: 1, Volume: 2, Time: 180000},
+		domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Volume: 1, Time: 240000},
+	)
+	candles, err = charts.Candles("PI_XBTUSD", domain.Candle2m, 60000)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Candle{{Interval: domain.Candle2m, Open: 1, High: 2, Low: 0.5,
+		Close: 1, Volume: 3, Time: 120000}, {Interval: domain.Candle2m, Open: 1, High: 1,
+		Low: 1, Close: 1, Volume: 1, Time: 240000}}, candles)
+	_, err = charts.Candles("PI_XBTUSD", "unknown", 0)
+	assert.ErrorIs(t, err, ErrUnsupportedResolution)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+// Ledger keeps users' positions by order fills and pairs' latest prices.
+type Ledger struct {
+	muPositions *sync.Mutex
+	positions   map[string]map[string]*domain.Position
+	prices      map[string]float64
+}
+
+// NewLedger returns pointer to empty Ledger.
+func NewLedger() *Ledger {
+	return &Ledger{
+		muPositions: &sync.Mutex{},
+		positions:   make(map[string]map[string]*domain.Position),
+		prices:      make(map[string]float64),
+	}
+}
+
+// Fill applies order to user's position and returns its copy.
+func (ledger *Ledger) Fill(info domain.OrderInfo) (domain.Position, error) {
+	ledger.muPositions.Lock()
+	defer ledger.muPositions.Unlock()
+	positions, ok := ledger.positions[info.Username]
+	if !ok {
+		positions = make(map[string]*domain.Position)
+		ledger.positions[info.Username] = positions
+	}
+	position, ok := positions[info.Name]
+	if !ok {
+		position = &domain.Position{PairName: info.Name}
+	}
+	if err := position.Fill(info.Side, info.Price, info.Amount); err != nil {
+		return domain.Position{}, err
+	}
+	positions[info.Name] = position
+	if price, ok := ledger.prices[info.Name]; ok {
+		position.Mark(price)
+	}
+	return *position, nil
+}
+
+// Mark saves the latest pair's price for unrealized PnL.
+func (ledger *Ledger) Mark(pairName string, price float64) {
+	ledger.muPositions.Lock()
+	defer ledger.muPositions.Unlock()
+	ledger.prices[pairName] = price
+}
+
+// Position returns user's pair position marked by the latest price.
+func (ledger *Ledger) Position(username, pairName string) (domain.Position, bool) {
+	ledger.muPositions.Lock()
+	defer ledger.muPositions.Unlock()
+	position, ok := ledger.positions[username][pairName]
+	if !ok {
+		return domain.Position{PairName: pairName}, false
+	}
+	if price, ok := ledger.prices[pairName]; ok {
+		position.Mark(price)
+	}
+	return *position, true
+}
+
+// Portfolio returns user's positions marked by the latest prices.
+func (ledger *Ledger) Portfolio(username string) domain.Portfolio {
+	ledger.muPositions.Lock()
+	defer ledger.muPositions.Unlock()
+	positions := make([]domain.Position, 0, len(ledger.positions[username]))
+	for pairName, position := range ledger.positions[username] {
+		if price, ok := ledger.prices[pairName]; ok {
+			position.Mark(price)
+		}
+		positions = append(positions, *position)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].PairName < positions[j].PairName
+	})
+	return domain.NewPortfolio(username, positions)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedger(t *testing.T) {
+	ledger := NewLedger()
+	assert.Empty(t, ledger.Portfolio("username").Positions)
+
+	ledger.Mark("first", 12)
+	position, err := ledger.Fill(domain.OrderInfo{Username: "username", Name: "first",
+		Side: string(domain.Buy), Price: 10, Amount: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, position.Size, int64(2))
+	assert.InDelta(t, position.UnrealizedPnL, 4, 1e-9)
+
+	_, err = ledger.Fill(domain.OrderInfo{Username: "username", Name: "second",
+		Side: string(domain.Sell), Price: 5, Amount: 1})
+	assert.NoError(t, err)
+	_, err = ledger.Fill(domain.OrderInfo{Username: "other", Name: "first",
+		Side: string(domain.Buy), Price: 1, Amount: 1})
+	assert.NoError(t, err)
+	_, err = ledger.Fill(domain.OrderInfo{Username: "username", Name: "first",
+		Side: string(domain.WaitToBuy), Price: 1, Amount: 1})
+	assert.Error(t, err)
+
+	ledger.Mark("first", 11)
+	ledger.Mark("second", 4)
+	position, ok := ledger.Position("username", "first")
+	assert.True(t, ok)
+	assert.InDelta(t, position.LastPrice, 11, 1e-9)
+	_, ok = ledger.Position("username", "third")
+	assert.False(t, ok)
+	portfolio := ledger.Portfolio("username")
+	assert.Equal(t, len(portfolio.Positions), 2)
+	assert.Equal(t, portfolio.Positions[0].PairName, "first")
+	assert.InDelta(t, portfolio.Positions[0].UnrealizedPnL, 2, 1e-9)
+	assert.InDelta(t, portfolio.Positions[1].UnrealizedPnL, 1, 1e-9)
+	assert.InDelta(t, portfolio.TotalPnL, 3, 1e-9)
+
+	_, err = ledger.Fill(domain.OrderInfo{Username: "username", Name: "first",
+		Side: string(domain.Sell), Price: 11, Amount: 2})
+	assert.NoError(t, err)
+	portfolio = ledger.Portfolio("username")
+	assert.InDelta(t, portfolio.RealizedPnL, 2, 1e-9)
+	assert.InDelta(t, portfolio.TotalPnL, 3, 1e-9)
+}
@@ -34,6 +34,20 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddSubscription mocks base method.
+func (m *MockUserRepository) AddSubscription(arg0 string, arg1 domain.Config) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSubscription indicates an expected call of AddSubscription.
+func (mr *MockUserRepositoryMockRecorder) AddSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSubscription", reflect.TypeOf((*MockUserRepository)(nil).AddSubscription), arg0, arg1)
+}
+
 // AddUser mocks base method.
 func (m *MockUserRepository) AddUser(user *domain.User) error {
 	m.ctrl.T.Helper()
@@ -48,6 +62,20 @@ func (mr *MockUserRepositoryMockRecorder) AddUser(user interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockUserRepository)(nil).AddUser), user)
 }
 
+// DeleteSubscription mocks base method.
+func (m *MockUserRepository) DeleteSubscription(arg0 string, arg1 domain.Config) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockUserRepositoryMockRecorder) DeleteSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockUserRepository)(nil).DeleteSubscription), arg0, arg1)
+}
+
 // DeleteUser mocks base method.
 func (m *MockUserRepository) DeleteUser(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -77,6 +105,36 @@ func (mr *MockUserRepositoryMockRecorder) GenerateJWT(arg0 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockUserRepository)(nil).GenerateJWT), arg0)
 }
 
+// GetRole mocks base method.
+func (m *MockUserRepository) GetRole(arg0 string) (domain.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", arg0)
+	ret0, _ := ret[0].(domain.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockUserRepositoryMockRecorder) GetRole(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockUserRepository)(nil).GetRole), arg0)
+}
+
+// GetSubscriptions mocks base method.
+func (m *MockUserRepository) GetSubscriptions() ([]domain.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptions")
+	ret0, _ := ret[0].([]domain.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptions indicates an expected call of GetSubscriptions.
+func (mr *MockUserRepositoryMockRecorder) GetSubscriptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptions", reflect.TypeOf((*MockUserRepository)(nil).GetSubscriptions))
+}
+
 // GetUser mocks base method.
 func (m *MockUserRepository) GetUser(arg0 string) (*domain.User, error) {
 	m.ctrl.T.Helper()
@@ -121,6 +179,34 @@ func (mr *MockUserRepositoryMockRecorder) SetKeys(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetKeys", reflect.TypeOf((*MockUserRepository)(nil).SetKeys), arg0, arg1, arg2)
 }
 
+// MigrateUser mocks base method.
+func (m *MockUserRepository) MigrateUser(arg0, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateUser", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MigrateUser indicates an expected call of MigrateUser.
+func (mr *MockUserRepositoryMockRecorder) MigrateUser(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateUser", reflect.TypeOf((*MockUserRepository)(nil).MigrateUser), arg0, arg1, arg2)
+}
+
+// SetRisk mocks base method.
+func (m *MockUserRepository) SetRisk(arg0 string, arg1 domain.RiskLimits) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRisk", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRisk indicates an expected call of SetRisk.
+func (mr *MockUserRepositoryMockRecorder) SetRisk(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRisk", reflect.TypeOf((*MockUserRepository)(nil).SetRisk), arg0, arg1)
+}
+
 // MockOrderRepository is a mock of OrderRepository interface.
 type MockOrderRepository struct {
 	ctrl     *gomock.Controller
@@ -173,10 +259,10 @@ func (mr *MockOrderRepositoryMockRecorder) Connect() *gomock.Call {
 }
 
 // GetOrders mocks base method.
-func (m *MockOrderRepository) GetOrders(arg0 int64) ([]domain.OrderInfo, error) {
+func (m *MockOrderRepository) GetOrders(arg0 domain.OrderFilter) (domain.OrderPage, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetOrders", arg0)
-	ret0, _ := ret[0].([]domain.OrderInfo)
+	ret0, _ := ret[0].(domain.OrderPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -187,6 +273,34 @@ func (mr *MockOrderRepositoryMockRecorder) GetOrders(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetOrders), arg0)
 }
 
+// SetFill mocks base method.
+func (m *MockOrderRepository) SetFill(arg0, arg1 string, arg2 int64, arg3 float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFill", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFill indicates an expected call of SetFill.
+func (mr *MockOrderRepositoryMockRecorder) SetFill(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFill", reflect.TypeOf((*MockOrderRepository)(nil).SetFill), arg0, arg1, arg2, arg3)
+}
+
+// MarkMismatch mocks base method.
+func (m *MockOrderRepository) MarkMismatch(arg0, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkMismatch", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkMismatch indicates an expected call of MarkMismatch.
+func (mr *MockOrderRepositoryMockRecorder) MarkMismatch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMismatch", reflect.TypeOf((*MockOrderRepository)(nil).MarkMismatch), arg0, arg1, arg2)
+}
+
 // Shutdown mocks base method.
 func (m *MockOrderRepository) Shutdown() {
 	m.ctrl.T.Helper()
@@ -199,6 +313,162 @@ func (mr *MockOrderRepositoryMockRecorder) Shutdown() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockOrderRepository)(nil).Shutdown))
 }
 
+// MockCandleRepository is a mock of CandleRepository interface.
+type MockCandleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCandleRepositoryMockRecorder
+}
+
+// MockCandleRepositoryMockRecorder is the mock recorder for MockCandleRepository.
+type MockCandleRepositoryMockRecorder struct {
+	mock *MockCandleRepository
+}
+
+// NewMockCandleRepository creates a new mock instance.
+func NewMockCandleRepository(ctrl *gomock.Controller) *MockCandleRepository {
+	mock := &MockCandleRepository{ctrl: ctrl}
+	mock.recorder = &MockCandleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCandleRepository) EXPECT() *MockCandleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddCandle mocks base method.
+func (m *MockCandleRepository) AddCandle(arg0 string, arg1 domain.Candle) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCandle", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCandle indicates an expected call of AddCandle.
+func (mr *MockCandleRepositoryMockRecorder) AddCandle(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCandle", reflect.TypeOf((*MockCandleRepository)(nil).AddCandle), arg0, arg1)
+}
+
+// Connect mocks base method.
+func (m *MockCandleRepository) Connect() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Connect")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Connect indicates an expected call of Connect.
+func (mr *MockCandleRepositoryMockRecorder) Connect() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockCandleRepository)(nil).Connect))
+}
+
+// GetCandles mocks base method.
+func (m *MockCandleRepository) GetCandles(arg0 domain.CandleFilter) ([]domain.Candle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCandles", arg0)
+	ret0, _ := ret[0].([]domain.Candle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCandles indicates an expected call of GetCandles.
+func (mr *MockCandleRepositoryMockRecorder) GetCandles(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCandles", reflect.TypeOf((*MockCandleRepository)(nil).GetCandles), arg0)
+}
+
+// Shutdown mocks base method.
+func (m *MockCandleRepository) Shutdown() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Shutdown")
+}
+
+// Shutdown indicates an expected call of Shutdown.
+func (mr *MockCandleRepositoryMockRecorder) Shutdown() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockCandleRepository)(nil).Shutdown))
+}
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddEvent mocks base method.
+func (m *MockAuditRepository) AddEvent(arg0 domain.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddEvent", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddEvent indicates an expected call of AddEvent.
+func (mr *MockAuditRepositoryMockRecorder) AddEvent(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEvent", reflect.TypeOf((*MockAuditRepository)(nil).AddEvent), arg0)
+}
+
+// Connect mocks base method.
+func (m *MockAuditRepository) Connect() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Connect")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Connect indicates an expected call of Connect.
+func (mr *MockAuditRepositoryMockRecorder) Connect() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockAuditRepository)(nil).Connect))
+}
+
+// GetEvents mocks base method.
+func (m *MockAuditRepository) GetEvents(arg0 domain.AuditFilter) ([]domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", arg0)
+	ret0, _ := ret[0].([]domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockAuditRepositoryMockRecorder) GetEvents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*MockAuditRepository)(nil).GetEvents), arg0)
+}
+
+// Shutdown mocks base method.
+func (m *MockAuditRepository) Shutdown() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Shutdown")
+}
+
+// Shutdown indicates an expected call of Shutdown.
+func (mr *MockAuditRepositoryMockRecorder) Shutdown() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockAuditRepository)(nil).Shutdown))
+}
+
 // MockMessageWriter is a mock of MessageWriter interface.
 type MockMessageWriter struct {
 	ctrl     *gomock.Controller
@@ -299,3 +569,32 @@ func (mr *MockStockMarketAPIMockRecorder) AddOrder(arg0, arg1 interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrder", reflect.TypeOf((*MockStockMarketAPI)(nil).AddOrder), arg0, arg1)
 }
+
+// CancelOrder mocks base method.
+func (m *MockStockMarketAPI) CancelOrder(arg0 string, arg1 *domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelOrder", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelOrder indicates an expected call of CancelOrder.
+func (mr *MockStockMarketAPIMockRecorder) CancelOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockStockMarketAPI)(nil).CancelOrder), arg0, arg1)
+}
+
+// OpenOrders mocks base method.
+func (m *MockStockMarketAPI) OpenOrders(arg0 *domain.User) ([]domain.OpenOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenOrders", arg0)
+	ret0, _ := ret[0].([]domain.OpenOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenOrders indicates an expected call of OpenOrders.
+func (mr *MockStockMarketAPIMockRecorder) OpenOrders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenOrders", reflect.TypeOf((*MockStockMarketAPI)(nil).OpenOrders), arg0)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+var ErrModeConflict = errors.New("pair is already traded by user in another mode")
+
+// TradingModes keeps live or paper mode of every user's pair, live mode is default.
+type TradingModes struct {
+	muModes *sync.Mutex
+	modes   map[string]map[string]domain.TradingMode
+}
+
+// NewTradingModes returns pointer to empty TradingModes.
+func NewTradingModes() *TradingModes {
+	return &TradingModes{
+		muModes: &sync.Mutex{},
+		modes:   make(map[string]map[string]domain.TradingMode),
+	}
+}
+
+// Set sets trading mode of user's pair, empty mode is live.
+func (modes *TradingModes) Set(username, pairName string, mode domain.TradingMode) {
+	if len(mode) == 0 {
+		mode = domain.LiveMode
+	}
+	modes.muModes.Lock()
+	defer modes.muModes.Unlock()
+	pairs, ok := modes.modes[username]
+	if !ok {
+		pairs = make(map[string]domain.TradingMode)
+		modes.modes[username] = pairs
+	}
+	pairs[pairName] = mode
+}
+
+// Mode returns trading mode of user's pair.
+func (modes *TradingModes) Mode(username, pairName string) domain.TradingMode {
+	modes.muModes.Lock()
+	defer modes.muModes.Unlock()
+	mode, ok := modes.modes[username][pairName]
+	if !ok {
+		return domain.LiveMode
+	}
+	return mode
+}
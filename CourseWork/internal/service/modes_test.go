@@ -0,0 +1,20 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTradingModes_Mode(t *testing.T) {
+	modes := NewTradingModes()
+	assert.Equal(t, modes.Mode("first", "pair"), domain.LiveMode)
+	modes.Set("first", "pair", domain.PaperMode)
+	assert.Equal(t, modes.Mode("first", "pair"), domain.PaperMode)
+	// mode of one user doesn't change other users of the pair
+	assert.Equal(t, modes.Mode("second", "pair"), domain.LiveMode)
+	assert.Equal(t, modes.Mode("first", "other pair"), domain.LiveMode)
+	modes.Set("first", "pair", "")
+	assert.Equal(t, modes.Mode("first", "pair"), domain.LiveMode)
+}
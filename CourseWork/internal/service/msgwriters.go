@@ -4,9 +4,15 @@ import (
 	"sync"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	orderMessage = "order"
+	errorMessage = "error"
+)
+
 type MessageWriters struct {
 	Writers   []MessageWriter
 	muWriters *sync.Mutex
@@ -32,6 +38,7 @@ func (writers MessageWriters) WriteMessages(message domain.OrderInfo, user domai
 	for _, writer := range writers.Writers {
 		if err := writer.WriteMessage(message, user); err != nil {
 			writers.log.Printf("WRITE: <%s>", err)
+			metrics.DeliveryFailures.WithLabelValues(orderMessage).Inc()
 			continue
 		}
 	}
@@ -43,6 +50,7 @@ func (writers MessageWriters) WriteErrors(message string, user domain.User) {
 	for _, writer := range writers.Writers {
 		if err := writer.WriteError(message, user); err != nil {
 			writers.log.Printf("WRITE: <%s>", err)
+			metrics.DeliveryFailures.WithLabelValues(errorMessage).Inc()
 			continue
 		}
 	}
@@ -55,6 +63,7 @@ func (writers MessageWriters) WriteErrorsToAll(message string, users []*domain.U
 		for _, writer := range writers.Writers {
 			if err := writer.WriteError(message, *user); err != nil {
 				writers.log.Printf("WRITE: <%s>", err)
+				metrics.DeliveryFailures.WithLabelValues(errorMessage).Inc()
 				continue
 			}
 		}
@@ -4,26 +4,43 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	DonchianName = "Donchian"
-)
-
 var (
 	ErrUserIsLogged    = errors.New("current user is already logged")
 	ErrUserIsNotLogged = errors.New("current user is not logged")
+	ErrPairIsRunning   = errors.New("pair is already running")
 )
 
+// StockMarketSocket sends pair's candles until ctx is done.
 type StockMarketSocket interface {
-	Connect(context.Context) error
-	SubscribeCandle(Pair, chan domain.Candle, chan PairError) error
+	SubscribeCandle(context.Context, Pair, chan domain.Candle, chan PairError) error
+}
+
+// SocketStatusReporter is implemented by sockets, which report connection state.
+type SocketStatusReporter interface {
+	Status() domain.SocketStatus
+}
+
+// CandleHistory returns pair's candles of interval, which started at from or later.
+type CandleHistory interface {
+	Candles(string, domain.CandleInterval, int64) ([]domain.Candle, error)
+}
+
+// CandleStore saves closed candles of pair.
+type CandleStore interface {
+	AddCandle(string, domain.Candle) error
 }
 
 // Indicator implements strategy of stock market service.
@@ -31,51 +48,112 @@ type Indicator interface {
 	Add(candle domain.Candle) (domain.Signal, error)
 }
 
+// Resetter is implemented by indicators, which remember entered position.
+type Resetter interface {
+	Reset()
+}
+
 // Pair describes stock market pair entity.
+// Every user has personal Indicator's state, Sizer and Config, but candles are read once.
+// Closed candles are saved to Store, if it is set. Signals of every candle are written to Audit.
+// Paused pair keeps reading candles, but only reduce-only parts of its order events are sent.
+// Candles of users' filter intervals are subscribed by running pair and only update filters.
+// Filter interval is unsubscribed, when the last user of it is deleted.
 type Pair struct {
-	Name      string
-	Users     []*domain.User
-	Interval  domain.CandleInterval
-	Indicator Indicator
-	stop      chan struct{}
-	socket    StockMarketSocket
-	ctx       context.Context
-	cancel    context.CancelFunc
-	log       *logrus.Logger
-}
-
-// NewPair returns pointer to Pair with custom parameters.
-func NewPair(name string, interval domain.CandleInterval, indicatorName string,
-	log *logrus.Logger) (*Pair, error) {
+	Name         string
+	Users        []*domain.User
+	Interval     domain.CandleInterval
+	Indicators   map[string]Indicator
+	Sizers       map[string]*domain.Sizer
+	Configs      map[string]domain.Config
+	Store        CandleStore
+	Audit        *Auditor
+	muIndicators *sync.Mutex
+	stop         chan struct{}
+	socket       StockMarketSocket
+	ctx          context.Context
+	cancel       context.CancelFunc
+	runCtx       context.Context
+	errors       chan PairError
+	filterFeeds  map[domain.CandleInterval]context.CancelFunc
+	filters      *sync.WaitGroup
+	paused       bool
+	lastCandle   int64
+	log          *logrus.Logger
+}
+
+// NewPair returns pointer to Pair and checks Indicator from config.
+func NewPair(config domain.Config, socket StockMarketSocket, log *logrus.Logger) (*Pair, error) {
+	if _, err := NewUserIndicator(config); err != nil {
+		return nil, err
+	}
 	pair := &Pair{
-		Name:      name,
-		Users:     make([]*domain.User, 0),
-		Interval:  interval,
-		Indicator: domain.NewDonchian(),
-		stop:      make(chan struct{}),
-		socket:    &KrakenSocket{log: log},
-		ctx:       context.Background(),
-		log:       log,
-	}
-	switch indicatorName {
-	case DonchianName:
-		pair.Indicator = domain.NewDonchian()
-	default:
-		return nil, fmt.Errorf("such indicator doesn't exist")
+		Name:         config.PairName,
+		Users:        make([]*domain.User, 0),
+		Interval:     config.PairInterval,
+		Indicators:   make(map[string]Indicator),
+		Sizers:       make(map[string]*domain.Sizer),
+		Configs:      make(map[string]domain.Config),
+		muIndicators: &sync.Mutex{},
+		stop:         make(chan struct{}),
+		socket:       socket,
+		ctx:          context.Background(),
+		filterFeeds:  make(map[domain.CandleInterval]context.CancelFunc),
+		filters:      &sync.WaitGroup{},
+		log:          log,
 	}
 	return pair, nil
 }
 
-// AddUser subscribes user to current Pair.
-func (pair *Pair) AddUser(user *domain.User) error {
+// AddUser subscribes user to current Pair with new Indicator from config.
+// So every new user starts without entered position.
+func (pair *Pair) AddUser(user *domain.User, config domain.Config) error {
 	if pair.IsUserLogged(user) {
 		return ErrUserIsLogged
 	}
-	pair.Users = append(pair.Users, user)
+	indicator, err := NewUserIndicator(config)
+	if err != nil {
+		return err
+	}
+	sizer, err := domain.NewSizer(config.Sizing)
+	if err != nil {
+		return err
+	}
+	pair.addUser(user, config, indicator, sizer)
 	return nil
 }
 
-// DeleteUser unsubscribes user from current Pair.
+// MoveUser adds user with Indicator and Sizer of another pair, which isn't running,
+// so user could be warmed up before it's added.
+func (pair *Pair) MoveUser(user *domain.User, from *Pair) error {
+	if pair.IsUserLogged(user) {
+		return ErrUserIsLogged
+	}
+	from.muIndicators.Lock()
+	indicator, ok := from.Indicators[user.Username]
+	sizer := from.Sizers[user.Username]
+	config := from.Configs[user.Username]
+	from.muIndicators.Unlock()
+	if !ok {
+		return ErrUserIsNotLogged
+	}
+	pair.addUser(user, config, indicator, sizer)
+	return nil
+}
+
+// addUser subscribes user with Indicator and Sizer.
+// Filter intervals of user are subscribed by SubscribeFilters of running pair.
+func (pair *Pair) addUser(user *domain.User, config domain.Config, indicator Indicator,
+	sizer *domain.Sizer) {
+	pair.muIndicators.Lock()
+	pair.Indicators[user.Username] = indicator
+	pair.Sizers[user.Username] = sizer
+	pair.Configs[user.Username] = config
+	pair.Users = append(pair.Users, user)
+	pair.muIndicators.Unlock()
+}
+
+// DeleteUser unsubscribes user from current Pair and deletes user's Indicator.
 func (pair *Pair) DeleteUser(user *domain.User) error {
 	if !pair.IsUserLogged(user) {
 		return ErrUserIsNotLogged
@@ -84,20 +162,240 @@ func (pair *Pair) DeleteUser(user *domain.User) error {
 	if pair.Users, err = remove(pair.Users, user); err != nil {
 		return err
 	}
+	pair.muIndicators.Lock()
+	delete(pair.Indicators, user.Username)
+	delete(pair.Sizers, user.Username)
+	delete(pair.Configs, user.Username)
+	pair.releaseFilters()
+	pair.muIndicators.Unlock()
 	pair.log.Printf("REMOVE: user <%s> was removed from pair <%s> <%s>",
 		user.Username, pair.Name, pair.Interval)
 	return nil
 }
 
-// Stop gracefully shutdowns current Pair.
-func (pair Pair) Stop(wg *sync.WaitGroup) {
+// User returns subscribed user by username.
+func (pair *Pair) User(username string) (*domain.User, bool) {
+	for _, user := range pair.Users {
+		if user.Username == username {
+			return user, true
+		}
+	}
+	return nil, false
+}
+
+// Config returns subscribed user's config by username.
+func (pair *Pair) Config(username string) (domain.Config, bool) {
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	config, ok := pair.Configs[username]
+	return config, ok
+}
+
+// Size counts user's order size by price and user's total PnL.
+func (pair *Pair) Size(username string, price, pnl float64) (int64, error) {
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	sizer, ok := pair.Sizers[username]
+	if !ok {
+		return 0, ErrUserIsNotLogged
+	}
+	return sizer.Size(price, pnl)
+}
+
+// Volatility returns user's volatility of pair per square root of minute,
+// so volatilities of different intervals are comparable.
+func (pair *Pair) Volatility(username string) (float64, bool) {
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	sizer, ok := pair.Sizers[username]
+	if !ok {
+		return 0, false
+	}
+	volatility, ok := sizer.Volatility()
+	if !ok {
+		return 0, false
+	}
+	duration, err := pair.Interval.Duration()
+	if err != nil {
+		return 0, false
+	}
+	return volatility / math.Sqrt(duration.Minutes()), true
+}
+
+// WarmUp primes Indicators and Sizers of users by the last history candles,
+// so trading starts with full windows. Signals of history candles are skipped and
+// entered positions are reset, so every user still starts without position.
+// Filters are primed by candles of their intervals and keep their trends.
+// It returns the number of added candles of pair's interval.
+func (pair *Pair) WarmUp(history CandleHistory, usernames ...string) (int, error) {
+	var size int32
+	filterSizes := make(map[domain.CandleInterval]int32)
+	pair.muIndicators.Lock()
+	for _, username := range usernames {
+		config, ok := pair.Configs[username]
+		if !ok {
+			continue
+		}
+		if config.WarmUpSize() > size {
+			size = config.WarmUpSize()
+		}
+		for _, filter := range config.Filters {
+			if config.WarmUpSize() > filterSizes[filter.Interval] {
+				filterSizes[filter.Interval] = config.WarmUpSize()
+			}
+		}
+	}
+	pair.muIndicators.Unlock()
+	if size == 0 {
+		return 0, nil
+	}
+	for interval, filterSize := range filterSizes {
+		if err := pair.warmUpFilters(history, interval, filterSize, usernames); err != nil {
+			pair.log.Printf("WARM-UP: filter <%s> <%s> isn't warmed up <%s>",
+				pair.Name, interval, err)
+		}
+	}
+	candles, err := pair.historyCandles(history, pair.Interval, size)
+	if err != nil {
+		return 0, err
+	}
+
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	for _, username := range usernames {
+		indicator, ok := pair.Indicators[username]
+		if !ok {
+			continue
+		}
+		for _, candle := range candles {
+			if _, err = indicator.Add(candle); err != nil {
+				if err == domain.ErrSameTimestamp {
+					continue
+				}
+				return 0, err
+			}
+			pair.Sizers[username].Add(candle)
+		}
+		if resetter, ok := indicator.(Resetter); ok {
+			resetter.Reset()
+		}
+	}
+	return len(candles), nil
+}
+
+// warmUpFilters primes users' filters of interval by history candles.
+func (pair *Pair) warmUpFilters(history CandleHistory, interval domain.CandleInterval,
+	size int32, usernames []string) error {
+	candles, err := pair.historyCandles(history, interval, size)
+	if err != nil {
+		return err
+	}
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	for _, username := range usernames {
+		filtered, ok := pair.Indicators[username].(FilteredIndicator)
+		if !ok {
+			continue
+		}
+		for _, candle := range candles {
+			candle.Interval = interval
+			if err = filtered.AddFilter(candle); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// historyCandles returns the last size candles of pair's name and interval.
+func (pair *Pair) historyCandles(history CandleHistory, interval domain.CandleInterval,
+	size int32) ([]domain.Candle, error) {
+	duration, err := interval.Duration()
+	if err != nil {
+		return nil, err
+	}
+	from := time.Now().Add(-time.Duration(size) * duration).UnixMilli()
+	candles, err := history.Candles(pair.Name, interval, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) > int(size) {
+		candles = candles[len(candles)-int(size):]
+	}
+	return candles, nil
+}
+
+// Stop gracefully shutdowns current Pair and waits for its filters.
+func (pair *Pair) Stop(wg *sync.WaitGroup) {
 	pair.cancel()
 	<-pair.stop
+	// filters aren't added after cancel is seen under the lock
+	pair.muIndicators.Lock()
+	pair.muIndicators.Unlock()
+	pair.filters.Wait()
 	wg.Done()
 }
 
+// Pause stops sending pair's entering order events, candles are still read
+// and positions are still closed.
+func (pair *Pair) Pause() {
+	pair.muIndicators.Lock()
+	pair.paused = true
+	pair.muIndicators.Unlock()
+}
+
+// Resume restores sending pair's order events. Indicators keep positions entered by
+// skipped signals, so indicators of users with flat positions by isFlat forget them.
+func (pair *Pair) Resume(isFlat func(username string) bool) {
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	pair.paused = false
+	for username, indicator := range pair.Indicators {
+		if resetter, ok := indicator.(Resetter); ok && isFlat(username) {
+			resetter.Reset()
+		}
+	}
+}
+
+// IsPaused checks if pair's order events are skipped.
+func (pair *Pair) IsPaused() bool {
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	return pair.paused
+}
+
+// Info returns pair's state with subscribers sorted by username.
+func (pair *Pair) Info() domain.PairInfo {
+	status := domain.UnknownSocket
+	if reporter, ok := pair.socket.(SocketStatusReporter); ok {
+		status = reporter.Status()
+	}
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	info := domain.PairInfo{
+		Name:         pair.Name,
+		Interval:     pair.Interval,
+		Paused:       pair.paused,
+		LastCandle:   pair.lastCandle,
+		SocketStatus: status,
+		Subscribers:  make([]domain.SubscriberInfo, 0, len(pair.Users)),
+	}
+	for _, user := range pair.Users {
+		info.Subscribers = append(info.Subscribers, domain.SubscriberInfo{
+			Username:  user.Username,
+			Indicator: pair.Configs[user.Username].IndicatorName,
+			Mode:      pair.Configs[user.Username].TradingMode(),
+			State:     indicatorState(pair.Indicators[user.Username]),
+		})
+	}
+	sort.Slice(info.Subscribers, func(i, j int) bool {
+		return info.Subscribers[i].Username < info.Subscribers[j].Username
+	})
+	return info
+}
+
 // IsUserLogged cheks if User is subscribed.
-func (pair Pair) IsUserLogged(user *domain.User) bool {
+func (pair *Pair) IsUserLogged(user *domain.User) bool {
 	for _, loggedUser := range pair.Users {
 		if user.Username == loggedUser.Username {
 			return true
@@ -108,42 +406,55 @@ func (pair Pair) IsUserLogged(user *domain.User) bool {
 
 // Run represents second step of pipeline where
 // there is a process of sending candles to Indicator.
-func (pair *Pair) Run(events chan domain.StockMarketEvent, errors chan PairError) error {
+// Every live candle is sent to ticks to mark positions by its close,
+// backfilled candles only update Indicators.
+// Candle is closed, when the next one is received, so the last candle isn't stored.
+func (pair *Pair) Run(events chan domain.StockMarketEvent, ticks chan PairTick,
+	errors chan PairError) error {
 	ctx, cancel := context.WithCancel(pair.ctx)
 	pair.cancel = cancel
 	candles := make(chan domain.Candle)
-	if err := pair.socket.Connect(ctx); err != nil {
-		return fmt.Errorf("can't connect <%w>", err)
-	}
-	err := pair.socket.SubscribeCandle(*pair, candles, errors)
+	err := pair.socket.SubscribeCandle(ctx, *pair, candles, errors)
 	if err != nil {
+		cancel()
 		return fmt.Errorf("can't sucscribe candle <%w>", err)
 	}
+	pair.runCtx = ctx
+	pair.errors = errors
+	pair.SubscribeFilters()
 	go func() {
+		var current domain.Candle
 		for candle := range candles {
-			signal, err := pair.Indicator.Add(candle)
+			metrics.CandlesReceived.WithLabelValues(pair.Name, string(pair.Interval)).Inc()
+			if current.Time != 0 && candle.Time > current.Time {
+				pair.storeCandle(current)
+			}
+			if candle.Time >= current.Time {
+				current = candle
+			}
+			pairEvents, err := pair.addCandle(candle)
 			if err != nil {
-				// timestamp checking
-				if err == domain.ErrSameTimestamp {
-					continue
-				}
-				// other error sending
 				errors <- PairError{
 					Name:     pair.Name,
 					Interval: pair.Interval,
 					Message:  err.Error(),
 				}
-				return
+				// socket is stopped and the rest candles are skipped
+				cancel()
+				for range candles {
+				}
+				break
 			}
-			pair.log.Printf("%s Signal: %s", candle, signal)
-			if signal == domain.Buy || signal == domain.Sell {
-				events <- domain.StockMarketEvent{
-					Signal:   signal,
-					Name:     pair.Name,
-					Interval: pair.Interval,
-					Volume:   candle.Volume,
-					Close:    candle.Close,
+			if candle.Backfilled {
+				for _, event := range pairEvents {
+					pair.log.Printf("BACKFILL: signal <%s> of user <%s> is skipped",
+						event.Signal, event.Username)
 				}
+				continue
+			}
+			ticks <- PairTick{Name: pair.Name, Interval: pair.Interval, Candle: candle}
+			for _, event := range pairEvents {
+				events <- event
 			}
 		}
 		pair.log.Printf("STOP: <%s> <%s> was interrupted gracefully", pair.Name, pair.Interval)
@@ -152,10 +463,189 @@ func (pair *Pair) Run(events chan domain.StockMarketEvent, errors chan PairError
 	return nil
 }
 
+// SubscribeFilters subscribes filter intervals of users of running pair, which aren't
+// subscribed yet. Subscription waits for stock market, so muPairs shouldn't be locked.
+// Filter, which isn't subscribed, has unknown trend and blocks entries of and rule.
+func (pair *Pair) SubscribeFilters() {
+	pair.muIndicators.Lock()
+	if pair.runCtx == nil || pair.runCtx.Err() != nil {
+		pair.muIndicators.Unlock()
+		return
+	}
+	if pair.filterFeeds == nil {
+		pair.filterFeeds = make(map[domain.CandleInterval]context.CancelFunc)
+	}
+	feeds := make(map[domain.CandleInterval]context.Context)
+	for interval := range pair.filterIntervals() {
+		if _, ok := pair.filterFeeds[interval]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(pair.runCtx)
+		pair.filterFeeds[interval] = cancel
+		feeds[interval] = ctx
+		pair.filters.Add(1)
+	}
+	pair.muIndicators.Unlock()
+	for interval, ctx := range feeds {
+		candles := make(chan domain.Candle)
+		err := pair.socket.SubscribeCandle(ctx, Pair{Name: pair.Name, Interval: interval},
+			candles, pair.errors)
+		if err != nil {
+			pair.muIndicators.Lock()
+			if ctx.Err() == nil {
+				pair.filterFeeds[interval]()
+				delete(pair.filterFeeds, interval)
+			}
+			pair.muIndicators.Unlock()
+			pair.filters.Done()
+			pair.log.Printf("FILTER: <%s> <%s> isn't subscribed <%s>", pair.Name, interval, err)
+			continue
+		}
+		go pair.runFilter(interval, candles)
+	}
+}
+
+// filterIntervals returns filter intervals of users, muIndicators should be locked.
+func (pair *Pair) filterIntervals() map[domain.CandleInterval]struct{} {
+	intervals := make(map[domain.CandleInterval]struct{})
+	for _, config := range pair.Configs {
+		for _, filter := range config.Filters {
+			intervals[filter.Interval] = struct{}{}
+		}
+	}
+	return intervals
+}
+
+// releaseFilters unsubscribes filter intervals, which aren't used by users.
+// muIndicators should be locked.
+func (pair *Pair) releaseFilters() {
+	intervals := pair.filterIntervals()
+	for interval, cancel := range pair.filterFeeds {
+		if _, ok := intervals[interval]; ok {
+			continue
+		}
+		cancel()
+		delete(pair.filterFeeds, interval)
+	}
+}
+
+// runFilter sends candles of filter interval to users' filters until feed is unsubscribed.
+func (pair *Pair) runFilter(interval domain.CandleInterval, candles chan domain.Candle) {
+	defer pair.filters.Done()
+	for candle := range candles {
+		candle.Interval = interval
+		pair.addFilterCandle(candle)
+	}
+}
+
+// addFilterCandle sends candle of filter interval to every user's FilteredIndicator.
+func (pair *Pair) addFilterCandle(candle domain.Candle) {
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	for username, indicator := range pair.Indicators {
+		filtered, ok := indicator.(FilteredIndicator)
+		if !ok {
+			continue
+		}
+		if err := filtered.AddFilter(candle); err != nil {
+			pair.log.Printf("FILTER: candle of <%s> <%s> is skipped for user <%s> <%s>",
+				pair.Name, candle.Interval, username, err)
+		}
+	}
+}
+
+// storeCandle saves closed candle, storage errors don't stop trading.
+func (pair *Pair) storeCandle(candle domain.Candle) {
+	if pair.Store == nil {
+		return
+	}
+	candle.Interval = pair.Interval
+	if err := pair.Store.AddCandle(pair.Name, candle); err != nil {
+		pair.log.Printf("DB: candle of <%s> <%s> isn't stored <%s>", pair.Name, pair.Interval, err)
+	}
+}
+
+// addCandle sends candle to every user's Indicator and returns order events.
+// Only reduce-only parts of events are returned while pair is paused.
+func (pair *Pair) addCandle(candle domain.Candle) ([]domain.StockMarketEvent, error) {
+	pair.muIndicators.Lock()
+	defer pair.muIndicators.Unlock()
+	pair.lastCandle = candle.Time
+	events := make([]domain.StockMarketEvent, 0)
+	for username, indicator := range pair.Indicators {
+		signal, err := indicator.Add(candle)
+		if err != nil {
+			// timestamp checking
+			if err == domain.ErrSameTimestamp {
+				continue
+			}
+			return nil, err
+		}
+		pair.Sizers[username].Add(candle)
+		pair.log.Printf("%s User: %s Signal: %s", candle, username, signal)
+		metrics.Signals.WithLabelValues(pair.Name, string(pair.Interval), string(signal)).Inc()
+		event := newStockMarketEvent(pair.Name, pair.Interval, signal, candle)
+		event.Username = username
+		event.TraceID = domain.NewTraceID(pair.Name, pair.Interval, candle.Time, username)
+		pair.Audit.Write(domain.NewAuditEvent(domain.SignalStage, event, domain.SignalAudit{
+			Candle:    candle,
+			Indicator: indicatorState(indicator),
+			Signal:    signal,
+		}))
+		if signal.IsOrder() {
+			event.Indicator = pair.Configs[username].IndicatorName
+			event.Order = pair.Configs[username].Order.Entry()
+			event.Protection, _ = pair.Configs[username].Order.Protection()
+			events = append(events, event)
+		}
+	}
+	if pair.paused {
+		return pausedEvents(events, pair.log), nil
+	}
+	return events, nil
+}
+
+// pausedEvents returns reduce-only parts of order events, entering events are skipped.
+func pausedEvents(events []domain.StockMarketEvent,
+	log *logrus.Logger) []domain.StockMarketEvent {
+	exits := make([]domain.StockMarketEvent, 0, len(events))
+	for _, event := range events {
+		exit, ok := event.Signal.Exit()
+		if !ok {
+			log.Printf("PAUSE: signal <%s> of user <%s> is skipped", event.Signal, event.Username)
+			continue
+		}
+		event.Signal = exit
+		exits = append(exits, event)
+	}
+	return exits
+}
+
+// indicatorState returns exported state of Indicator, it's empty if state can't be marshalled.
+func indicatorState(indicator Indicator) json.RawMessage {
+	state, err := json.Marshal(indicator)
+	if err != nil {
+		return nil
+	}
+	return state
+}
+
+// newStockMarketEvent creates order event by Indicator signal and candle.
+// Volume is counted later by user's Sizer.
+func newStockMarketEvent(name string, interval domain.CandleInterval,
+	signal domain.Signal, candle domain.Candle) domain.StockMarketEvent {
+	return domain.StockMarketEvent{
+		Signal:   signal,
+		Name:     name,
+		Interval: interval,
+		Close:    candle.Close,
+	}
+}
+
 // remove deletes user from user's slice.
 func remove(users []*domain.User, deletingUser *domain.User) ([]*domain.User, error) {
 	for i, user := range users {
-		if user == deletingUser {
+		if user.Username == deletingUser.Username {
 			return append(users[:i], users[i+1:]...), nil
 		}
 	}
@@ -175,6 +665,60 @@ func (pairs Pairs) IsExist(pairName string, interval domain.CandleInterval) bool
 	return false
 }
 
+// User returns logged user of any pair by username.
+func (pairs Pairs) User(username string) (*domain.User, bool) {
+	for _, intervals := range pairs {
+		for _, pair := range intervals {
+			if user, ok := pair.User(username); ok {
+				return user, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Volatilities returns user's volatility of every subscribed pair by pair's name,
+// zero volatility is unknown. Pair of several intervals has the highest one.
+func (pairs Pairs) Volatilities(username string) map[string]float64 {
+	volatilities := make(map[string]float64)
+	for pairName, intervals := range pairs {
+		for _, pair := range intervals {
+			if _, ok := pair.User(username); !ok {
+				continue
+			}
+			volatility, _ := pair.Volatility(username)
+			volatilities[pairName] = math.Max(volatilities[pairName], volatility)
+		}
+	}
+	return volatilities
+}
+
+// Active returns quantity of running pairs by name and interval and quantity
+// of users subscribed on any of them.
+func (pairs Pairs) Active() (int, int) {
+	var active int
+	users := make(map[string]struct{})
+	for _, intervals := range pairs {
+		for _, pair := range intervals {
+			active++
+			for _, user := range pair.Users {
+				users[user.Username] = struct{}{}
+			}
+		}
+	}
+	return active, len(users)
+}
+
+// Detach moves all pairs into returned Pairs, so pairs become empty.
+func (pairs Pairs) Detach() Pairs {
+	detached := make(Pairs, len(pairs))
+	for name, intervals := range pairs {
+		detached[name] = intervals
+		delete(pairs, name)
+	}
+	return detached
+}
+
 // Shutdown gracefully shutdowns all running pairs.
 func (pairs Pairs) Shutdown(wg *sync.WaitGroup) {
 	for _, interval := range pairs {
@@ -186,11 +730,14 @@ func (pairs Pairs) Shutdown(wg *sync.WaitGroup) {
 }
 
 // AddPair adds Pair to nested pair's map.
-func (pairs Pairs) AddPair(pair *Pair, user *domain.User) error {
-	pairs[pair.Name][pair.Interval] = pair
-	if err := pair.AddUser(user); err != nil {
-		return err
+func (pairs Pairs) AddPair(pair *Pair) error {
+	if pairs.IsExist(pair.Name, pair.Interval) {
+		return ErrPairIsRunning
+	}
+	if _, ok := pairs[pair.Name]; !ok {
+		pairs[pair.Name] = make(map[domain.CandleInterval]*Pair)
 	}
+	pairs[pair.Name][pair.Interval] = pair
 	return nil
 }
 
@@ -201,6 +748,13 @@ type PairError struct {
 	Message  string
 }
 
+// PairTick describes the latest Pair's candle.
+type PairTick struct {
+	Name     string
+	Interval domain.CandleInterval
+	Candle   domain.Candle
+}
+
 func (pairError PairError) Error() string {
 	return pairError.Message
 }
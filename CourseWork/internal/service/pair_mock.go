@@ -35,32 +35,55 @@ func (m *MockStockMarketSocket) EXPECT() *MockStockMarketSocketMockRecorder {
 	return m.recorder
 }
 
-// Connect mocks base method.
-func (m *MockStockMarketSocket) Connect(arg0 context.Context) error {
+// SubscribeCandle mocks base method.
+func (m *MockStockMarketSocket) SubscribeCandle(arg0 context.Context, arg1 Pair, arg2 chan domain.Candle, arg3 chan PairError) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Connect", arg0)
+	ret := m.ctrl.Call(m, "SubscribeCandle", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Connect indicates an expected call of Connect.
-func (mr *MockStockMarketSocketMockRecorder) Connect(arg0 interface{}) *gomock.Call {
+// SubscribeCandle indicates an expected call of SubscribeCandle.
+func (mr *MockStockMarketSocketMockRecorder) SubscribeCandle(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockStockMarketSocket)(nil).Connect), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeCandle", reflect.TypeOf((*MockStockMarketSocket)(nil).SubscribeCandle), arg0, arg1, arg2, arg3)
 }
 
-// SubscribeCandle mocks base method.
-func (m *MockStockMarketSocket) SubscribeCandle(arg0 Pair, arg1 chan domain.Candle, arg2 chan PairError) error {
+// MockCandleStore is a mock of CandleStore interface.
+type MockCandleStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockCandleStoreMockRecorder
+}
+
+// MockCandleStoreMockRecorder is the mock recorder for MockCandleStore.
+type MockCandleStoreMockRecorder struct {
+	mock *MockCandleStore
+}
+
+// NewMockCandleStore creates a new mock instance.
+func NewMockCandleStore(ctrl *gomock.Controller) *MockCandleStore {
+	mock := &MockCandleStore{ctrl: ctrl}
+	mock.recorder = &MockCandleStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCandleStore) EXPECT() *MockCandleStoreMockRecorder {
+	return m.recorder
+}
+
+// AddCandle mocks base method.
+func (m *MockCandleStore) AddCandle(arg0 string, arg1 domain.Candle) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SubscribeCandle", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "AddCandle", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SubscribeCandle indicates an expected call of SubscribeCandle.
-func (mr *MockStockMarketSocketMockRecorder) SubscribeCandle(arg0, arg1, arg2 interface{}) *gomock.Call {
+// AddCandle indicates an expected call of AddCandle.
+func (mr *MockCandleStoreMockRecorder) AddCandle(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeCandle", reflect.TypeOf((*MockStockMarketSocket)(nil).SubscribeCandle), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCandle", reflect.TypeOf((*MockCandleStore)(nil).AddCandle), arg0, arg1)
 }
 
 // MockIndicator is a mock of Indicator interface.
@@ -2,20 +2,73 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/fakekraken"
+	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	pair  = makePair()
-	pairs = make(Pairs)
+	pair   = makePair()
+	pairs  = make(Pairs)
+	config = domain.Config{PairName: "name", PairInterval: domain.Candle1m,
+		IndicatorName: DonchianName}
+	atrConfig = domain.Config{PairName: "name", PairInterval: domain.Candle1m,
+		IndicatorName: DonchianName, Sizing: domain.Sizing{Mode: domain.ATRSizing,
+			Capital: 100, Fraction: 0.01, ATRPeriod: 1}}
 )
 
+// pairOption edits test pair, options are applied in order.
+type pairOption func(*Pair)
+
+// withName sets pair's name.
+func withName(name string) pairOption {
+	return func(pair *Pair) {
+		pair.Name = name
+	}
+}
+
+// withInterval sets pair's interval.
+func withInterval(interval domain.CandleInterval) pairOption {
+	return func(pair *Pair) {
+		pair.Interval = interval
+	}
+}
+
+// withLog sets pair's logger.
+func withLog(log *logrus.Logger) pairOption {
+	return func(pair *Pair) {
+		pair.log = log
+	}
+}
+
+// withStopped makes pair stoppable without running it.
+func withStopped() pairOption {
+	return func(pair *Pair) {
+		pair.cancel = func() {}
+		close(pair.stop)
+	}
+}
+
+// withUsers adds users by pairConfig with pair's name and interval.
+func withUsers(t *testing.T, pairConfig domain.Config, users ...*domain.User) pairOption {
+	return func(pair *Pair) {
+		pairConfig.PairName = pair.Name
+		pairConfig.PairInterval = pair.Interval
+		for _, user := range users {
+			assert.NoError(t, pair.AddUser(user, pairConfig))
+		}
+	}
+}
+
 func TestMain(m *testing.M) {
 	pair.log.SetOutput(io.Discard)
 	code := m.Run()
@@ -30,25 +83,54 @@ func setupPairs() {
 	pairs = make(Pairs)
 }
 
-func makePair() *Pair {
-	return &Pair{
-		Name:      "name",
-		Users:     make([]*domain.User, 0),
-		Interval:  domain.Candle1m,
-		Indicator: &MockIndicator{},
-		stop:      make(chan struct{}),
-		socket:    &MockStockMarketSocket{},
-		ctx:       context.Background(),
-		log:       logrus.New(),
+func makePair(options ...pairOption) *Pair {
+	pair := &Pair{
+		Name:         "name",
+		Users:        make([]*domain.User, 0),
+		Interval:     domain.Candle1m,
+		Indicators:   make(map[string]Indicator),
+		Sizers:       make(map[string]*domain.Sizer),
+		Configs:      make(map[string]domain.Config),
+		muIndicators: &sync.Mutex{},
+		stop:         make(chan struct{}),
+		filters:      &sync.WaitGroup{},
+		socket:       &MockStockMarketSocket{},
+		ctx:          context.Background(),
+		log:          logrus.New(),
 	}
+	for _, option := range options {
+		option(pair)
+	}
+	return pair
+}
+
+// makePairs returns Pairs of running pairs.
+func makePairs(running ...*Pair) Pairs {
+	pairs := make(Pairs)
+	for _, pair := range running {
+		if _, ok := pairs[pair.Name]; !ok {
+			pairs[pair.Name] = make(map[domain.CandleInterval]*Pair)
+		}
+		pairs[pair.Name][pair.Interval] = pair
+	}
+	return pairs
+}
+
+// makeAllocationPairs returns Pairs of user's pairs sized by ATR.
+func makeAllocationPairs(t *testing.T, user *domain.User) Pairs {
+	return makePairs(
+		makePair(withName("first"), withUsers(t, atrConfig, user)),
+		makePair(withName("second"), withInterval(domain.Candle5m), withUsers(t, atrConfig, user)))
 }
 
 func TestNewPair(t *testing.T) {
-	nilPair, err := NewPair("name", "", DonchianName, nil)
+	nilPair, err := NewPair(config, &MockStockMarketSocket{}, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, nilPair)
 
-	nilPair, err = NewPair("name", "", "", nil)
+	badConfig := config
+	badConfig.IndicatorName = ""
+	nilPair, err = NewPair(badConfig, &MockStockMarketSocket{}, nil)
 	assert.Error(t, err)
 	assert.Nil(t, nilPair)
 }
@@ -56,11 +138,11 @@ func TestNewPair(t *testing.T) {
 func TestPair_AddUser(t *testing.T) {
 	setup()
 	user := domain.NewUser("name")
-	err := pair.AddUser(user)
+	err := pair.AddUser(user, config)
 	assert.NoError(t, err)
 	assert.Equal(t, len(pair.Users), 1)
 
-	err = pair.AddUser(user)
+	err = pair.AddUser(user, config)
 	assert.Error(t, err)
 	assert.Equal(t, len(pair.Users), 1)
 }
@@ -68,7 +150,7 @@ func TestPair_AddUser(t *testing.T) {
 func TestPair_DeleteUser(t *testing.T) {
 	setup()
 	user := domain.NewUser("name")
-	_ = pair.AddUser(user)
+	_ = pair.AddUser(user, config)
 	err := pair.DeleteUser(user)
 	assert.NoError(t, err)
 	assert.Equal(t, len(pair.Users), 0)
@@ -77,32 +159,496 @@ func TestPair_DeleteUser(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestPair_MoveUser(t *testing.T) {
+	setup()
+	user := domain.NewUser("name")
+	warmed := makePair()
+	assert.ErrorIs(t, pair.MoveUser(user, warmed), ErrUserIsNotLogged)
+	assert.NoError(t, warmed.AddUser(user, config))
+	assert.NoError(t, pair.MoveUser(user, warmed))
+	assert.True(t, pair.IsUserLogged(user))
+	assert.Same(t, warmed.Indicators[user.Username], pair.Indicators[user.Username])
+	assert.Equal(t, config, pair.Configs[user.Username])
+	assert.ErrorIs(t, pair.MoveUser(user, warmed), ErrUserIsLogged)
+}
+
 func TestPair_IsUserLogged(t *testing.T) {
 	setup()
 	user := domain.NewUser("name")
 	assert.False(t, pair.IsUserLogged(user))
-	_ = pair.AddUser(user)
+	_ = pair.AddUser(user, config)
 	assert.True(t, pair.IsUserLogged(user))
 	_ = pair.DeleteUser(user)
 	assert.False(t, pair.IsUserLogged(user))
 }
 
 func TestPairs_AddPair(t *testing.T) {
-	user := domain.NewUser("name")
-	pairs[pair.Name] = make(map[domain.CandleInterval]*Pair)
-	err := pairs.AddPair(pair, user)
+	setupPairs()
+	err := pairs.AddPair(pair)
 	assert.NoError(t, err)
 	assert.Equal(t, len(pairs[pair.Name]), 1)
-	err = pairs.AddPair(pair, user)
-	assert.Error(t, err)
+	err = pairs.AddPair(pair)
+	assert.ErrorIs(t, err, ErrPairIsRunning)
 	assert.Equal(t, len(pairs[pair.Name]), 1)
 }
 
 func TestPairs_IsExist(t *testing.T) {
 	setupPairs()
 	assert.False(t, pairs.IsExist(pair.Name, pair.Interval))
-	user := domain.NewUser("name")
-	pairs[pair.Name] = make(map[domain.CandleInterval]*Pair)
-	_ = pairs.AddPair(pair, user)
+	_ = pairs.AddPair(pair)
 	assert.True(t, pairs.IsExist(pair.Name, pair.Interval))
 }
+
+func TestPairs_Active(t *testing.T) {
+	setupPairs()
+	active, users := pairs.Active()
+	assert.Equal(t, active, 0)
+	assert.Equal(t, users, 0)
+	user := domain.NewUser("username")
+	allocationPairs := makeAllocationPairs(t, user)
+	assert.NoError(t, allocationPairs["first"][domain.Candle1m].AddUser(domain.NewUser("other"), config))
+	active, users = allocationPairs.Active()
+	assert.Equal(t, active, 2)
+	assert.Equal(t, users, 2)
+}
+
+func TestPair_User(t *testing.T) {
+	setup()
+	first := domain.NewUser("first")
+	_, ok := pair.User(first.Username)
+	assert.False(t, ok)
+	assert.NoError(t, pair.AddUser(first, config))
+	user, ok := pair.User(first.Username)
+	assert.True(t, ok)
+	assert.Equal(t, user, first)
+	assert.Equal(t, len(pair.Indicators), 1)
+
+	badConfig := config
+	badConfig.Params = domain.IndicatorParams{ChannelSize: -1}
+	assert.Error(t, pair.AddUser(domain.NewUser("second"), badConfig))
+	assert.Equal(t, len(pair.Indicators), 1)
+
+	assert.NoError(t, pair.DeleteUser(first))
+	assert.Equal(t, len(pair.Indicators), 0)
+	assert.Equal(t, len(pair.Sizers), 0)
+}
+
+func TestPair_Size(t *testing.T) {
+	setup()
+	user := domain.NewUser("name")
+	_, err := pair.Size(user.Username, 10, 0)
+	assert.ErrorIs(t, err, ErrUserIsNotLogged)
+	notionalConfig := config
+	notionalConfig.Sizing = domain.Sizing{Mode: domain.NotionalSizing, Notional: 100}
+	assert.NoError(t, pair.AddUser(user, notionalConfig))
+	size, err := pair.Size(user.Username, 30, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, size, int64(3))
+}
+
+func TestPair_addCandle(t *testing.T) {
+	setup()
+	pair.log.SetOutput(io.Discard)
+	early := domain.NewUser("early")
+	stopConfig := config
+	stopConfig.Order = domain.OrderOptions{Type: domain.StopOrder, Trigger: 0.1}
+	assert.NoError(t, pair.AddUser(early, stopConfig))
+	candles := []domain.Candle{
+		{High: 1, Low: 1, Time: 1},
+		{High: 1, Low: 1, Time: 2},
+		{High: 2, Low: 1, Time: 3},
+	}
+	var events []domain.StockMarketEvent
+	for _, candle := range candles {
+		var err error
+		events, err = pair.addCandle(candle)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Signal, domain.OpenLong)
+	assert.Equal(t, events[0].Username, early.Username)
+	assert.Equal(t, events[0].Indicator, config.IndicatorName)
+	// stop order protects immediate-or-cancel entry
+	assert.Equal(t, events[0].Order, domain.OrderOptions{Type: domain.IOCOrder})
+	assert.Equal(t, events[0].Protection, domain.OrderOptions{Type: domain.StopOrder,
+		Trigger: 0.1, ReduceOnly: true})
+
+	// late user starts without entered position and doesn't get sell signal
+	late := domain.NewUser("late")
+	assert.NoError(t, pair.AddUser(late, config))
+	candles = []domain.Candle{
+		{High: 2, Low: 1, Time: 4},
+		{High: 2, Low: 1, Time: 5},
+		{High: 2, Low: 0.5, Time: 6},
+	}
+	for _, candle := range candles {
+		var err error
+		events, err = pair.addCandle(candle)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Signal, domain.CloseLong)
+	assert.Equal(t, events[0].Username, early.Username)
+
+	events, err := pair.addCandle(domain.Candle{High: 2, Low: 1, Time: 6})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	_, err = pair.addCandle(domain.Candle{High: -1, Time: 7})
+	assert.Error(t, err)
+}
+
+func TestPair_RunBackfilled(t *testing.T) {
+	setup()
+	c := gomock.NewController(t)
+	defer c.Finish()
+	socket := NewMockStockMarketSocket(c)
+	pair.socket = socket
+	assert.NoError(t, pair.AddUser(domain.NewUser("username"), config))
+	socket.EXPECT().SubscribeCandle(gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any()).DoAndReturn(func(_ context.Context, _ Pair, candles chan domain.Candle,
+		_ chan PairError) error {
+		go func() {
+			candles <- domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 1,
+				Backfilled: true}
+			candles <- domain.Candle{Open: 1, High: 2, Low: 1, Close: 2, Time: 2}
+			close(candles)
+		}()
+		return nil
+	})
+	events := make(chan domain.StockMarketEvent)
+	ticks := make(chan PairTick, 2)
+	errors := make(chan PairError)
+	assert.NoError(t, pair.Run(events, ticks, errors))
+	<-pair.stop
+	assert.Len(t, ticks, 1)
+	tick := <-ticks
+	assert.Equal(t, int64(2), tick.Candle.Time)
+}
+
+func TestPair_RunStore(t *testing.T) {
+	setup()
+	c := gomock.NewController(t)
+	defer c.Finish()
+	socket := NewMockStockMarketSocket(c)
+	store := NewMockCandleStore(c)
+	pair.socket = socket
+	pair.Store = store
+	assert.NoError(t, pair.AddUser(domain.NewUser("username"), config))
+	socket.EXPECT().SubscribeCandle(gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any()).DoAndReturn(func(_ context.Context, _ Pair, candles chan domain.Candle,
+		_ chan PairError) error {
+		go func() {
+			candles <- domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 1}
+			candles <- domain.Candle{Open: 1, High: 2, Low: 1, Close: 2, Time: 1}
+			candles <- domain.Candle{Open: 2, High: 2, Low: 2, Close: 2, Time: 2}
+			close(candles)
+		}()
+		return nil
+	})
+	// only the first candle is closed
+	store.EXPECT().AddCandle(pair.Name, domain.Candle{Interval: pair.Interval,
+		Open: 1, High: 2, Low: 1, Close: 2, Time: 1}).Return(nil)
+	events := make(chan domain.StockMarketEvent, 3)
+	ticks := make(chan PairTick, 3)
+	errors := make(chan PairError)
+	assert.NoError(t, pair.Run(events, ticks, errors))
+	<-pair.stop
+	assert.Len(t, ticks, 3)
+}
+
+func TestPair_RunFilters(t *testing.T) {
+	setup()
+	c := gomock.NewController(t)
+	defer c.Finish()
+	socket := NewMockStockMarketSocket(c)
+	pair.socket = socket
+	filterConfig := config
+	filterConfig.Params.ChannelSize = 1
+	filterConfig.Filters = []domain.FilterConfig{{Interval: domain.Candle10m,
+		IndicatorName: DonchianName, Params: domain.IndicatorParams{ChannelSize: 1}}}
+	assert.NoError(t, pair.AddUser(domain.NewUser("username"), filterConfig))
+	feeds := make(map[domain.CandleInterval]chan domain.Candle)
+	socket.EXPECT().SubscribeCandle(gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any()).DoAndReturn(func(_ context.Context, subscribed Pair,
+		candles chan domain.Candle, _ chan PairError) error {
+		assert.Equal(t, pair.Name, subscribed.Name)
+		feeds[subscribed.Interval] = candles
+		return nil
+	}).Times(2)
+	events := make(chan domain.StockMarketEvent, 1)
+	ticks := make(chan PairTick, 2)
+	errors := make(chan PairError)
+	assert.NoError(t, pair.Run(events, ticks, errors))
+	assert.Len(t, feeds, 2)
+
+	// the filter trend is up after its breakout
+	feeds[domain.Candle10m] <- domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 1}
+	feeds[domain.Candle10m] <- domain.Candle{Open: 1, High: 2, Low: 1, Close: 2, Time: 2}
+	feeds[domain.Candle10m] <- domain.Candle{Open: 2, High: 2, Low: 2, Close: 2, Time: 3}
+	close(feeds[domain.Candle10m])
+	feeds[domain.Candle1m] <- domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 1}
+	feeds[domain.Candle1m] <- domain.Candle{Open: 1, High: 2, Low: 1, Close: 2, Time: 2}
+	close(feeds[domain.Candle1m])
+	<-pair.stop
+	assert.Len(t, events, 1)
+	event := <-events
+	assert.Equal(t, domain.OpenLong, event.Signal)
+}
+
+func TestPair_RunDerived(t *testing.T) {
+	setup()
+	c := gomock.NewController(t)
+	defer c.Finish()
+	server := fakekraken.NewServer()
+	defer server.Close()
+	minute := time.Minute.Milliseconds()
+	start := time.Now().UnixMilli() / (15 * minute) * (15 * minute)
+	socket := newTestSocket(server)
+	// candles of the current period before subscription are read from history
+	socket.History = historyFunc(func(_ string, interval domain.CandleInterval,
+		from int64) ([]domain.Candle, error) {
+		assert.Equal(t, domain.Candle1m, interval)
+		assert.Equal(t, start, from)
+		return []domain.Candle{
+			{Open: 10, High: 11, Low: 9, Close: 11, Volume: 1, Time: start},
+			{Open: 11, High: 12, Low: 11, Close: 11, Volume: 1, Time: start + minute},
+		}, nil
+	})
+	server.AddCandles("PI_XBTUSD", domain.Candle1m,
+		domain.Candle{Open: 11, High: 13, Low: 11, Close: 12, Volume: 1, Time: start + minute},
+		domain.Candle{Open: 12, High: 12, Low: 8, Close: 9, Volume: 2, Time: start + 2*minute},
+		domain.Candle{Open: 9, High: 10, Low: 9, Close: 10, Volume: 1, Time: start + 3*minute},
+		domain.Candle{Open: 10, High: 10, Low: 10, Close: 10, Volume: 1, Time: start + 15*minute})
+	pair.Name = "PI_XBTUSD"
+	pair.Interval = domain.Candle15m
+	pair.socket = socket
+	derivedConfig := config
+	derivedConfig.PairName = pair.Name
+	derivedConfig.PairInterval = pair.Interval
+	assert.NoError(t, pair.AddUser(domain.NewUser("username"), derivedConfig))
+	indicator := NewMockIndicator(c)
+	pair.Indicators["username"] = indicator
+	// indicator gets the whole period once
+	received := make(chan struct{})
+	indicator.EXPECT().Add(domain.Candle{Interval: domain.Candle15m, Open: 10, High: 13, Low: 8,
+		Close: 10, Volume: 5, Time: start}).DoAndReturn(func(domain.Candle) (domain.Signal, error) {
+		close(received)
+		return domain.WaitToBuy, nil
+	})
+
+	events := make(chan domain.StockMarketEvent)
+	ticks := make(chan PairTick, 1)
+	errors := make(chan PairError)
+	assert.NoError(t, pair.Run(events, ticks, errors))
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("derived candle isn't received")
+	}
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	pair.Stop(wg)
+}
+
+func TestPair_ReleaseFilters(t *testing.T) {
+	setup()
+	c := gomock.NewController(t)
+	defer c.Finish()
+	socket := NewMockStockMarketSocket(c)
+	pair.socket = socket
+	filterConfig := config
+	filterConfig.Filters = []domain.FilterConfig{{Interval: domain.Candle10m,
+		IndicatorName: DonchianName}}
+	first, second := domain.NewUser("first"), domain.NewUser("second")
+	assert.NoError(t, pair.AddUser(first, filterConfig))
+	feeds := make(map[domain.CandleInterval]context.Context)
+	socket.EXPECT().SubscribeCandle(gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any()).DoAndReturn(func(ctx context.Context, subscribed Pair,
+		candles chan domain.Candle, _ chan PairError) error {
+		feeds[subscribed.Interval] = ctx
+		go func() {
+			<-ctx.Done()
+			close(candles)
+		}()
+		return nil
+	}).Times(2)
+	events := make(chan domain.StockMarketEvent)
+	ticks := make(chan PairTick)
+	errors := make(chan PairError)
+	assert.NoError(t, pair.Run(events, ticks, errors))
+
+	// filter interval is subscribed once and is released by its last user
+	assert.NoError(t, pair.AddUser(second, filterConfig))
+	pair.SubscribeFilters()
+	assert.NoError(t, pair.DeleteUser(first))
+	assert.NoError(t, feeds[domain.Candle10m].Err())
+	assert.NoError(t, pair.DeleteUser(second))
+	assert.Error(t, feeds[domain.Candle10m].Err())
+	assert.NoError(t, feeds[domain.Candle1m].Err())
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	pair.Stop(wg)
+	assert.Empty(t, pair.filterFeeds)
+}
+
+// historyFunc implements CandleHistory by function.
+type historyFunc func(string, domain.CandleInterval, int64) ([]domain.Candle, error)
+
+func (history historyFunc) Candles(pairName string, interval domain.CandleInterval,
+	from int64) ([]domain.Candle, error) {
+	return history(pairName, interval, from)
+}
+
+func TestPair_WarmUp(t *testing.T) {
+	setup()
+	warmConfig := config
+	warmConfig.WarmUp = 3
+	assert.NoError(t, pair.AddUser(domain.NewUser("username"), warmConfig))
+	assert.NoError(t, pair.AddUser(domain.NewUser("cold"), config))
+	start := time.Now()
+	history := historyFunc(func(pairName string, interval domain.CandleInterval,
+		from int64) ([]domain.Candle, error) {
+		assert.Equal(t, pair.Name, pairName)
+		assert.Equal(t, pair.Interval, interval)
+		assert.LessOrEqual(t, from, start.Add(-3*time.Minute).UnixMilli()+1000)
+		return []domain.Candle{
+			{Open: 1, High: 1, Low: 1, Close: 1, Time: 1},
+			{Open: 1, High: 1, Low: 1, Close: 1, Time: 2},
+			// breakout is skipped and position isn't entered
+			{Open: 1, High: 5, Low: 1, Close: 5, Time: 3},
+			{Open: 5, High: 5, Low: 4, Close: 5, Time: 4},
+		}, nil
+	})
+	count, err := pair.WarmUp(history, "username")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	events, err := pair.addCandle(domain.Candle{Open: 5, High: 6, Low: 5, Close: 6, Time: 5})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, domain.OpenLong, events[0].Signal)
+	assert.Equal(t, "username", events[0].Username)
+
+	_, err = pair.WarmUp(historyFunc(func(string, domain.CandleInterval,
+		int64) ([]domain.Candle, error) {
+		return nil, fmt.Errorf("history isn't available")
+	}), "cold")
+	assert.Error(t, err)
+}
+
+func TestPair_WarmUpFilters(t *testing.T) {
+	setup()
+	filterConfig := config
+	filterConfig.WarmUp = 2
+	filterConfig.Filters = []domain.FilterConfig{{Interval: domain.Candle10m,
+		IndicatorName: DonchianName, Params: domain.IndicatorParams{ChannelSize: 1}}}
+	assert.NoError(t, pair.AddUser(domain.NewUser("username"), filterConfig))
+	history := historyFunc(func(pairName string, interval domain.CandleInterval,
+		from int64) ([]domain.Candle, error) {
+		if interval == domain.Candle10m {
+			return []domain.Candle{
+				{Open: 1, High: 1, Low: 1, Close: 1, Time: 1},
+				{Open: 1, High: 2, Low: 1, Close: 2, Time: 2},
+			}, nil
+		}
+		return []domain.Candle{{Open: 1, High: 1, Low: 1, Close: 1, Time: 1}}, nil
+	})
+	count, err := pair.WarmUp(history, "username")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	composite, ok := pair.Indicators["username"].(*CompositeIndicator)
+	assert.True(t, ok)
+	assert.Equal(t, domain.Buy, composite.filters[0].trend)
+}
+
+func TestPair_RunPaused(t *testing.T) {
+	setup()
+	c := gomock.NewController(t)
+	defer c.Finish()
+	socket := NewMockStockMarketSocket(c)
+	pair.socket = socket
+	pausedConfig := config
+	pausedConfig.Params.ChannelSize = 1
+	assert.NoError(t, pair.AddUser(domain.NewUser("username"), pausedConfig))
+	socket.EXPECT().SubscribeCandle(gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any()).DoAndReturn(func(_ context.Context, _ Pair, candles chan domain.Candle,
+		_ chan PairError) error {
+		go func() {
+			candles <- domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 1}
+			candles <- domain.Candle{Open: 1, High: 3, Low: 1, Close: 3, Time: 2}
+			candles <- domain.Candle{Open: 3, High: 5, Low: 3, Close: 5, Time: 3}
+			candles <- domain.Candle{Open: 5, High: 5, Low: 5, Close: 5, Time: 4}
+			close(candles)
+		}()
+		return nil
+	})
+	pair.Pause()
+	events := make(chan domain.StockMarketEvent, 4)
+	ticks := make(chan PairTick, 4)
+	errors := make(chan PairError)
+	assert.NoError(t, pair.Run(events, ticks, errors))
+	<-pair.stop
+	// ticks are sent, so positions are still marked
+	assert.Len(t, ticks, 4)
+	assert.Empty(t, events)
+	info := pair.Info()
+	assert.True(t, info.Paused)
+	assert.Equal(t, int64(4), info.LastCandle)
+	assert.Len(t, info.Subscribers, 1)
+	assert.NotEmpty(t, info.Subscribers[0].State)
+}
+
+func TestPair_RunResumed(t *testing.T) {
+	setup()
+	c := gomock.NewController(t)
+	defer c.Finish()
+	socket := NewMockStockMarketSocket(c)
+	pair.socket = socket
+	pausedConfig := config
+	pausedConfig.Params.ChannelSize = 1
+	assert.NoError(t, pair.AddUser(domain.NewUser("username"), pausedConfig))
+	candles := make(chan chan domain.Candle, 1)
+	socket.EXPECT().SubscribeCandle(gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any()).DoAndReturn(func(_ context.Context, _ Pair, pairCandles chan domain.Candle,
+		_ chan PairError) error {
+		candles <- pairCandles
+		return nil
+	})
+	events := make(chan domain.StockMarketEvent, 4)
+	ticks := make(chan PairTick)
+	assert.NoError(t, pair.Run(events, ticks, make(chan PairError)))
+	feed := <-candles
+	send := func(candle domain.Candle) {
+		feed <- candle
+		<-ticks
+	}
+
+	// entry of paused pair is skipped
+	pair.Pause()
+	send(domain.Candle{Open: 1, High: 1, Low: 1, Close: 1, Time: 1})
+	send(domain.Candle{Open: 1, High: 3, Low: 1, Close: 3, Time: 2})
+	// position is flat, so the next low breakout doesn't close it
+	pair.Resume(func(string) bool { return true })
+	send(domain.Candle{Open: 3, High: 3, Low: 0.5, Close: 0.5, Time: 3})
+	send(domain.Candle{Open: 0.5, High: 4, Low: 0.5, Close: 4, Time: 4})
+	// exit of paused pair is sent
+	pair.Pause()
+	send(domain.Candle{Open: 4, High: 4, Low: 0.2, Close: 0.2, Time: 5})
+	close(feed)
+	<-pair.stop
+	close(events)
+	signals := make([]domain.Signal, 0)
+	for event := range events {
+		signals = append(signals, event.Signal)
+	}
+	assert.Equal(t, []domain.Signal{domain.OpenLong, domain.CloseLong}, signals)
+}
+
+func TestPairs_Detach(t *testing.T) {
+	running := makeAllocationPairs(t, domain.NewUser("username"))
+	detached := running.Detach()
+	assert.Empty(t, running)
+	assert.True(t, detached.IsExist("first", domain.Candle1m))
+	assert.True(t, detached.IsExist("second", domain.Candle5m))
+}
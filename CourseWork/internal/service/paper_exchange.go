@@ -0,0 +1,203 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+const (
+	DefaultPaperCash = 10000
+	paperOrderPrefix = "paper"
+)
+
+var (
+	ErrInsufficientFunds    = errors.New("insufficient funds for paper order")
+	ErrInsufficientPosition = errors.New("insufficient position for paper order")
+	ErrNotFilled            = errors.New("paper order can't be filled by limit price")
+	ErrUnsupportedOrderType = errors.New("paper exchange fills only ioc and limit orders")
+	ErrUnknownOrder         = errors.New("paper exchange doesn't have resting orders")
+)
+
+// PaperAccount describes simulated user's cash balance and positions.
+type PaperAccount struct {
+	Cash      float64
+	Positions map[string]int64
+}
+
+// PaperExchange implements StockMarketAPI with simulated fills.
+// Accounts are kept in memory and are restored from stored paper orders after restart.
+// Order ids start with the exchange's start time, so they are unique across restarts.
+type PaperExchange struct {
+	accounts    map[string]*PaperAccount
+	muAccounts  *sync.Mutex
+	initialCash float64
+	session     int64
+	orders      int64
+}
+
+// NewPaperExchange returns pointer to PaperExchange with initial cash for every user.
+func NewPaperExchange(initialCash float64) *PaperExchange {
+	return &PaperExchange{
+		accounts:    make(map[string]*PaperAccount),
+		muAccounts:  &sync.Mutex{},
+		initialCash: initialCash,
+		session:     time.Now().UnixNano(),
+	}
+}
+
+// IsPaperOrder checks if order was placed by PaperExchange.
+func IsPaperOrder(orderID string) bool {
+	return strings.HasPrefix(orderID, paperOrderPrefix+"-")
+}
+
+// AddOrder fills immediate-or-cancel limit order by event close price.
+// Limit order is filled the same way, because paper orders don't rest.
+// Buy order is reduced by cash balance and sell order is reduced by long position,
+// signal, which opens short position, could sell by account's equity too.
+// Reduce-only order is reduced by position of the opposite side.
+func (exchange *PaperExchange) AddOrder(event domain.StockMarketEvent,
+	user *domain.User) (domain.OrderInfo, error) {
+	orderType := event.Order.OrderType()
+	if orderType != domain.IOCOrder && orderType != domain.LimitOrder {
+		return domain.OrderInfo{}, ErrUnsupportedOrderType
+	}
+	side := event.Signal.Side()
+	requested := orderVolume(event.Volume)
+	size := requested
+	limitPrice := countLimitPrice(side, event.Close, user.GetLimit(event.Name))
+	price := event.Close
+	if price <= 0 {
+		return domain.OrderInfo{}, ErrNotFilled
+	}
+	reduceOnly := event.Order.ReduceOnly || event.Signal.IsReduceOnly()
+
+	exchange.muAccounts.Lock()
+	defer exchange.muAccounts.Unlock()
+	account := exchange.account(user.Username)
+	position := account.Positions[event.Name]
+	switch side {
+	case domain.Buy:
+		if price > limitPrice {
+			return domain.OrderInfo{}, ErrNotFilled
+		}
+		if reduceOnly {
+			size = minInt64(size, maxInt64(-position, 0))
+			if size == 0 {
+				return domain.OrderInfo{}, ErrInsufficientPosition
+			}
+		}
+		size = minInt64(size, int64(math.Floor(account.Cash/price)))
+		if size == 0 {
+			return domain.OrderInfo{}, ErrInsufficientFunds
+		}
+		account.Cash -= price * float64(size)
+		account.Positions[event.Name] += size
+	case domain.Sell:
+		if price < limitPrice {
+			return domain.OrderInfo{}, ErrNotFilled
+		}
+		available := maxInt64(position, 0)
+		isShortEntry := event.Signal == domain.OpenShort || event.Signal == domain.ReverseToShort
+		if isShortEntry && !reduceOnly {
+			equity := account.Cash + float64(position)*price
+			available += maxInt64(int64(math.Floor(equity/price))-maxInt64(-position, 0), 0)
+		}
+		size = minInt64(size, available)
+		if size == 0 {
+			return domain.OrderInfo{}, ErrInsufficientPosition
+		}
+		account.Cash += price * float64(size)
+		account.Positions[event.Name] -= size
+	default:
+		return domain.OrderInfo{}, fmt.Errorf("unsupported paper order side <%s>", event.Signal)
+	}
+	exchange.orders++
+	orderID := fmt.Sprintf("%s-%d-%d-%s", paperOrderPrefix, exchange.session, exchange.orders,
+		user.Username)
+	return domain.OrderInfo{
+		Name:    event.Name,
+		OrderID: orderID,
+		Price:   price,
+		Amount:  size,
+		Side:    string(side),
+		Type:    orderType,
+		Size:    requested,
+		Status:  placed,
+	}, nil
+}
+
+// Restore applies filled part of stored paper order to user's account.
+func (exchange *PaperExchange) Restore(info domain.OrderInfo) error {
+	if !IsPaperOrder(info.OrderID) {
+		return fmt.Errorf("order <%s> isn't paper order", info.OrderID)
+	}
+	exchange.muAccounts.Lock()
+	defer exchange.muAccounts.Unlock()
+	account := exchange.account(info.Username)
+	switch domain.Signal(info.Side) {
+	case domain.Buy:
+		account.Cash -= info.Price * float64(info.Amount)
+		account.Positions[info.Name] += info.Amount
+	case domain.Sell:
+		account.Cash += info.Price * float64(info.Amount)
+		account.Positions[info.Name] -= info.Amount
+	default:
+		return fmt.Errorf("unsupported paper order side <%s>", info.Side)
+	}
+	return nil
+}
+
+// CancelOrder is CancelOrder implementation of StockMarketAPI, paper orders are never rested.
+func (exchange *PaperExchange) CancelOrder(string, *domain.User) error {
+	return ErrUnknownOrder
+}
+
+// OpenOrders is OpenOrders implementation of StockMarketAPI, paper orders are never rested.
+func (exchange *PaperExchange) OpenOrders(*domain.User) ([]domain.OpenOrder, error) {
+	return make([]domain.OpenOrder, 0), nil
+}
+
+// Account returns copy of user's PaperAccount.
+func (exchange *PaperExchange) Account(username string) PaperAccount {
+	exchange.muAccounts.Lock()
+	defer exchange.muAccounts.Unlock()
+	account := exchange.account(username)
+	positions := make(map[string]int64, len(account.Positions))
+	for name, amount := range account.Positions {
+		positions[name] = amount
+	}
+	return PaperAccount{Cash: account.Cash, Positions: positions}
+}
+
+// account returns user's PaperAccount and creates it if it doesn't exist.
+func (exchange *PaperExchange) account(username string) *PaperAccount {
+	account, ok := exchange.accounts[username]
+	if !ok {
+		account = &PaperAccount{
+			Cash:      exchange.initialCash,
+			Positions: make(map[string]int64),
+		}
+		exchange.accounts[username] = account
+	}
+	return account
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
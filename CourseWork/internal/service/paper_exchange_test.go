@@ -0,0 +1,124 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaperExchange_AddOrder(t *testing.T) {
+	exchange := NewPaperExchange(100)
+	user := domain.NewUser("name")
+
+	_, err := exchange.AddOrder(domain.StockMarketEvent{Signal: domain.Sell,
+		Name: "pair", Volume: 1, Close: 10}, user)
+	assert.ErrorIs(t, err, ErrInsufficientPosition)
+
+	// partial fill by cash balance
+	info, err := exchange.AddOrder(domain.StockMarketEvent{Signal: domain.Buy,
+		Name: "pair", Volume: 20, Close: 30}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Amount, int64(3))
+	assert.Equal(t, info.Price, 30.)
+	assert.Equal(t, info.Side, string(domain.Buy))
+	assert.NotEmpty(t, info.OrderID)
+	account := exchange.Account(user.Username)
+	assert.Equal(t, account.Cash, 10.)
+	assert.Equal(t, account.Positions["pair"], int64(3))
+
+	_, err = exchange.AddOrder(domain.StockMarketEvent{Signal: domain.Buy,
+		Name: "pair", Volume: 1, Close: 30}, user)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// partial fill by position
+	info, err = exchange.AddOrder(domain.StockMarketEvent{Signal: domain.Sell,
+		Name: "pair", Volume: 5, Close: 40}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Amount, int64(3))
+	account = exchange.Account(user.Username)
+	assert.Equal(t, account.Cash, 130.)
+	assert.Equal(t, account.Positions["pair"], int64(0))
+
+	_, err = exchange.AddOrder(domain.StockMarketEvent{Signal: domain.WaitToBuy,
+		Name: "pair", Volume: 1, Close: 30}, user)
+	assert.Error(t, err)
+	_, err = exchange.AddOrder(domain.StockMarketEvent{Signal: domain.Buy,
+		Name: "pair", Volume: 1}, user)
+	assert.ErrorIs(t, err, ErrNotFilled)
+	_, err = exchange.AddOrder(domain.StockMarketEvent{Signal: domain.Buy,
+		Name: "pair", Volume: 1, Close: 30, Order: domain.OrderOptions{
+			Type: domain.PostOnlyOrder}}, user)
+	assert.ErrorIs(t, err, ErrUnsupportedOrderType)
+	orders, err := exchange.OpenOrders(user)
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+	assert.ErrorIs(t, exchange.CancelOrder("id", user), ErrUnknownOrder)
+}
+
+func TestPaperExchange_AddOrderShort(t *testing.T) {
+	exchange := NewPaperExchange(100)
+	user := domain.NewUser("name")
+
+	// short position is reduced by equity
+	info, err := exchange.AddOrder(domain.StockMarketEvent{Signal: domain.OpenShort,
+		Name: "pair", Volume: 20, Close: 10}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Amount, int64(10))
+	assert.Equal(t, info.Side, string(domain.Sell))
+	account := exchange.Account(user.Username)
+	assert.Equal(t, account.Cash, 200.)
+	assert.Equal(t, account.Positions["pair"], int64(-10))
+
+	// reduce-only order is reduced by position
+	info, err = exchange.AddOrder(domain.StockMarketEvent{Signal: domain.CloseShort,
+		Name: "pair", Volume: 20, Close: 10}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Amount, int64(10))
+	_, err = exchange.AddOrder(domain.StockMarketEvent{Signal: domain.CloseShort,
+		Name: "pair", Volume: 1, Close: 10}, user)
+	assert.ErrorIs(t, err, ErrInsufficientPosition)
+
+	_, err = exchange.AddOrder(domain.StockMarketEvent{Signal: domain.OpenLong,
+		Name: "pair", Volume: 5, Close: 10}, user)
+	assert.NoError(t, err)
+	info, err = exchange.AddOrder(domain.StockMarketEvent{Signal: domain.ReverseToShort,
+		Name: "pair", Volume: 10, Close: 10}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Amount, int64(10))
+	account = exchange.Account(user.Username)
+	assert.Equal(t, account.Cash, 150.)
+	assert.Equal(t, account.Positions["pair"], int64(-5))
+}
+
+func TestPaperExchange_Restore(t *testing.T) {
+	exchange := NewPaperExchange(100)
+	user := domain.NewUser("name")
+	info, err := exchange.AddOrder(domain.StockMarketEvent{Signal: domain.OpenLong,
+		Name: "pair", Volume: 5, Close: 10}, user)
+	assert.NoError(t, err)
+	assert.True(t, IsPaperOrder(info.OrderID))
+	assert.False(t, IsPaperOrder("kraken-id"))
+
+	// restarted exchange has default cash and no positions
+	restarted := NewPaperExchange(100)
+	assert.Equal(t, restarted.Account(user.Username).Positions["pair"], int64(0))
+	info.Username = user.Username
+	assert.NoError(t, restarted.Restore(info))
+	account := restarted.Account(user.Username)
+	assert.Equal(t, account.Cash, 50.)
+	assert.Equal(t, account.Positions["pair"], int64(5))
+
+	// reduce-only order closes restored position and ids don't collide
+	closed, err := restarted.AddOrder(domain.StockMarketEvent{Signal: domain.CloseLong,
+		Name: "pair", Volume: 5, Close: 20}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, closed.Amount, int64(5))
+	assert.NotEqual(t, closed.OrderID, info.OrderID)
+	assert.Equal(t, restarted.Account(user.Username).Cash, 150.)
+
+	assert.Error(t, restarted.Restore(domain.OrderInfo{OrderID: "kraken-id",
+		Username: user.Username, Side: string(domain.Buy)}))
+	assert.Error(t, restarted.Restore(domain.OrderInfo{OrderID: info.OrderID,
+		Username: user.Username, Side: string(domain.WaitToBuy)}))
+}
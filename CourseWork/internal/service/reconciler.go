@@ -0,0 +1,314 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultReconcileInterval = 5 * time.Minute
+	// reconcileDelay leaves the latest fills for the next check,
+	// so orders, which are being stored, aren't reported as unknown.
+	reconcileDelay = 30 * time.Second
+)
+
+// AccountAPI describes stock market's account methods used by reconciliation.
+type AccountAPI interface {
+	Fills(*domain.User) ([]domain.Fill, error)
+	OpenPositions(*domain.User) ([]domain.OpenPosition, error)
+	Accounts(*domain.User) (map[string]domain.Account, error)
+}
+
+// Reconciler periodically compares stored orders and Ledger positions of users
+// with live subscriptions with stock market's fills and open positions.
+// Mismatched orders are marked in OrderRepository and users are alerted.
+// Resting orders filled after they were stored are applied to Ledger and OrderRepository.
+type Reconciler struct {
+	Interval time.Duration
+	users    UserRepository
+	orders   OrderRepository
+	api      AccountAPI
+	ledger   *Ledger
+	modes    *TradingModes
+	writers  *MessageWriters
+	muState  *sync.Mutex
+	checked  map[string]time.Time
+	alerted  map[string]map[string]struct{}
+	now      func() time.Time
+	stop     chan struct{}
+	done     chan struct{}
+	log      *logrus.Logger
+}
+
+// NewReconciler returns pointer to Reconciler with default interval.
+func NewReconciler(users UserRepository, orders OrderRepository, api AccountAPI,
+	ledger *Ledger, modes *TradingModes, writers *MessageWriters, log *logrus.Logger) *Reconciler {
+	return &Reconciler{
+		Interval: DefaultReconcileInterval,
+		users:    users,
+		orders:   orders,
+		api:      api,
+		ledger:   ledger,
+		modes:    modes,
+		writers:  writers,
+		muState:  &sync.Mutex{},
+		checked:  make(map[string]time.Time),
+		alerted:  make(map[string]map[string]struct{}),
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		log:      log,
+	}
+}
+
+// Run reconciles users every Interval until Shutdown.
+func (reconciler *Reconciler) Run() {
+	go func() {
+		ticker := time.NewTicker(reconciler.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-reconciler.stop:
+				close(reconciler.done)
+				return
+			case <-ticker.C:
+				reconciler.ReconcileAll()
+			}
+		}
+	}()
+}
+
+// Shutdown stops running reconciliation.
+func (reconciler *Reconciler) Shutdown() {
+	close(reconciler.stop)
+	<-reconciler.done
+}
+
+// ReconcileAll reconciles every user with live subscriptions.
+func (reconciler *Reconciler) ReconcileAll() {
+	subscriptions, err := reconciler.users.GetSubscriptions()
+	if err != nil {
+		reconciler.log.Printf("RECONCILE: can't get subscriptions <%s>", err)
+		return
+	}
+	usernames := make(map[string]struct{})
+	for _, subscription := range subscriptions {
+		if subscription.Config.Mode == domain.PaperMode {
+			continue
+		}
+		if _, ok := usernames[subscription.Username]; ok {
+			continue
+		}
+		usernames[subscription.Username] = struct{}{}
+		if _, err = reconciler.Reconcile(subscription.Username); err != nil {
+			reconciler.log.Printf("RECONCILE: user <%s> <%s>", subscription.Username, err)
+		}
+	}
+}
+
+// Reconcile compares user's new fills with stored orders and open positions with
+// Ledger. Only new mismatches are sent to user.
+func (reconciler *Reconciler) Reconcile(username string) (domain.Reconciliation, error) {
+	user, err := reconciler.users.GetUser(username)
+	if err != nil {
+		return domain.Reconciliation{}, fmt.Errorf("can't reconcile <%w>", err)
+	}
+	fills, err := reconciler.api.Fills(user)
+	if err != nil {
+		return domain.Reconciliation{}, fmt.Errorf("can't reconcile fills <%w>", err)
+	}
+	positions, err := reconciler.api.OpenPositions(user)
+	if err != nil {
+		return domain.Reconciliation{}, fmt.Errorf("can't reconcile positions <%w>", err)
+	}
+	accounts, err := reconciler.api.Accounts(user)
+	if err != nil {
+		return domain.Reconciliation{}, fmt.Errorf("can't reconcile accounts <%w>", err)
+	}
+
+	reconciler.muState.Lock()
+	since := reconciler.checked[username]
+	reconciler.muState.Unlock()
+	until := reconciler.now().Add(-reconcileDelay)
+	fillMismatches, count, err := reconciler.reconcileFills(user, fills, since, until)
+	if err != nil {
+		return domain.Reconciliation{}, err
+	}
+	report := domain.Reconciliation{
+		Username:   username,
+		CheckedAt:  reconciler.now(),
+		Fills:      count,
+		Mismatches: append(fillMismatches, reconciler.reconcilePositions(user, positions)...),
+		Accounts:   accounts,
+	}
+
+	reconciler.muState.Lock()
+	reconciler.checked[username] = until
+	alerted := make(map[string]struct{}, len(report.Mismatches))
+	messages := make([]string, 0)
+	for _, mismatch := range report.Mismatches {
+		message := mismatch.String()
+		if _, ok := reconciler.alerted[username][message]; !ok {
+			messages = append(messages, message)
+		}
+		alerted[message] = struct{}{}
+	}
+	reconciler.alerted[username] = alerted
+	reconciler.muState.Unlock()
+	for _, message := range messages {
+		reconciler.log.Printf("RECONCILE: user <%s> %s", username, message)
+		reconciler.writers.WriteErrors("reconciliation: "+message, *user)
+	}
+	return report, nil
+}
+
+// reconcileFills compares fills of orders, which were filled in (since, until],
+// with stored orders' amount and marks mismatched orders.
+// All fetched fills of such orders are counted, because order could be filled in parts.
+// Resting order's fills beyond its stored amount are late fills, they aren't mismatches.
+func (reconciler *Reconciler) reconcileFills(user *domain.User, fills []domain.Fill,
+	since, until time.Time) ([]domain.Mismatch, int, error) {
+	username := user.Username
+	filled := make(map[string]float64)
+	orderFills := make(map[string][]domain.Fill)
+	symbols := make(map[string]string)
+	orderIDs := make([]string, 0)
+	var count int
+	for _, fill := range fills {
+		filled[fill.OrderID] += fill.Size
+		orderFills[fill.OrderID] = append(orderFills[fill.OrderID], fill)
+		symbols[fill.OrderID] = strings.ToUpper(fill.Symbol)
+		if fill.FillTime.After(since) && !fill.FillTime.After(until) {
+			count++
+			orderIDs = append(orderIDs, fill.OrderID)
+		}
+	}
+	mismatches := make([]domain.Mismatch, 0)
+	checked := make(map[string]struct{})
+	for _, orderID := range orderIDs {
+		if _, ok := checked[orderID]; ok {
+			continue
+		}
+		checked[orderID] = struct{}{}
+		page, err := reconciler.orders.GetOrders(domain.OrderFilter{
+			Username: username,
+			OrderID:  orderID,
+			Limit:    1,
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("can't reconcile fills <%w>", err)
+		}
+		mismatch := domain.Mismatch{
+			Kind:     domain.UnknownFill,
+			PairName: symbols[orderID],
+			OrderID:  orderID,
+			Actual:   filled[orderID],
+		}
+		if len(page.Orders) != 0 {
+			stored := page.Orders[0]
+			mismatch.Kind = domain.FillAmountMismatch
+			mismatch.Expected = float64(stored.Amount)
+			if mismatch.Expected == mismatch.Actual {
+				continue
+			}
+			if stored.Type.IsResting() && mismatch.Actual > mismatch.Expected {
+				err = reconciler.applyLateFills(user, stored, orderFills[orderID])
+				if err == nil {
+					continue
+				}
+				reconciler.log.Printf("RECONCILE: late fills of order <%s> aren't applied <%s>",
+					orderID, err)
+			}
+			if err = reconciler.orders.MarkMismatch(username, orderID,
+				mismatch.String()); err != nil {
+				reconciler.log.Printf("DB: mismatch isn't marked <%s>", err)
+			}
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	return mismatches, count, nil
+}
+
+// applyLateFills applies fills of resting order beyond its stored amount to OrderRepository
+// and Ledger, then user is notified. Stored amount covers the oldest fills.
+func (reconciler *Reconciler) applyLateFills(user *domain.User, stored domain.OrderInfo,
+	fills []domain.Fill) error {
+	sort.Slice(fills, func(i, j int) bool {
+		return fills[i].FillTime.Before(fills[j].FillTime)
+	})
+	covered := float64(stored.Amount)
+	var late, lateNotional, total, totalNotional float64
+	for _, fill := range fills {
+		size := math.Max(fill.Size-covered, 0)
+		covered = math.Max(covered-fill.Size, 0)
+		late += size
+		lateNotional += size * fill.Price
+		total += fill.Size
+		totalNotional += fill.Size * fill.Price
+	}
+	if late < 1 {
+		return fmt.Errorf("late fills are less than a contract")
+	}
+	amount := int64(late)
+	if err := reconciler.orders.SetFill(user.Username, stored.OrderID, stored.Amount+amount,
+		totalNotional/total); err != nil {
+		return err
+	}
+	info := stored
+	info.Username = user.Username
+	info.Amount = amount
+	info.Price = lateNotional / late
+	position, err := reconciler.ledger.Fill(info)
+	if err != nil {
+		return err
+	}
+	info.Position = &position
+	reconciler.log.Printf("RECONCILE: <%d> late contracts of order <%s> of user <%s> are applied",
+		amount, stored.OrderID, user.Username)
+	reconciler.writers.WriteMessages(info, *user)
+	return nil
+}
+
+// reconcilePositions compares stock market's positions with Ledger positions,
+// pairs in paper mode aren't compared.
+func (reconciler *Reconciler) reconcilePositions(user *domain.User,
+	positions []domain.OpenPosition) []domain.Mismatch {
+	actual := make(map[string]int64)
+	for _, position := range positions {
+		actual[strings.ToUpper(position.Symbol)] += position.SignedSize()
+	}
+	expected := make(map[string]int64)
+	for _, position := range reconciler.ledger.Portfolio(user.Username).Positions {
+		expected[strings.ToUpper(position.PairName)] = position.Size
+	}
+	pairNames := make([]string, 0, len(actual)+len(expected))
+	for pairName := range actual {
+		pairNames = append(pairNames, pairName)
+	}
+	for pairName := range expected {
+		if _, ok := actual[pairName]; !ok {
+			pairNames = append(pairNames, pairName)
+		}
+	}
+	sort.Strings(pairNames)
+	mismatches := make([]domain.Mismatch, 0)
+	for _, pairName := range pairNames {
+		if reconciler.modes.Mode(user.Username, pairName) == domain.PaperMode || actual[pairName] == expected[pairName] {
+			continue
+		}
+		mismatches = append(mismatches, domain.Mismatch{
+			Kind:     domain.PositionSizeMismatch,
+			PairName: pairName,
+			Expected: float64(expected[pairName]),
+			Actual:   float64(actual[pairName]),
+		})
+	}
+	return mismatches
+}
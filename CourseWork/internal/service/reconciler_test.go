@@ -0,0 +1,103 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/fakekraken"
+	mock_service "github.com/agandreev/tfs-go-hw/CourseWork/internal/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconciler_Reconcile(t *testing.T) {
+	server := fakekraken.NewServer()
+	defer server.Close()
+	privateKey := base64.StdEncoding.EncodeToString([]byte("private"))
+	server.AddKey("public", privateKey)
+	keeper := newKeeper()
+	user := domain.NewUser("username")
+	user.PublicKey = "public"
+	sealedKey, err := keeper.Seal([]byte(privateKey))
+	assert.NoError(t, err)
+	user.PrivateKey = sealedKey
+
+	filledAt := time.Now().Add(-time.Hour)
+	server.AddFill("public", domain.Fill{FillID: "1", Symbol: "pi_xbtusd", Side: "buy",
+		OrderID: "matched", Size: 1, Price: 10, FillTime: filledAt})
+	server.AddFill("public", domain.Fill{FillID: "2", Symbol: "pi_xbtusd", Side: "buy",
+		OrderID: "partial", Size: 1, Price: 10, FillTime: filledAt})
+	server.AddFill("public", domain.Fill{FillID: "3", Symbol: "pi_xbtusd", Side: "buy",
+		OrderID: "partial", Size: 2, Price: 10, FillTime: filledAt})
+	server.AddFill("public", domain.Fill{FillID: "4", Symbol: "pi_xbtusd", Side: "buy",
+		OrderID: "unknown", Size: 1, Price: 10, FillTime: filledAt})
+	// resting order is filled after it was stored
+	server.AddFill("public", domain.Fill{FillID: "6", Symbol: "pi_xbtusd", Side: "buy",
+		OrderID: "resting", Size: 2, Price: 12, FillTime: filledAt})
+	// fill is too fresh, it's checked next time
+	server.AddFill("public", domain.Fill{FillID: "5", Symbol: "pi_ethusd", Side: "sell",
+		OrderID: "fresh", Size: 1, Price: 10, FillTime: time.Now()})
+	server.SetAccounts("public", map[string]domain.Account{
+		"fi_xbtusd": {Type: "marginAccount", Currency: "xbt", Auxiliary: &domain.Auxiliary{PV: 1}},
+	})
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	users := mock_service.NewMockUserRepository(c)
+	users.EXPECT().GetUser(user.Username).Return(user, nil).Times(2)
+	orders := mock_service.NewMockOrderRepository(c)
+	orders.EXPECT().GetOrders(domain.OrderFilter{Username: user.Username, OrderID: "matched",
+		Limit: 1}).Return(domain.OrderPage{Orders: []domain.OrderInfo{{OrderID: "matched",
+		Amount: 1}}}, nil)
+	orders.EXPECT().GetOrders(domain.OrderFilter{Username: user.Username, OrderID: "partial",
+		Limit: 1}).Return(domain.OrderPage{Orders: []domain.OrderInfo{{OrderID: "partial",
+		Amount: 2}}}, nil)
+	orders.EXPECT().GetOrders(domain.OrderFilter{Username: user.Username, OrderID: "resting",
+		Limit: 1}).Return(domain.OrderPage{Orders: []domain.OrderInfo{{OrderID: "resting",
+		Name: "PI_XBTUSD", Side: string(domain.Buy), Type: domain.LimitOrder, Price: 11}}}, nil)
+	orders.EXPECT().SetFill(user.Username, "resting", int64(2), 12.).Return(nil)
+	orders.EXPECT().GetOrders(domain.OrderFilter{Username: user.Username, OrderID: "unknown",
+		Limit: 1}).Return(domain.OrderPage{}, nil)
+	partial := domain.Mismatch{Kind: domain.FillAmountMismatch, PairName: "PI_XBTUSD",
+		OrderID: "partial", Expected: 2, Actual: 3}
+	orders.EXPECT().MarkMismatch(user.Username, "partial", partial.String()).Return(nil)
+	writer := mock_service.NewMockMessageWriter(c)
+	writer.EXPECT().WriteError(gomock.Any(), *user).Return(nil).Times(4)
+	writer.EXPECT().WriteMessage(gomock.Any(), *user).DoAndReturn(
+		func(info domain.OrderInfo, _ domain.User) error {
+			assert.Equal(t, int64(2), info.Amount)
+			assert.Equal(t, int64(6), info.Position.Size)
+			return nil
+		})
+
+	log := logrus.New()
+	writers := NewMessageWriters(log)
+	writers.AddWriter(writer)
+	ledger := NewLedger()
+	_, err = ledger.Fill(domain.OrderInfo{Username: user.Username, Name: "PI_XBTUSD",
+		Side: string(domain.Buy), Price: 10, Amount: 4})
+	assert.NoError(t, err)
+	reconciler := NewReconciler(users, orders, NewKrakenAPI(server.OrderURL(), keeper),
+		ledger, NewTradingModes(), writers, log)
+
+	report, err := reconciler.Reconcile(user.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.Fills)
+	assert.Equal(t, []domain.Mismatch{
+		partial,
+		{Kind: domain.UnknownFill, PairName: "PI_XBTUSD", OrderID: "unknown", Actual: 1},
+		{Kind: domain.PositionSizeMismatch, PairName: "PI_ETHUSD", Actual: -1},
+		{Kind: domain.PositionSizeMismatch, PairName: "PI_XBTUSD", Expected: 6, Actual: 7},
+	}, report.Mismatches)
+	assert.Equal(t, 1., report.Accounts["fi_xbtusd"].Auxiliary.PV)
+
+	// the same mismatches aren't alerted again and old fills aren't checked
+	reconciler.now = func() time.Time { return time.Now().Add(-time.Minute) }
+	report, err = reconciler.Reconcile(user.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Fills)
+	assert.Len(t, report.Mismatches, 2)
+}
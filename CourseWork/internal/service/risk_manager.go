@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+var (
+	ErrUserPaused      = errors.New("user's pairs are paused by daily loss")
+	ErrMaxDailyOrders  = errors.New("max daily orders quantity is reached")
+	ErrMaxPositionSize = errors.New("max position size is reached")
+)
+
+// RiskManager checks orders by users' RiskLimits before sending them to stock market.
+type RiskManager struct {
+	muDays *sync.Mutex
+	days   map[string]*riskDay
+	now    func() time.Time
+}
+
+// riskDay describes user's daily counters.
+type riskDay struct {
+	day      time.Time
+	orders   int64
+	startPnL float64
+	paused   bool
+}
+
+// NewRiskManager returns pointer to RiskManager.
+func NewRiskManager() *RiskManager {
+	return &RiskManager{
+		muDays: &sync.Mutex{},
+		days:   make(map[string]*riskDay),
+		now:    time.Now,
+	}
+}
+
+// Check validates order event by user's limits and clips its volume to max position.
+// Paused user and user with max daily orders can only close positions,
+// reverse order is shrunk to its closing part then.
+// totalPnL is user's current PnL, it starts daily loss counting.
+// Order is counted into daily orders by Count, when it is placed.
+func (manager *RiskManager) Check(event domain.StockMarketEvent, user *domain.User,
+	position domain.Position, totalPnL float64) (domain.StockMarketEvent, error) {
+	limits := user.GetRisk()
+	manager.muDays.Lock()
+	defer manager.muDays.Unlock()
+	day := manager.day(user.Username, totalPnL)
+	if day.paused {
+		return exitEvent(event, position.Size, ErrUserPaused)
+	}
+	if limits.MaxDailyOrders > 0 && day.orders >= limits.MaxDailyOrders {
+		return exitEvent(event, position.Size, ErrMaxDailyOrders)
+	}
+	if limits.MaxPosition > 0 {
+		volume, err := clipVolume(event.Signal, orderVolume(event.Volume),
+			position.Size, limits.MaxPosition)
+		if err != nil {
+			return event, err
+		}
+		event.Volume = volume
+	}
+	return event, nil
+}
+
+// Count counts user's placed order into today's orders.
+func (manager *RiskManager) Count(username string) {
+	manager.muDays.Lock()
+	defer manager.muDays.Unlock()
+	if day, ok := manager.days[username]; ok && day.day.Equal(manager.today()) {
+		day.orders++
+	}
+}
+
+// CheckLoss pauses user if loss since the start of the day exceeds max daily loss.
+// It returns true only when user becomes paused.
+func (manager *RiskManager) CheckLoss(user *domain.User, totalPnL float64) bool {
+	maxDailyLoss := user.GetRisk().MaxDailyLoss
+	if maxDailyLoss <= 0 {
+		return false
+	}
+	manager.muDays.Lock()
+	defer manager.muDays.Unlock()
+	day := manager.day(user.Username, totalPnL)
+	if day.paused || day.startPnL-totalPnL < maxDailyLoss {
+		return false
+	}
+	day.paused = true
+	return true
+}
+
+// IsPaused checks if user is paused today.
+func (manager *RiskManager) IsPaused(username string) bool {
+	manager.muDays.Lock()
+	defer manager.muDays.Unlock()
+	day, ok := manager.days[username]
+	return ok && day.paused && day.day.Equal(manager.today())
+}
+
+// day returns user's counters and resets them on the next day.
+func (manager *RiskManager) day(username string, totalPnL float64) *riskDay {
+	today := manager.today()
+	day, ok := manager.days[username]
+	if !ok || !day.day.Equal(today) {
+		day = &riskDay{day: today, startPnL: totalPnL}
+		manager.days[username] = day
+	}
+	return day
+}
+
+// today returns start of the current UTC day.
+func (manager *RiskManager) today() time.Time {
+	return manager.now().UTC().Truncate(24 * time.Hour)
+}
+
+// exitEvent returns reduce-only order event as is and shrinks reverse order event to
+// the whole position's close. Event, which doesn't close position, is rejected by err.
+func exitEvent(event domain.StockMarketEvent, size int64,
+	err error) (domain.StockMarketEvent, error) {
+	if event.Signal.IsReduceOnly() {
+		return event, nil
+	}
+	exit, ok := event.Signal.Exit()
+	if !ok || exit.OrderVolume(0, size) == 0 {
+		return event, err
+	}
+	event.Signal = exit
+	event.Volume = exit.OrderVolume(0, size)
+	return event, nil
+}
+
+// clipVolume cuts order volume, which increases position over maxPosition.
+func clipVolume(signal domain.Signal, volume, size, maxPosition int64) (int64, error) {
+	var next int64
+	switch signal.Side() {
+	case domain.Buy:
+		next = size + volume
+	case domain.Sell:
+		next = size - volume
+	default:
+		return 0, fmt.Errorf("unsupported order side <%s>", signal)
+	}
+	if abs(next) <= maxPosition || abs(next) <= abs(size) {
+		return volume, nil
+	}
+	allowed := volume - (abs(next) - maxPosition)
+	if allowed <= 0 {
+		return 0, ErrMaxPositionSize
+	}
+	return allowed, nil
+}
+
+// abs returns absolute value of int64.
+func abs(value int64) int64 {
+	return int64(math.Abs(float64(value)))
+}
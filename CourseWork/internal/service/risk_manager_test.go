@@ -0,0 +1,121 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskManager_Check(t *testing.T) {
+	manager := NewRiskManager()
+	now := time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time { return now }
+	user := domain.NewUser("username")
+	user.Risk = domain.RiskLimits{MaxPosition: 3, MaxDailyOrders: 2}
+	event := domain.StockMarketEvent{Signal: domain.Buy, Volume: 5}
+
+	checked, err := manager.Check(event, user, domain.Position{Size: 1}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, checked.Volume, int64(2))
+	manager.Count(user.Username)
+	_, err = manager.Check(event, user, domain.Position{Size: 3}, 0)
+	assert.ErrorIs(t, err, ErrMaxPositionSize)
+	event.Signal = domain.Sell
+	checked, err = manager.Check(event, user, domain.Position{Size: 3}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, checked.Volume, int64(5))
+	// order, which isn't placed, isn't counted
+	_, err = manager.Check(event, user, domain.Position{}, 0)
+	assert.NoError(t, err)
+	manager.Count(user.Username)
+	_, err = manager.Check(event, user, domain.Position{}, 0)
+	assert.ErrorIs(t, err, ErrMaxDailyOrders)
+
+	// counters are reset on the next day
+	now = now.Add(24 * time.Hour)
+	_, err = manager.Check(event, user, domain.Position{}, 0)
+	assert.NoError(t, err)
+}
+
+func TestRiskManager_CheckExit(t *testing.T) {
+	manager := NewRiskManager()
+	now := time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time { return now }
+	capped := domain.NewUser("capped")
+	capped.Risk = domain.RiskLimits{MaxDailyOrders: 1}
+	manager.Count(capped.Username)
+	_, err := manager.Check(domain.StockMarketEvent{Signal: domain.OpenLong, Volume: 1},
+		capped, domain.Position{}, 0)
+	assert.NoError(t, err)
+	manager.Count(capped.Username)
+	_, err = manager.Check(domain.StockMarketEvent{Signal: domain.OpenLong, Volume: 1},
+		capped, domain.Position{Size: 1}, 0)
+	assert.ErrorIs(t, err, ErrMaxDailyOrders)
+
+	// capped user closes position
+	checked, err := manager.Check(domain.StockMarketEvent{Signal: domain.CloseLong, Volume: 1},
+		capped, domain.Position{Size: 1}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, checked.Signal, domain.CloseLong)
+	assert.Equal(t, checked.Volume, int64(1))
+
+	paused := domain.NewUser("paused")
+	paused.Risk.MaxDailyLoss = 10
+	assert.False(t, manager.CheckLoss(paused, 0))
+	assert.True(t, manager.CheckLoss(paused, -10))
+	_, err = manager.Check(domain.StockMarketEvent{Signal: domain.OpenShort, Volume: 2},
+		paused, domain.Position{}, -10)
+	assert.ErrorIs(t, err, ErrUserPaused)
+
+	// paused user closes position
+	checked, err = manager.Check(domain.StockMarketEvent{Signal: domain.CloseShort, Volume: 2},
+		paused, domain.Position{Size: -2}, -10)
+	assert.NoError(t, err)
+	assert.Equal(t, checked.Signal, domain.CloseShort)
+	assert.Equal(t, checked.Volume, int64(2))
+
+	// reverse is shrunk to the close of position
+	checked, err = manager.Check(domain.StockMarketEvent{Signal: domain.ReverseToShort, Volume: 5},
+		paused, domain.Position{Size: 3}, -10)
+	assert.NoError(t, err)
+	assert.Equal(t, checked.Signal, domain.CloseLong)
+	assert.Equal(t, checked.Volume, int64(3))
+	_, err = manager.Check(domain.StockMarketEvent{Signal: domain.ReverseToLong, Volume: 5},
+		paused, domain.Position{}, -10)
+	assert.ErrorIs(t, err, ErrUserPaused)
+}
+
+func TestRiskManager_CheckLoss(t *testing.T) {
+	manager := NewRiskManager()
+	now := time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time { return now }
+	user := domain.NewUser("username")
+	assert.False(t, manager.CheckLoss(user, -100))
+
+	user.Risk.MaxDailyLoss = 10
+	assert.False(t, manager.CheckLoss(user, 5))
+	assert.False(t, manager.CheckLoss(user, -4))
+	assert.True(t, manager.CheckLoss(user, -5))
+	assert.False(t, manager.CheckLoss(user, -6))
+	assert.True(t, manager.IsPaused(user.Username))
+	_, err := manager.Check(domain.StockMarketEvent{Signal: domain.Buy}, user,
+		domain.Position{}, -6)
+	assert.ErrorIs(t, err, ErrUserPaused)
+
+	now = now.Add(24 * time.Hour)
+	assert.False(t, manager.IsPaused(user.Username))
+	assert.False(t, manager.CheckLoss(user, -6))
+}
+
+func TestClipVolume(t *testing.T) {
+	volume, err := clipVolume(domain.Sell, 4, -1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, volume, int64(2))
+	volume, err = clipVolume(domain.Buy, 4, -5, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, volume, int64(4))
+	_, err = clipVolume(domain.WaitToBuy, 1, 0, 3)
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+)
+
+// StoredHistory reads candles from CandleRepository and falls back to remote history,
+// if stored candles don't cover the whole period, e.g. after downtime.
+type StoredHistory struct {
+	candles CandleRepository
+	remote  CandleHistory
+	now     func() time.Time
+}
+
+// NewStoredHistory returns pointer to StoredHistory.
+func NewStoredHistory(candles CandleRepository, remote CandleHistory) *StoredHistory {
+	return &StoredHistory{
+		candles: candles,
+		remote:  remote,
+		now:     time.Now,
+	}
+}
+
+// Candles returns pair's candles of interval, which started at from or later.
+func (history *StoredHistory) Candles(pairName string, interval domain.CandleInterval,
+	from int64) ([]domain.Candle, error) {
+	stored, err := history.candles.GetCandles(domain.CandleFilter{
+		PairName: pairName,
+		Interval: interval,
+		From:     time.UnixMilli(from),
+		Limit:    domain.MaxCandlesLimit,
+	})
+	if err == nil && history.covers(stored, interval, from) {
+		return stored, nil
+	}
+	return history.remote.Candles(pairName, interval, from)
+}
+
+// covers checks that stored candles start at from, have no gaps and
+// the last one is closed just before the current candle.
+func (history *StoredHistory) covers(candles []domain.Candle,
+	interval domain.CandleInterval, from int64) bool {
+	duration, err := interval.Duration()
+	if err != nil || len(candles) == 0 {
+		return false
+	}
+	step := duration.Milliseconds()
+	first := candles[0].Time
+	last := candles[len(candles)-1].Time
+	if first >= from+step || last < history.now().UnixMilli()-2*step {
+		return false
+	}
+	return (last-first)/step+1 == int64(len(candles))
+}
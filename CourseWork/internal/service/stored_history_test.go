@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agandreev/tfs-go-hw/CourseWork/internal/domain"
+	mock_service "github.com/agandreev/tfs-go-hw/CourseWork/internal/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoredHistory_Candles(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	now := time.UnixMilli(10 * 60000)
+	from := now.Add(-3 * time.Minute).UnixMilli()
+	remoteCandles := []domain.Candle{{Time: 1}}
+	var remoteCalls int
+	remote := historyFunc(func(string, domain.CandleInterval, int64) ([]domain.Candle, error) {
+		remoteCalls++
+		return remoteCandles, nil
+	})
+	candles := mock_service.NewMockCandleRepository(c)
+	history := NewStoredHistory(candles, remote)
+	history.now = func() time.Time { return now }
+	filter := domain.CandleFilter{
+		PairName: "PI_XBTUSD",
+		Interval: domain.Candle1m,
+		From:     time.UnixMilli(from),
+		Limit:    domain.MaxCandlesLimit,
+	}
+
+	stored := []domain.Candle{{Time: 7 * 60000}, {Time: 8 * 60000}, {Time: 9 * 60000}}
+	candles.EXPECT().GetCandles(filter).Return(stored, nil)
+	received, err := history.Candles("PI_XBTUSD", domain.Candle1m, from)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, received)
+	assert.Zero(t, remoteCalls)
+
+	tests := []struct {
+		name   string
+		stored []domain.Candle
+		err    error
+	}{
+		{name: "error", err: errors.New("no connection")},
+		{name: "empty", stored: []domain.Candle{}},
+		{name: "late start", stored: []domain.Candle{{Time: 8 * 60000}, {Time: 9 * 60000}}},
+		{name: "downtime", stored: []domain.Candle{{Time: 7 * 60000}}},
+		{name: "gap", stored: []domain.Candle{{Time: 7 * 60000}, {Time: 9 * 60000}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			remoteCalls = 0
+			candles.EXPECT().GetCandles(filter).Return(test.stored, test.err)
+			received, err = history.Candles("PI_XBTUSD", domain.Candle1m, from)
+			assert.NoError(t, err)
+			assert.Equal(t, remoteCandles, received)
+			assert.Equal(t, 1, remoteCalls)
+		})
+	}
+}
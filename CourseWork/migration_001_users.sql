@@ -0,0 +1,16 @@
+CREATE TABLE users
+(
+    username    TEXT PRIMARY KEY,
+    public_key  TEXT NOT NULL,
+    private_key TEXT NOT NULL,
+    telegram_id BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE subscriptions
+(
+    username      TEXT NOT NULL REFERENCES users (username) ON DELETE CASCADE,
+    pair_name     TEXT NOT NULL,
+    pair_interval TEXT NOT NULL,
+    config        JSONB NOT NULL,
+    PRIMARY KEY (username, pair_name, pair_interval)
+);
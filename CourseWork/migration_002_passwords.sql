@@ -0,0 +1,4 @@
+-- private_key keeps sealed key since now. Users stored before have empty password_hash
+-- and plaintext private_key, they set password and seal the key by POST /auth/migrate.
+ALTER TABLE users
+    ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+ALTER TABLE orders
+    ADD COLUMN username       TEXT        NOT NULL DEFAULT '',
+    ADD COLUMN pair_interval  TEXT        NOT NULL DEFAULT '',
+    ADD COLUMN indicator_name TEXT        NOT NULL DEFAULT '',
+    ADD COLUMN created_at     TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX orders_username_id_idx ON orders (username, id DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN risk JSONB NOT NULL DEFAULT '{}';
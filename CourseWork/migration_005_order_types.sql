@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    ADD COLUMN order_type TEXT    NOT NULL DEFAULT 'ioc',
+    ADD COLUMN size       NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN status     TEXT    NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    ADD COLUMN mismatch TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX orders_username_order_id_idx ON orders (username, orderID);
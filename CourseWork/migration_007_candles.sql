@@ -0,0 +1,12 @@
+CREATE TABLE candles
+(
+    name          TEXT             NOT NULL,
+    pair_interval TEXT             NOT NULL,
+    time          BIGINT           NOT NULL,
+    open          DOUBLE PRECISION NOT NULL,
+    close         DOUBLE PRECISION NOT NULL,
+    high          DOUBLE PRECISION NOT NULL,
+    low           DOUBLE PRECISION NOT NULL,
+    volume        BIGINT           NOT NULL,
+    PRIMARY KEY (name, pair_interval, time)
+);
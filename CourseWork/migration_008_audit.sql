@@ -0,0 +1,17 @@
+CREATE TABLE audit_events
+(
+    id            BIGSERIAL PRIMARY KEY,
+    trace_id      TEXT        NOT NULL,
+    stage         TEXT        NOT NULL,
+    time          TIMESTAMPTZ NOT NULL,
+    username      TEXT        NOT NULL,
+    name          TEXT        NOT NULL,
+    pair_interval TEXT        NOT NULL,
+    order_id      TEXT        NOT NULL DEFAULT '',
+    error         TEXT        NOT NULL DEFAULT '',
+    data          JSONB
+);
+
+CREATE INDEX audit_events_username_time_idx ON audit_events (username, time);
+CREATE INDEX audit_events_trace_id_idx ON audit_events (trace_id);
+CREATE INDEX audit_events_username_order_id_idx ON audit_events (username, order_id);